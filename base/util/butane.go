@@ -0,0 +1,89 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/coreos/butane/config/common"
+	"github.com/coreos/butane/internal/butanelocal"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+)
+
+// TranslateButaneLocal reads the Butane config at configPath from the
+// files directories and translates it to an Ignition config using
+// the translator set by config.TranslateBytes.  Entries in the child config's report are
+// wrapped in ErrChildConfig and reported at c.
+func TranslateButaneLocal(c path.ContextPath, configPath string, options common.TranslateOptions) ([]byte, report.Report, error) {
+	var r report.Report
+	translator := butanelocal.GetTranslator(options)
+	if translator == nil {
+		r.AddOnError(c, common.ErrNoButaneTranslator)
+		return nil, r, common.ErrNoButaneTranslator
+	}
+	filePath, dir, err := ResolveLocalPath(configPath, "", options)
+	if err != nil {
+		r.AddOnError(c, err)
+		return nil, r, err
+	}
+	key := filePath
+	if dir.FS == nil {
+		if abs, err := filepath.Abs(filePath); err == nil {
+			key = abs
+		}
+	} else {
+		key = dir.String() + ":" + filePath
+	}
+	if slices.Contains(butanelocal.Parents(options), key) {
+		r.AddOnError(c, common.ErrButaneLocalLoop)
+		return nil, r, common.ErrButaneLocalLoop
+	}
+	input, err := ReadLocalPath(dir, filePath)
+	if err != nil {
+		r.AddOnError(c, err)
+		return nil, r, err
+	}
+	r.AddOnInfo(c, LocalFileSource(dir, options))
+
+	childOptions := butanelocal.WithParent(options, key)
+	// the child's fields aren't in our output
+	childOptions.SourceMap = nil
+	output, childReport, err := translator(input, common.TranslateBytesOptions{
+		TranslateOptions: childOptions,
+		// child configs are always Ignition, never a MachineConfig
+		Raw: true,
+	})
+	for _, entry := range childReport.Entries {
+		childErr := common.ErrChildConfig{
+			File:    configPath,
+			Message: entry.Message,
+		}
+		if entry.Marker.StartP != nil {
+			childErr.Line = entry.Marker.StartP.Line
+			childErr.Column = entry.Marker.StartP.Column
+		}
+		if entry.Context.Len() > 0 {
+			childErr.Path = entry.Context.String()
+		}
+		r.AddOn(c, childErr, entry.Kind)
+	}
+	if err != nil && !r.IsFatal() {
+		r.AddOnError(c, err)
+	}
+	return output, r, err
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/coreos/butane/config/common"
+	"github.com/coreos/butane/translate"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+)
+
+// TreeEncoder reads the files of a storage.trees entry and encodes them
+// as data URLs on a bounded pool of goroutines.  Encoded files, and
+// errors found while walking the tree, are applied by Wait in walk
+// order, so neither the output nor the report depends on scheduling.
+type TreeEncoder struct {
+	yamlPath path.ContextPath
+	ts       *translate.TranslationSet
+	r        *report.Report
+	options  common.TranslateOptions
+	slots    chan struct{}
+	jobs     []*encodeJob
+}
+
+type encodeJob struct {
+	done  chan struct{}
+	apply func()
+}
+
+// TreeFile points to the fields of an output file that a TreeEncoder
+// fills in.
+type TreeFile struct {
+	Source      **string
+	Compression **string
+	Mode        **int
+}
+
+// NewTreeEncoder returns a TreeEncoder for the tree at yamlPath, which
+// records translations in ts and errors in r, and encodes with the given
+// options, running at most GOMAXPROCS encodings at a time.
+func NewTreeEncoder(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report.Report, options common.TranslateOptions) *TreeEncoder {
+	return &TreeEncoder{
+		yamlPath: yamlPath,
+		ts:       ts,
+		r:        r,
+		options:  options,
+		slots:    make(chan struct{}, runtime.GOMAXPROCS(0)),
+	}
+}
+
+// AddError schedules err to be reported at the tree, after the files
+// added before it.
+func (e *TreeEncoder) AddError(err error) {
+	job := &encodeJob{
+		done: make(chan struct{}),
+		apply: func() {
+			e.r.AddOnError(e.yamlPath, err)
+		},
+	}
+	close(job.done)
+	e.jobs = append(e.jobs, job)
+}
+
+// AddFile schedules srcPath in dir to be read and encoded as the
+// contents of entry i of storage.files, blocking while all workers are
+// busy.  Wait stores the result in the fields returned by file, which
+// is called then because pointers into the config may have been
+// invalidated in the meantime, and sets the file's mode to mode if it
+// doesn't have one.
+func (e *TreeEncoder) AddFile(dir common.FilesDirEntry, srcPath string, i int, currentCompression *string, mode int, file func() TreeFile) {
+	var (
+		uri         string
+		compression *string
+		err         error
+	)
+	job := &encodeJob{
+		done: make(chan struct{}),
+		apply: func() {
+			if err != nil {
+				e.r.AddOnError(e.yamlPath, err)
+				return
+			}
+			f := file()
+			filePath := path.New("json", "storage", "files", i)
+			*f.Source = &uri
+			e.ts.AddTranslation(e.yamlPath, filePath.Append("contents", "source"))
+			if compression != nil {
+				*f.Compression = compression
+				e.ts.AddTranslation(e.yamlPath, filePath.Append("contents", "compression"))
+			}
+			e.ts.AddTranslation(e.yamlPath, filePath.Append("contents"))
+			if *f.Mode == nil {
+				*f.Mode = &mode
+				e.ts.AddTranslation(e.yamlPath, filePath.Append("mode"))
+			}
+		},
+	}
+	e.jobs = append(e.jobs, job)
+	e.slots <- struct{}{}
+	go func() {
+		defer func() {
+			<-e.slots
+			close(job.done)
+		}()
+		var contents []byte
+		if contents, err = ReadLocalPath(dir, srcPath); err != nil {
+			return
+		}
+		uri, compression, err = MakeDataURLCached(contents, currentCompression, !e.options.NoResourceAutoCompression, e.options.DataURLCache)
+	}()
+}
+
+// Wait waits for all scheduled files to be encoded, then applies them
+// and the scheduled errors in the order they were added.
+func (e *TreeEncoder) Wait() {
+	for _, job := range e.jobs {
+		<-job.done
+		job.apply()
+	}
+	e.jobs = nil
+}
+
+// TreeFileMode returns the mode of a file read from a tree: fileMode if
+// specified, otherwise 0755 for executables and 0644 for other files.
+func TreeFileMode(info os.FileInfo, fileMode *int) int {
+	if fileMode != nil {
+		return *fileMode
+	}
+	if info.Mode()&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
@@ -15,7 +15,11 @@
 package util
 
 import (
+	"context"
+	"errors"
+	"io/fs"
 	"os"
+	slashpath "path"
 	"path/filepath"
 	"strings"
 
@@ -37,17 +41,232 @@ func EnsurePathWithinFilesDir(path, filesDir string) error {
 	return nil
 }
 
-func ReadLocalFile(configPath, filesDir string) ([]byte, error) {
-	if filesDir == "" {
+// FilesDirs returns the list of files directories configured in options,
+// in search order.
+func FilesDirs(options common.TranslateOptions) []common.FilesDirEntry {
+	var dirs []common.FilesDirEntry
+	if options.FilesDir != "" {
+		dirs = append(dirs, common.FilesDirEntry{Path: options.FilesDir})
+	}
+	if options.FilesFS != nil {
+		dirs = append(dirs, common.FilesDirEntry{FS: options.FilesFS})
+	}
+	for _, dir := range options.FilesDirs {
+		if dir.Path != "" || dir.FS != nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// ResolveLocalPath finds configPath within the files directories
+// configured in options, checking for path traversal.  If root is
+// non-empty, only the files directory with that name is considered.
+// Otherwise, the files directories are searched in order and the first
+// one containing configPath is used; if options.FilesDirStrict is set, it
+// is an error for configPath to exist in more than one of them.  If
+// configPath doesn't exist in any of them, the path within the first
+// candidate directory is returned so the caller's subsequent I/O will
+// report a useful error.  Returns the resolved path and the files
+// directory containing it.  For files directories backed by an fs.FS,
+// the path is relative to the root of the FS; otherwise it's a path in
+// the host filesystem.  Either way, it should be accessed with the other
+// local path helpers in this file.
+func ResolveLocalPath(configPath, root string, options common.TranslateOptions) (string, common.FilesDirEntry, error) {
+	dirs := FilesDirs(options)
+	if len(dirs) == 0 {
 		// a files dir isn't configured; refuse to read anything
-		return nil, common.ErrNoFilesDir
+		return "", common.FilesDirEntry{}, common.ErrNoFilesDir
+	}
+	if root != "" {
+		var found []common.FilesDirEntry
+		for _, dir := range dirs {
+			if dir.Name == root {
+				found = append(found, dir)
+			}
+		}
+		if len(found) == 0 {
+			return "", common.FilesDirEntry{}, common.ErrUnknownFilesDir{Name: root}
+		}
+		dirs = found
+	}
+
+	var candidate string
+	var candidateDir common.FilesDirEntry
+	for _, dir := range dirs {
+		// calculate file path within the files dir and check for
+		// path traversal
+		filePath, err := localPath(dir, configPath)
+		if err != nil {
+			return "", common.FilesDirEntry{}, err
+		}
+		if _, err := LstatLocalPath(dir, filePath); err != nil {
+			continue
+		}
+		if candidate == "" {
+			candidate = filePath
+			candidateDir = dir
+			if !options.FilesDirStrict {
+				break
+			}
+		} else {
+			return "", common.FilesDirEntry{}, common.ErrAmbiguousLocalPath{
+				Dirs: []string{candidateDir.String(), dir.String()},
+			}
+		}
+	}
+	if candidate == "" {
+		candidateDir = dirs[0]
+		candidate, _ = localPath(candidateDir, configPath)
+	}
+	return candidate, candidateDir, nil
+}
+
+// localPath returns the path of configPath within dir, or
+// ErrFilesDirEscape if configPath points outside dir.
+func localPath(dir common.FilesDirEntry, configPath string) (string, error) {
+	if dir.FS == nil {
+		filePath := filepath.Join(dir.Path, filepath.FromSlash(configPath))
+		if err := EnsurePathWithinFilesDir(filePath, dir.Path); err != nil {
+			return "", err
+		}
+		return filePath, nil
+	}
+	// Mirror the host filesystem semantics by joining to a stand-in
+	// for the root directory.
+	const root = "root"
+	joined := slashpath.Join(root, filepath.ToSlash(configPath))
+	if joined == root {
+		return ".", nil
+	}
+	if !strings.HasPrefix(joined, root+"/") {
+		return "", common.ErrFilesDirEscape
+	}
+	return strings.TrimPrefix(joined, root+"/"), nil
+}
+
+// ReadLocalFile reads configPath from filesDir.
+func ReadLocalFile(configPath, filesDir string) ([]byte, error) {
+	contents, _, err := ReadLocalFileFromDirs(configPath, common.TranslateOptions{FilesDir: filesDir})
+	return contents, err
+}
+
+// ReadLocalFileFromDirs reads configPath from the files directories
+// configured in options.  It returns the file contents and the files
+// directory the file was read from.
+func ReadLocalFileFromDirs(configPath string, options common.TranslateOptions) ([]byte, common.FilesDirEntry, error) {
+	filePath, dir, err := ResolveLocalPath(configPath, "", options)
+	if err != nil {
+		return nil, dir, err
+	}
+	contents, err := ReadLocalPath(dir, filePath)
+	return contents, dir, err
+}
+
+// ReadLocalPath reads a file at a path returned by ResolveLocalPath.
+func ReadLocalPath(dir common.FilesDirEntry, filePath string) ([]byte, error) {
+	if dir.FS == nil {
+		return os.ReadFile(filePath)
 	}
-	// calculate file path within FilesDir and check for path traversal
-	filePath := filepath.Join(filesDir, filepath.FromSlash(configPath))
-	if err := EnsurePathWithinFilesDir(filePath, filesDir); err != nil {
-		return nil, err
+	return fs.ReadFile(dir.FS, filePath)
+}
+
+// StatLocalPath stats a path returned by ResolveLocalPath, following
+// symlinks.
+func StatLocalPath(dir common.FilesDirEntry, filePath string) (fs.FileInfo, error) {
+	if dir.FS == nil {
+		return os.Stat(filePath)
+	}
+	return fs.Stat(dir.FS, filePath)
+}
+
+// LstatLocalPath stats a path returned by ResolveLocalPath without
+// following symlinks, if the underlying filesystem supports them.
+func LstatLocalPath(dir common.FilesDirEntry, filePath string) (fs.FileInfo, error) {
+	if dir.FS == nil {
+		return os.Lstat(filePath)
+	}
+	if lfs, ok := dir.FS.(common.SymlinkFS); ok {
+		return lfs.Lstat(filePath)
+	}
+	return fs.Stat(dir.FS, filePath)
+}
+
+// ReadLocalLink returns the target of a symlink at a path returned by
+// ResolveLocalPath or WalkLocalPath.
+func ReadLocalLink(dir common.FilesDirEntry, filePath string) (string, error) {
+	if dir.FS == nil {
+		return os.Readlink(filePath)
+	}
+	if lfs, ok := dir.FS.(common.SymlinkFS); ok {
+		return lfs.ReadLink(filePath)
+	}
+	return "", &fs.PathError{Op: "readlink", Path: filePath, Err: errors.ErrUnsupported}
+}
+
+// WalkLocalPath walks the tree rooted at a path returned by
+// ResolveLocalPath, with the semantics of filepath.Walk.  Symlinks are
+// not followed.  The walk stops with ctx's error if ctx is canceled; ctx
+// may be nil.
+func WalkLocalPath(ctx context.Context, dir common.FilesDirEntry, root string, fn filepath.WalkFunc) error {
+	if ctx != nil {
+		walkFn := fn
+		fn = func(path string, info fs.FileInfo, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return walkFn(path, info, err)
+		}
+	}
+	if dir.FS == nil {
+		return filepath.Walk(root, fn)
+	}
+	info, err := LstatLocalPath(dir, root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	err = walkFS(dir, root, info, fn)
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walkFS(dir common.FilesDirEntry, name string, info fs.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(name, info, nil)
+	}
+	entries, err := fs.ReadDir(dir.FS, name)
+	err1 := fn(name, info, err)
+	if err != nil || err1 != nil {
+		return err1
+	}
+	for _, entry := range entries {
+		child := slashpath.Join(name, entry.Name())
+		childInfo, err := LstatLocalPath(dir, child)
+		if err != nil {
+			if err := fn(child, childInfo, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := walkFS(dir, child, childInfo, fn); err != nil {
+			if !childInfo.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LocalFileSource returns an informational report entry describing which
+// files directory a local file was read from, or nil if there's only one
+// files directory and the answer is obvious.
+func LocalFileSource(dir common.FilesDirEntry, options common.TranslateOptions) error {
+	if len(FilesDirs(options)) < 2 || (dir.Path == "" && dir.FS == nil) {
+		return nil
 	}
-	return os.ReadFile(filePath)
+	return common.ErrLocalFileSource{Dir: dir.String()}
 }
 
 // CheckForDecimalMode fails if the specified mode appears to have been
@@ -16,8 +16,13 @@ package util
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
+
+	"github.com/coreos/butane/config/common"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -126,3 +131,173 @@ func TestCheckForDecimalMode(t *testing.T) {
 	assert.Equal(t, expectedBadDirModes, badDirModes, "bad set of decimal directory modes")
 	assert.Equal(t, expectedBadFileModes, badFileModes, "bad set of decimal file modes")
 }
+
+func TestResolveLocalPath(t *testing.T) {
+	site := t.TempDir()
+	shared := t.TempDir()
+	for _, f := range []string{
+		filepath.Join(site, "both"),
+		filepath.Join(shared, "both"),
+		filepath.Join(site, "site-only"),
+		filepath.Join(shared, "shared-only"),
+	} {
+		if err := os.WriteFile(f, []byte(filepath.Base(f)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	siteDir := common.FilesDirEntry{Path: site}
+	sharedDir := common.FilesDirEntry{Name: "shared", Path: shared}
+	memFS := fstest.MapFS{
+		"both":        {Data: []byte("both")},
+		"dir/fs-only": {Data: []byte("fs-only")},
+	}
+	memDir := common.FilesDirEntry{FS: memFS}
+
+	tests := []struct {
+		path    string
+		root    string
+		options common.TranslateOptions
+		outPath string
+		outDir  common.FilesDirEntry
+		err     error
+	}{
+		// no files dir
+		{
+			path: "both",
+			err:  common.ErrNoFilesDir,
+		},
+		// single files dir, legacy option
+		{
+			path:    "both",
+			options: common.TranslateOptions{FilesDir: site},
+			outPath: filepath.Join(site, "both"),
+			outDir:  siteDir,
+		},
+		// first match wins
+		{
+			path: "both",
+			options: common.TranslateOptions{
+				FilesDirs: []common.FilesDirEntry{siteDir, sharedDir},
+			},
+			outPath: filepath.Join(site, "both"),
+			outDir:  siteDir,
+		},
+		// fall through to later dirs
+		{
+			path: "shared-only",
+			options: common.TranslateOptions{
+				FilesDirs: []common.FilesDirEntry{siteDir, sharedDir},
+			},
+			outPath: filepath.Join(shared, "shared-only"),
+			outDir:  sharedDir,
+		},
+		// missing everywhere; return path in first dir
+		{
+			path: "missing",
+			options: common.TranslateOptions{
+				FilesDirs: []common.FilesDirEntry{siteDir, sharedDir},
+			},
+			outPath: filepath.Join(site, "missing"),
+			outDir:  siteDir,
+		},
+		// strict, ambiguous
+		{
+			path: "both",
+			options: common.TranslateOptions{
+				FilesDirs:      []common.FilesDirEntry{siteDir, sharedDir},
+				FilesDirStrict: true,
+			},
+			err: common.ErrAmbiguousLocalPath{Dirs: []string{site, "shared=" + shared}},
+		},
+		// strict, unambiguous
+		{
+			path: "site-only",
+			options: common.TranslateOptions{
+				FilesDirs:      []common.FilesDirEntry{siteDir, sharedDir},
+				FilesDirStrict: true,
+			},
+			outPath: filepath.Join(site, "site-only"),
+			outDir:  siteDir,
+		},
+		// named root
+		{
+			path: "both",
+			root: "shared",
+			options: common.TranslateOptions{
+				FilesDirs: []common.FilesDirEntry{siteDir, sharedDir},
+			},
+			outPath: filepath.Join(shared, "both"),
+			outDir:  sharedDir,
+		},
+		// unknown root
+		{
+			path: "both",
+			root: "other",
+			options: common.TranslateOptions{
+				FilesDirs: []common.FilesDirEntry{siteDir, sharedDir},
+			},
+			err: common.ErrUnknownFilesDir{Name: "other"},
+		},
+		// escape
+		{
+			path: "../both",
+			options: common.TranslateOptions{
+				FilesDirs: []common.FilesDirEntry{siteDir, sharedDir},
+			},
+			err: common.ErrFilesDirEscape,
+		},
+		// fs.FS searched after FilesDir
+		{
+			path: "dir/fs-only",
+			options: common.TranslateOptions{
+				FilesDir: site,
+				FilesFS:  memFS,
+			},
+			outPath: "dir/fs-only",
+			outDir:  memDir,
+		},
+		// fs.FS, unclean path
+		{
+			path: "/dir/../both",
+			options: common.TranslateOptions{
+				FilesFS: memFS,
+			},
+			outPath: "both",
+			outDir:  memDir,
+		},
+		// fs.FS, escape
+		{
+			path: "dir/../../both",
+			options: common.TranslateOptions{
+				FilesFS: memFS,
+			},
+			err: common.ErrFilesDirEscape,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("resolve %d", i), func(t *testing.T) {
+			outPath, outDir, err := ResolveLocalPath(test.path, test.root, test.options)
+			assert.Equal(t, test.err, err, "bad error")
+			if test.err != nil {
+				return
+			}
+			assert.Equal(t, test.outPath, outPath, "bad path")
+			assert.Equal(t, test.outDir, outDir, "bad files dir")
+		})
+	}
+}
+
+func TestReadLocalFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := ReadLocalFile("a", dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(contents))
+	_, err = ReadLocalFile("a", "")
+	assert.Equal(t, common.ErrNoFilesDir, err)
+	_, err = ReadLocalFile("../a", dir)
+	assert.Equal(t, common.ErrFilesDirEscape, err)
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/coreos/ignition/v2/config/util"
+)
+
+// MakeHash returns an Ignition verification hash for contents.  Ignition
+// verifies the hash of the decompressed contents, so if compression is
+// specified, contents are decompressed first.
+func MakeHash(contents []byte, compression *string) (string, error) {
+	decompressed, err := decompress(contents, compression)
+	if err != nil {
+		return "", err
+	}
+	sum := sha512.Sum512(decompressed)
+	return "sha512-" + hex.EncodeToString(sum[:]), nil
+}
+
+// CheckHash fails if contents don't match the specified Ignition
+// verification hash.  Hashes with an unknown function or malformed
+// digest, and contents that can't be decompressed, are left for Ignition
+// to report.
+func CheckHash(expected string, contents []byte, compression *string) error {
+	function, digest, ok := strings.Cut(expected, "-")
+	if !ok {
+		return nil
+	}
+	var hasher hash.Hash
+	switch function {
+	case "sha256":
+		hasher = sha256.New()
+	case "sha512":
+		hasher = sha512.New()
+	default:
+		return nil
+	}
+	expectedSum, err := hex.DecodeString(digest)
+	if err != nil || len(expectedSum) != hasher.Size() {
+		return nil
+	}
+	decompressed, err := decompress(contents, compression)
+	if err != nil {
+		return nil
+	}
+	hasher.Write(decompressed)
+	if !bytes.Equal(hasher.Sum(nil), expectedSum) {
+		return common.ErrHashMismatch
+	}
+	return nil
+}
+
+func decompress(contents []byte, compression *string) ([]byte, error) {
+	switch {
+	case util.NilOrEmpty(compression):
+		return contents, nil
+	case *compression == "gzip":
+		reader, err := gzip.NewReader(bytes.NewReader(contents))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	default:
+		return nil, common.ErrHashCompression
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"testing"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/coreos/ignition/v2/config/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHash(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gzipped := buf.Bytes()
+
+	sha256Hello := "sha256-2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	sha512Hello, err := MakeHash([]byte("hello"), nil)
+	assert.NoError(t, err)
+	gzipHash, err := MakeHash(gzipped, util.StrToPtr("gzip"))
+	assert.NoError(t, err)
+	assert.Equal(t, sha512Hello, gzipHash, "hash of compressed contents")
+
+	tests := []struct {
+		hash        string
+		contents    []byte
+		compression *string
+		err         error
+	}{
+		{sha256Hello, []byte("hello"), nil, nil},
+		{sha256Hello, []byte("goodbye"), nil, common.ErrHashMismatch},
+		{sha512Hello, []byte("hello"), util.StrToPtr(""), nil},
+		{sha512Hello, []byte("goodbye"), nil, common.ErrHashMismatch},
+		// hash describes decompressed contents
+		{sha256Hello, gzipped, util.StrToPtr("gzip"), nil},
+		{sha256Hello, gzipped, nil, common.ErrHashMismatch},
+		// left for Ignition to report
+		{"sha256-zzz", []byte("hello"), nil, nil},
+		{"sha256-abcd", []byte("hello"), nil, nil},
+		{"md5-5d41402abc4b2a76b9719d911017c592", []byte("hello"), nil, nil},
+		{"this isn't validated", []byte("hello"), nil, nil},
+		{sha256Hello, []byte("hello"), util.StrToPtr("xz"), nil},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("check %d", i), func(t *testing.T) {
+			assert.Equal(t, test.err, CheckHash(test.hash, test.contents, test.compression))
+		})
+	}
+}
@@ -48,13 +48,13 @@ func MergeTranslatedConfigs(parent interface{}, parentTranslations translate.Tra
 		default:
 			panic("unexpected mapping tag " + rightEntry.From.Tag)
 		}
-		leftEntry, ok := left.Set[rightEntry.From.String()]
+		leftEntry, ok := left.Get(rightEntry.From)
 		if !ok {
 			// the right mapping is more comprehensive than the
 			// left mapping
 			continue
 		}
-		if _, ok := ts.Set[rightEntry.To.String()]; ok && rightEntry.From.Tag != merge.TAG_CHILD {
+		if _, ok := ts.Get(rightEntry.To); ok && rightEntry.From.Tag != merge.TAG_CHILD {
 			// For result fields which are produced by combining
 			// the parent and child, there will be two
 			// transcript entries, one for each side.  We want
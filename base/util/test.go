@@ -41,7 +41,7 @@ func VerifyTranslations(t *testing.T, set translate.TranslationSet, exceptions [
 	exceptionSet := translate.NewTranslationSet(set.FromTag, set.ToTag)
 	for _, ex := range exceptions {
 		exceptionSet.AddTranslation(ex.From, ex.To)
-		if tr, ok := set.Set[ex.To.String()]; ok {
+		if tr, ok := set.Get(ex.To); ok {
 			assert.Equal(t, ex, tr, "non-identity translation with unexpected From")
 		} else {
 			t.Errorf("missing non-identity translation %v", ex)
@@ -49,9 +49,9 @@ func VerifyTranslations(t *testing.T, set translate.TranslationSet, exceptions [
 	}
 
 	// walk translations
-	for key, translation := range set.Set {
+	for _, translation := range set.Translations() {
 		// unexpected non-identity?
-		if _, ok := exceptionSet.Set[key]; !ok {
+		if _, ok := exceptionSet.Get(translation.To); !ok {
 			assert.Equal(t, translation.From.Path, translation.To.Path, "translation is not identity")
 		}
 		// camel case on left?
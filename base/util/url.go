@@ -17,15 +17,63 @@ package util
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"encoding/base64"
-	"net/url"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/coreos/butane/config/common"
 
 	"github.com/coreos/ignition/v2/config/util"
-	"github.com/vincent-petithory/dataurl"
 )
 
+// MakeDataURLCached is like MakeDataURL, but looks up the result in cache
+// first, and stores it there afterward, if cache is non-nil.  The cache
+// is best-effort; errors storing a result are ignored.
+func MakeDataURLCached(contents []byte, currentCompression *string, allowCompression bool, cache common.DataURLCache) (uri string, compression *string, err error) {
+	if cache == nil {
+		return MakeDataURL(contents, currentCompression, allowCompression)
+	}
+	sum := sha256.Sum256(contents)
+	// bump the version if MakeDataURL's output changes
+	key := fmt.Sprintf("v2-%x-%s-%t", sum, stringValue(currentCompression), allowCompression)
+	if cachedURI, cachedCompression, ok := cache.Get(key); ok {
+		if util.NilOrEmpty(currentCompression) {
+			compression = util.StrToPtr(cachedCompression)
+		}
+		return cachedURI, compression, nil
+	}
+	uri, compression, err = MakeDataURL(contents, currentCompression, allowCompression)
+	if err != nil {
+		return
+	}
+	_ = cache.Put(key, uri, stringValue(compression))
+	return
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Inputs at least this large are sampled before being compressed, so
+// incompressible data doesn't pay for a full gzip pass.
+const compressionSampleThreshold = 4 << 20
+
+// Size of each of the chunks gzipped to estimate compressibility.
+const compressionSampleSize = 64 << 10
+
 func MakeDataURL(contents []byte, currentCompression *string, allowCompression bool) (uri string, compression *string, err error) {
-	// try three different encodings, and select the smallest one
+	// consider three different encodings, and select the smallest one.
+	// Lengths of the uncompressed encodings are computed without
+	// building them, and only the winning encoding is materialized.
+	// The gzipped encoding is streamed into its data URL, and abandoned
+	// as soon as it grows longer than the others.
 
 	if util.NilOrEmpty(currentCompression) {
 		// The config does not specify compression.  We need to
@@ -42,42 +90,193 @@ func MakeDataURL(contents []byte, currentCompression *string, allowCompression b
 		compression = nil
 	}
 
-	// URL-escaped, useful for ASCII text
-	opaque := "," + dataurl.Escape(contents)
-
 	// Base64-encoded, useful for small or incompressible binary data
-	b64 := ";base64," + base64.StdEncoding.EncodeToString(contents)
-	if len(b64) < len(opaque) {
-		opaque = b64
-	}
+	b64Len := len(";base64,") + base64.StdEncoding.EncodedLen(len(contents))
+
+	// URL-escaped, useful for ASCII text
+	escapedLen := len(",") + escapedLength(contents, b64Len)
+	useBase64 := b64Len < escapedLen
+	bestLen := min(escapedLen, b64Len)
 
 	// Base64-encoded gzipped, useful for compressible data.  If the
 	// user already enabled compression, don't compress again.
 	// We don't try base64-encoded URL-escaped because gzipped data is
 	// binary and URL escaping is unlikely to be efficient.
-	if util.NilOrEmpty(currentCompression) && allowCompression {
-		var buf bytes.Buffer
-		var compressor *gzip.Writer
-		if compressor, err = gzip.NewWriterLevel(&buf, gzip.BestCompression); err != nil {
+	if util.NilOrEmpty(currentCompression) && allowCompression && mayCompress(contents) {
+		// Account for space needed by the compression value: the
+		// gzipped encoding must be shorter than bestLen-len("gzip"),
+		// so the URL including "data:" must be at most bestLen.
+		var ok bool
+		if uri, ok, err = gzipDataURL(contents, bestLen); err != nil {
 			return
 		}
-		if _, err = compressor.Write(contents); err != nil {
+		if ok {
+			compression = util.StrToPtr("gzip")
 			return
 		}
-		if err = compressor.Close(); err != nil {
-			return
+	}
+
+	if useBase64 {
+		uri = base64DataURL(contents)
+	} else {
+		uri = escapedDataURL(contents)
+	}
+	return
+}
+
+// gzip.Writers allocate about a megabyte of state at BestCompression, so
+// reuse them across calls.
+var gzipWriters = sync.Pool{
+	New: func() any {
+		w, err := gzip.NewWriterLevel(nil, gzip.BestCompression)
+		if err != nil {
+			panic(err)
 		}
-		gz := ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
-		// Account for space needed by the compression value
-		if len(gz)+len("gzip") < len(opaque) {
-			opaque = gz
-			compression = util.StrToPtr("gzip")
+		return w
+	},
+}
+
+// gzipBytes compresses contents at the level used for data URLs.
+func gzipBytes(contents []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	compressor := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(compressor)
+	compressor.Reset(&buf)
+	if _, err := compressor.Write(contents); err != nil {
+		return nil, err
+	}
+	if err := compressor.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// errURLTooLong stops a gzipped encoding that can't be the smallest.
+var errURLTooLong = errors.New("data URL exceeds length limit")
+
+// limitedBuilder is a strings.Builder that fails writes which would grow
+// it past limit bytes.
+type limitedBuilder struct {
+	strings.Builder
+	limit int
+}
+
+func (b *limitedBuilder) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errURLTooLong
+	}
+	return b.Builder.Write(p)
+}
+
+// gzipDataURL streams contents through gzip and base64 into a data URL,
+// without buffering the compressed data.  It returns false if the URL
+// would be longer than limit.
+func gzipDataURL(contents []byte, limit int) (string, bool, error) {
+	const prefix = "data:;base64,"
+	b := limitedBuilder{limit: limit}
+	b.WriteString(prefix)
+	encoder := base64.NewEncoder(base64.StdEncoding, &b)
+	compressor := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(compressor)
+	compressor.Reset(encoder)
+	_, err := compressor.Write(contents)
+	if err == nil {
+		err = compressor.Close()
+	}
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err == errURLTooLong {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return b.String(), true, nil
+}
+
+// mayCompress estimates whether gzip could produce a smaller encoding of
+// contents.  Small inputs are always compressed.  For large inputs, a few
+// chunks spread across the input are compressed, and if none of them
+// shrinks meaningfully, the input is assumed to be incompressible.
+func mayCompress(contents []byte) bool {
+	if len(contents) < compressionSampleThreshold {
+		return true
+	}
+	for _, offset := range []int{0, (len(contents) - compressionSampleSize) / 2, len(contents) - compressionSampleSize} {
+		sample, err := gzipBytes(contents[offset : offset+compressionSampleSize])
+		if err != nil {
+			// let the real compression pass report it
+			return true
+		}
+		if len(sample) < compressionSampleSize*15/16 {
+			return true
 		}
 	}
+	return false
+}
 
-	uri = (&url.URL{
-		Scheme: "data",
-		Opaque: opaque,
-	}).String()
-	return
+// escapedLength returns the length of dataurl.Escape(data), or some
+// length greater than limit if the escaped data would exceed it.
+func escapedLength(data []byte, limit int) int {
+	n := len(data)
+	for _, b := range data {
+		if !unreserved[b] {
+			n += 2
+			if n > limit {
+				break
+			}
+		}
+	}
+	return n
+}
+
+// base64DataURL builds a base64 data URL directly into its final buffer.
+func base64DataURL(data []byte) string {
+	const prefix = "data:;base64,"
+	var b strings.Builder
+	b.Grow(len(prefix) + base64.StdEncoding.EncodedLen(len(data)))
+	b.WriteString(prefix)
+	encoder := base64.NewEncoder(base64.StdEncoding, &b)
+	// writes to a strings.Builder can't fail
+	_, _ = encoder.Write(data)
+	_ = encoder.Close()
+	return b.String()
 }
+
+// escapedDataURL builds a URL-escaped data URL.  The escaping is
+// equivalent to dataurl.Escape, without per-byte formatting overhead.
+func escapedDataURL(data []byte) string {
+	const prefix = "data:,"
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(prefix) + escapedLength(data, math.MaxInt))
+	b.WriteString(prefix)
+	for _, c := range data {
+		if unreserved[c] {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+		}
+	}
+	return b.String()
+}
+
+// unreserved marks the RFC 2396 unreserved characters, which
+// dataurl.Escape leaves unescaped.
+var unreserved = func() (table [256]bool) {
+	for c := 'a'; c <= 'z'; c++ {
+		table[c] = true
+	}
+	for c := 'A'; c <= 'Z'; c++ {
+		table[c] = true
+	}
+	for c := '0'; c <= '9'; c++ {
+		table[c] = true
+	}
+	for _, c := range []byte("-_.!~*'()") {
+		table[c] = true
+	}
+	return
+}()
@@ -0,0 +1,157 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"math"
+	"math/rand"
+	"net/url"
+	"testing"
+
+	"github.com/coreos/ignition/v2/config/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/vincent-petithory/dataurl"
+)
+
+// referenceDataURL is the straightforward implementation of MakeDataURL,
+// which builds all three encodings and picks the smallest.
+func referenceDataURL(t *testing.T, contents []byte, currentCompression *string, allowCompression bool) (string, *string) {
+	var compression *string
+	if util.NilOrEmpty(currentCompression) {
+		compression = util.StrToPtr("")
+	}
+	opaque := "," + dataurl.Escape(contents)
+	b64 := ";base64," + base64.StdEncoding.EncodeToString(contents)
+	if len(b64) < len(opaque) {
+		opaque = b64
+	}
+	if util.NilOrEmpty(currentCompression) && allowCompression {
+		var buf bytes.Buffer
+		compressor, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		assert.NoError(t, err)
+		_, err = compressor.Write(contents)
+		assert.NoError(t, err)
+		assert.NoError(t, compressor.Close())
+		gz := ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+		if len(gz)+len("gzip") < len(opaque) {
+			opaque = gz
+			compression = util.StrToPtr("gzip")
+		}
+	}
+	return (&url.URL{Scheme: "data", Opaque: opaque}).String(), compression
+}
+
+func randomBytes(n int, seed int64) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(b)
+	return b
+}
+
+func TestMakeDataURL(t *testing.T) {
+	allBytes := make([]byte, 256)
+	for i := range allBytes {
+		allBytes[i] = byte(i)
+	}
+	inputs := [][]byte{
+		nil,
+		[]byte("a"),
+		[]byte("hello world"),
+		[]byte("-_.!~*'()%?#/ +"),
+		allBytes,
+		bytes.Repeat([]byte("compressible text\n"), 1000),
+		randomBytes(100, 1),
+		randomBytes(100000, 2),
+	}
+	for i, input := range inputs {
+		for _, currentCompression := range []*string{nil, util.StrToPtr(""), util.StrToPtr("gzip")} {
+			for _, allowCompression := range []bool{true, false} {
+				expectedURI, expectedCompression := referenceDataURL(t, input, currentCompression, allowCompression)
+				uri, compression, err := MakeDataURL(input, currentCompression, allowCompression)
+				assert.NoError(t, err, "#%d", i)
+				assert.Equal(t, expectedURI, uri, "#%d uri", i)
+				assert.Equal(t, expectedCompression, compression, "#%d compression", i)
+			}
+		}
+	}
+}
+
+func TestMakeDataURLLarge(t *testing.T) {
+	// incompressible input skips compression
+	input := randomBytes(compressionSampleThreshold, 3)
+	uri, compression, err := MakeDataURL(input, nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "", *compression)
+	assert.Equal(t, "data:;base64,"+base64.StdEncoding.EncodeToString(input), uri)
+
+	// compressible data past the first sample is still compressed
+	copy(input[compressionSampleSize:], bytes.Repeat([]byte{'a'}, len(input)-compressionSampleSize))
+	expectedURI, expectedCompression := referenceDataURL(t, input, nil, true)
+	uri, compression, err = MakeDataURL(input, nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedCompression, compression)
+	assert.Equal(t, expectedURI, uri)
+}
+
+func TestGzipDataURL(t *testing.T) {
+	input := bytes.Repeat([]byte("compressible text\n"), 1000)
+	uri, ok, err := gzipDataURL(input, math.MaxInt)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	expected, _ := referenceDataURL(t, input, nil, true)
+	assert.Equal(t, expected, uri)
+
+	// the limit is inclusive
+	actual, ok, err := gzipDataURL(input, len(uri))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uri, actual)
+	_, ok, err = gzipDataURL(input, len(uri)-1)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// compression stops early, and the pooled writer is reusable
+	_, ok, err = gzipDataURL(randomBytes(100000, 4), 1000)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	actual, ok, err = gzipDataURL(input, math.MaxInt)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uri, actual)
+}
+
+func benchmarkMakeDataURL(b *testing.B, contents []byte) {
+	b.SetBytes(int64(len(contents)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, err := MakeDataURL(contents, nil, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMakeDataURL100MBText(b *testing.B) {
+	benchmarkMakeDataURL(b, bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog.\n"), 100<<20/45))
+}
+
+func BenchmarkMakeDataURL100MBRandom(b *testing.B) {
+	benchmarkMakeDataURL(b, randomBytes(100<<20, 1))
+}
+
+func BenchmarkMakeDataURLSmall(b *testing.B) {
+	benchmarkMakeDataURL(b, []byte("[Unit]\nDescription=Example\n\n[Service]\nExecStart=/usr/bin/true\n"))
+}
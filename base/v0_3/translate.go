@@ -125,11 +125,12 @@ func translateResource(from Resource, options common.TranslateOptions) (to types
 
 	if from.Local != nil {
 		c := path.New("yaml", "local")
-		contents, err := baseutil.ReadLocalFile(*from.Local, options.FilesDir)
+		contents, dir, err := baseutil.ReadLocalFileFromDirs(*from.Local, options)
 		if err != nil {
 			r.AddOnError(c, err)
 			return
 		}
+		r.AddOnInfo(c, baseutil.LocalFileSource(dir, options))
 		// Validating the contents of the local file from here since there is no way to
 		// get both the filename and filedirectory in the Validate context
 		if strings.HasPrefix(c.String(), "$.ignition.config") {
@@ -139,7 +140,7 @@ func translateResource(from Resource, options common.TranslateOptions) (to types
 				return
 			}
 		}
-		src, compression, err := baseutil.MakeDataURL(contents, to.Compression, !options.NoResourceAutoCompression)
+		src, compression, err := baseutil.MakeDataURLCached(contents, to.Compression, !options.NoResourceAutoCompression, options.DataURLCache)
 		if err != nil {
 			r.AddOnError(c, err)
 			return
@@ -155,7 +156,7 @@ func translateResource(from Resource, options common.TranslateOptions) (to types
 	if from.Inline != nil {
 		c := path.New("yaml", "inline")
 
-		src, compression, err := baseutil.MakeDataURL([]byte(*from.Inline), to.Compression, !options.NoResourceAutoCompression)
+		src, compression, err := baseutil.MakeDataURLCached([]byte(*from.Inline), to.Compression, !options.NoResourceAutoCompression, options.DataURLCache)
 		if err != nil {
 			r.AddOnError(c, err)
 			return
@@ -201,19 +202,20 @@ func (c Config) processTrees(ret *types.Config, options common.TranslateOptions)
 
 	for i, tree := range c.Storage.Trees {
 		yamlPath := path.New("yaml", "storage", "trees", i)
-		if options.FilesDir == "" {
+		if len(baseutil.FilesDirs(options)) == 0 {
 			r.AddOnError(yamlPath, common.ErrNoFilesDir)
 			return ts, r
 		}
 
-		// calculate base path within FilesDir and check for
-		// path traversal
-		srcBaseDir := filepath.Join(options.FilesDir, filepath.FromSlash(tree.Local))
-		if err := baseutil.EnsurePathWithinFilesDir(srcBaseDir, options.FilesDir); err != nil {
+		// find base path within the files dirs and check for path
+		// traversal
+		srcBaseDir, dir, err := baseutil.ResolveLocalPath(tree.Local, "", options)
+		if err != nil {
 			r.AddOnError(yamlPath, err)
 			continue
 		}
-		info, err := os.Stat(srcBaseDir)
+		r.AddOnInfo(yamlPath, baseutil.LocalFileSource(dir, options))
+		info, err := baseutil.StatLocalPath(dir, srcBaseDir)
 		if err != nil {
 			r.AddOnError(yamlPath, err)
 			continue
@@ -227,23 +229,25 @@ func (c Config) processTrees(ret *types.Config, options common.TranslateOptions)
 			destBaseDir = *tree.Path
 		}
 
-		walkTree(yamlPath, &ts, &r, t, srcBaseDir, destBaseDir, options)
+		walkTree(yamlPath, &ts, &r, t, dir, srcBaseDir, destBaseDir, options)
 	}
 	return ts, r
 }
 
-func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report.Report, t *nodeTracker, srcBaseDir, destBaseDir string, options common.TranslateOptions) {
+func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report.Report, t *nodeTracker, dir common.FilesDirEntry, srcBaseDir, destBaseDir string, options common.TranslateOptions) {
+	encoder := baseutil.NewTreeEncoder(yamlPath, ts, r, options)
 	// The strategy for errors within WalkFunc is to add an error to
 	// the report and return nil, so walking continues but translation
-	// will fail afterward.
-	err := filepath.Walk(srcBaseDir, func(srcPath string, info os.FileInfo, err error) error {
+	// will fail afterward.  Errors are added through the encoder so
+	// they're reported in walk order.
+	err := baseutil.WalkLocalPath(options.Context, dir, srcBaseDir, func(srcPath string, info os.FileInfo, err error) error {
 		if err != nil {
-			r.AddOnError(yamlPath, err)
+			encoder.AddError(err)
 			return nil
 		}
 		relPath, err := filepath.Rel(srcBaseDir, srcPath)
 		if err != nil {
-			r.AddOnError(yamlPath, err)
+			encoder.AddError(err)
 			return nil
 		}
 		destPath := slashpath.Join(destBaseDir, filepath.ToSlash(relPath))
@@ -254,12 +258,12 @@ func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report
 			i, file := t.GetFile(destPath)
 			if file != nil {
 				if util.NotEmpty(file.Contents.Source) {
-					r.AddOnError(yamlPath, common.ErrNodeExists)
+					encoder.AddError(common.ErrNodeExists)
 					return nil
 				}
 			} else {
 				if t.Exists(destPath) {
-					r.AddOnError(yamlPath, common.ErrNodeExists)
+					encoder.AddError(common.ErrNodeExists)
 					return nil
 				}
 				i, file = t.AddFile(types.File{
@@ -272,41 +276,28 @@ func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report
 					ts.AddTranslation(yamlPath, path.New("json", "storage", "files"))
 				}
 			}
-			contents, err := os.ReadFile(srcPath)
-			if err != nil {
-				r.AddOnError(yamlPath, err)
-				return nil
-			}
-			url, compression, err := baseutil.MakeDataURL(contents, file.Contents.Compression, !options.NoResourceAutoCompression)
-			if err != nil {
-				r.AddOnError(yamlPath, err)
-				return nil
-			}
-			file.Contents.Source = &url
-			ts.AddTranslation(yamlPath, path.New("json", "storage", "files", i, "contents", "source"))
-			if compression != nil {
-				file.Contents.Compression = compression
-				ts.AddTranslation(yamlPath, path.New("json", "storage", "files", i, "contents", "compression"))
-			}
-			ts.AddTranslation(yamlPath, path.New("json", "storage", "files", i, "contents"))
-			if file.Mode == nil {
-				mode := 0644
-				if info.Mode()&0111 != 0 {
-					mode = 0755
+			// read and encode on the worker pool; the file is filled
+			// in, in walk order, when the walk finishes
+			encoder.AddFile(dir, srcPath, i, file.Contents.Compression, baseutil.TreeFileMode(info, nil), func() baseutil.TreeFile {
+				// earlier pointers may have been invalidated by
+				// later additions to the tracker
+				_, file := t.GetFile(destPath)
+				return baseutil.TreeFile{
+					Source:      &file.Contents.Source,
+					Compression: &file.Contents.Compression,
+					Mode:        &file.Mode,
 				}
-				file.Mode = &mode
-				ts.AddTranslation(yamlPath, path.New("json", "storage", "files", i, "mode"))
-			}
+			})
 		} else if info.Mode()&os.ModeType == os.ModeSymlink {
 			i, link := t.GetLink(destPath)
 			if link != nil {
 				if link.Target != "" {
-					r.AddOnError(yamlPath, common.ErrNodeExists)
+					encoder.AddError(common.ErrNodeExists)
 					return nil
 				}
 			} else {
 				if t.Exists(destPath) {
-					r.AddOnError(yamlPath, common.ErrNodeExists)
+					encoder.AddError(common.ErrNodeExists)
 					return nil
 				}
 				i, link = t.AddLink(types.Link{
@@ -319,19 +310,20 @@ func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report
 					ts.AddTranslation(yamlPath, path.New("json", "storage", "links"))
 				}
 			}
-			target, err := os.Readlink(srcPath)
+			target, err := baseutil.ReadLocalLink(dir, srcPath)
 			if err != nil {
-				r.AddOnError(yamlPath, err)
+				encoder.AddError(err)
 				return nil
 			}
 			link.Target = filepath.ToSlash(target)
 			ts.AddTranslation(yamlPath, path.New("json", "storage", "links", i, "target"))
 		} else {
-			r.AddOnError(yamlPath, common.ErrFileType)
+			encoder.AddError(common.ErrFileType)
 			return nil
 		}
 		return nil
 	})
+	encoder.Wait()
 	r.AddOnError(yamlPath, err)
 }
 
@@ -43,6 +43,17 @@ type Config struct {
 
 type Device string
 
+// ConfigResource is a Resource which can also be a Butane config.
+type ConfigResource struct {
+	Compression  *string      `yaml:"compression"`
+	HTTPHeaders  HTTPHeaders  `yaml:"http_headers"`
+	Source       *string      `yaml:"source"`
+	Inline       *string      `yaml:"inline"`       // Added, not in ignition spec
+	Local        *string      `yaml:"local"`        // Added, not in ignition spec
+	ButaneLocal  *string      `yaml:"butane_local"` // Added, not in ignition spec
+	Verification Verification `yaml:"verification"`
+}
+
 type Directory struct {
 	Group     NodeGroup `yaml:"group"`
 	Overwrite *bool     `yaml:"overwrite"`
@@ -102,8 +113,8 @@ type Ignition struct {
 }
 
 type IgnitionConfig struct {
-	Merge   []Resource `yaml:"merge"`
-	Replace Resource   `yaml:"replace"`
+	Merge   []ConfigResource `yaml:"merge"`
+	Replace ConfigResource   `yaml:"replace"`
 }
 
 type KernelArgument string
@@ -252,6 +263,7 @@ type Timeouts struct {
 type Tree struct {
 	Group    NodeGroup `yaml:"group"`
 	Local    string    `yaml:"local"`
+	FilesDir *string   `yaml:"files_dir"`
 	Path     *string   `yaml:"path"`
 	User     NodeUser  `yaml:"user"`
 	FileMode *int      `yaml:"file_mode"`
@@ -276,5 +288,6 @@ type Quadlet struct {
 }
 
 type Verification struct {
-	Hash *string `yaml:"hash"`
+	Hash      *string `yaml:"hash"`
+	HashLocal *string `yaml:"hash_local" butane:"auto_skip"` // Added, not in ignition spec
 }
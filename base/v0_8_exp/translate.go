@@ -117,6 +117,7 @@ func (c Config) ToIgn3_7Unvalidated(options common.TranslateOptions) (types.Conf
 func translateIgnition(from Ignition, options common.TranslateOptions) (to types.Ignition, tm translate.TranslationSet, r report.Report) {
 	tr := translate.NewTranslator("yaml", "json", options)
 	tr.AddCustomTranslator(translateResource)
+	tr.AddCustomTranslator(translateConfigResource)
 	to.Version = types.MaxVersion.String()
 	tm, r = translate.Prefixed(tr, "config", &from.Config, &to.Config)
 	translate.MergeP(tr, tm, &r, "proxy", &from.Proxy, &to.Proxy)
@@ -145,13 +146,31 @@ func translateResource(from Resource, options common.TranslateOptions) (to types
 	translate.MergeP(tr, tm, &r, "source", &from.Source, &to.Source)
 	translate.MergeP(tr, tm, &r, "compression", &from.Compression, &to.Compression)
 
+	if from.Verification.HashLocal != nil {
+		c := path.New("yaml", "verification", "hash_local")
+		contents, dir, err := baseutil.ReadLocalFileFromDirs(*from.Verification.HashLocal, options)
+		if err != nil {
+			r.AddOnError(c, err)
+			return
+		}
+		r.AddOnInfo(c, baseutil.LocalFileSource(dir, options))
+		hash, err := baseutil.MakeHash(contents, from.Compression)
+		if err != nil {
+			r.AddOnError(c, err)
+			return
+		}
+		to.Verification.Hash = &hash
+		tm.AddTranslation(c, path.New("json", "verification", "hash"))
+	}
+
 	if from.Local != nil {
 		c := path.New("yaml", "local")
-		contents, err := baseutil.ReadLocalFile(*from.Local, options.FilesDir)
+		contents, dir, err := baseutil.ReadLocalFileFromDirs(*from.Local, options)
 		if err != nil {
 			r.AddOnError(c, err)
 			return
 		}
+		r.AddOnInfo(c, baseutil.LocalFileSource(dir, options))
 		// Validating the contents of the local file from here since there is no way to
 		// get both the filename and filedirectory in the Validate context
 		if strings.HasPrefix(c.String(), "$.ignition.config") {
@@ -162,7 +181,10 @@ func translateResource(from Resource, options common.TranslateOptions) (to types
 			}
 		}
 
-		src, compression, err := baseutil.MakeDataURL(contents, to.Compression, !options.NoResourceAutoCompression)
+		if from.Verification.Hash != nil {
+			r.AddOnError(path.New("yaml"), baseutil.CheckHash(*from.Verification.Hash, contents, from.Compression))
+		}
+		src, compression, err := baseutil.MakeDataURLCached(contents, to.Compression, !options.NoResourceAutoCompression, options.DataURLCache)
 		if err != nil {
 			r.AddOnError(c, err)
 			return
@@ -178,7 +200,35 @@ func translateResource(from Resource, options common.TranslateOptions) (to types
 	if from.Inline != nil {
 		c := path.New("yaml", "inline")
 
-		src, compression, err := baseutil.MakeDataURL([]byte(*from.Inline), to.Compression, !options.NoResourceAutoCompression)
+		if from.Verification.Hash != nil {
+			r.AddOnError(path.New("yaml"), baseutil.CheckHash(*from.Verification.Hash, []byte(*from.Inline), from.Compression))
+		}
+		src, compression, err := baseutil.MakeDataURLCached([]byte(*from.Inline), to.Compression, !options.NoResourceAutoCompression, options.DataURLCache)
+		if err != nil {
+			r.AddOnError(c, err)
+			return
+		}
+		to.Source = &src
+		tm.AddTranslation(c, path.New("json", "source"))
+		if compression != nil {
+			to.Compression = compression
+			tm.AddTranslation(c, path.New("json", "compression"))
+		}
+	}
+	return
+}
+
+func translateConfigResource(from ConfigResource, options common.TranslateOptions) (to types.Resource, tm translate.TranslationSet, r report.Report) {
+	to, tm, r = translateResource(from.resource(), options)
+	if from.ButaneLocal != nil {
+		c := path.New("yaml", "butane_local")
+		contents, rChild, err := baseutil.TranslateButaneLocal(c, *from.ButaneLocal, options)
+		r.Merge(rChild)
+		if err != nil {
+			return
+		}
+
+		src, compression, err := baseutil.MakeDataURLCached(contents, to.Compression, !options.NoResourceAutoCompression, options.DataURLCache)
 		if err != nil {
 			r.AddOnError(c, err)
 			return
@@ -235,17 +285,18 @@ func translatePasswdUser(from PasswdUser, options common.TranslateOptions) (to t
 		c := path.New("yaml", "ssh_authorized_keys_local")
 		tm.AddTranslation(c, path.New("json", "sshAuthorizedKeys"))
 
-		if options.FilesDir == "" {
+		if len(baseutil.FilesDirs(options)) == 0 {
 			r.AddOnError(c, common.ErrNoFilesDir)
 			return
 		}
 
 		for keyFileIndex, sshKeyFile := range from.SSHAuthorizedKeysLocal {
-			sshKeys, err := baseutil.ReadLocalFile(sshKeyFile, options.FilesDir)
+			sshKeys, dir, err := baseutil.ReadLocalFileFromDirs(sshKeyFile, options)
 			if err != nil {
 				r.AddOnError(c.Append(keyFileIndex), err)
 				continue
 			}
+			r.AddOnInfo(c.Append(keyFileIndex), baseutil.LocalFileSource(dir, options))
 			for _, line := range regexp.MustCompile("\r?\n").Split(string(sshKeys), -1) {
 				if line == "" {
 					continue
@@ -270,11 +321,12 @@ func translateUnit(from Unit, options common.TranslateOptions) (to types.Unit, t
 
 	if util.NotEmpty(from.ContentsLocal) {
 		c := path.New("yaml", "contents_local")
-		contents, err := baseutil.ReadLocalFile(*from.ContentsLocal, options.FilesDir)
+		contents, dir, err := baseutil.ReadLocalFileFromDirs(*from.ContentsLocal, options)
 		if err != nil {
 			r.AddOnError(c, err)
 			return
 		}
+		r.AddOnInfo(c, baseutil.LocalFileSource(dir, options))
 		tm.AddTranslation(c, path.New("json", "contents"))
 		to.Contents = util.StrToPtr(string(contents))
 	}
@@ -289,11 +341,12 @@ func translateDropin(from Dropin, options common.TranslateOptions) (to types.Dro
 
 	if util.NotEmpty(from.ContentsLocal) {
 		c := path.New("yaml", "contents_local")
-		contents, err := baseutil.ReadLocalFile(*from.ContentsLocal, options.FilesDir)
+		contents, dir, err := baseutil.ReadLocalFileFromDirs(*from.ContentsLocal, options)
 		if err != nil {
 			r.AddOnError(c, err)
 			return
 		}
+		r.AddOnInfo(c, baseutil.LocalFileSource(dir, options))
 		tm.AddTranslation(c, path.New("json", "contents"))
 		to.Contents = util.StrToPtr(string(contents))
 	}
@@ -335,15 +388,17 @@ func isTemplateInstance(name string) (bool, string) {
 }
 
 // readLocalOrInlineContents reads content from either a local file or inline string (see Quadlet and Dropin).
-// Returns the content as bytes, the source path for error reporting, and any errors.
-func readLocalOrInlineContents(contentsLocal, contentsInline *string, ctxPath path.ContextPath, options common.TranslateOptions) (content []byte, contentPath path.ContextPath, err error) {
+// Returns the content as bytes, the source path for error reporting, the files directory of a local file, and
+// any errors.
+func readLocalOrInlineContents(contentsLocal, contentsInline *string, ctxPath path.ContextPath, options common.TranslateOptions) (content []byte, contentPath path.ContextPath, dir common.FilesDirEntry, err error) {
 	if util.NotEmpty(contentsLocal) {
 		contentPath = ctxPath.Append("contents_local")
-		localContents, err := baseutil.ReadLocalFile(*contentsLocal, options.FilesDir)
+		localContents, localDir, err := baseutil.ReadLocalFileFromDirs(*contentsLocal, options)
 		if err != nil {
-			return content, contentPath, err
+			return content, contentPath, dir, err
 		}
 		content = localContents
+		dir = localDir
 	}
 
 	if util.NotEmpty(contentsInline) {
@@ -378,12 +433,13 @@ func addFileWithContents(
 	}
 	ts.AddFromCommonSource(ctxPath, path.New("json", "storage", "files", i), file)
 	ts.AddTranslation(ctxPath.Append("name"), path.New("json", "storage", "files", i, "path"))
-	contentBytes, contentPath, err := readLocalOrInlineContents(contentsLocal, inlineContents, ctxPath, options)
+	contentBytes, contentPath, dir, err := readLocalOrInlineContents(contentsLocal, inlineContents, ctxPath, options)
 	if err != nil {
 		r.AddOnError(contentPath, err)
 		return ts, r
 	}
-	url, compression, err := baseutil.MakeDataURL(contentBytes, file.Contents.Compression, !options.NoResourceAutoCompression)
+	r.AddOnInfo(contentPath, baseutil.LocalFileSource(dir, options))
+	url, compression, err := baseutil.MakeDataURLCached(contentBytes, file.Contents.Compression, !options.NoResourceAutoCompression, options.DataURLCache)
 	if err != nil {
 		r.AddOnError(ctxPath, err)
 		return ts, r
@@ -477,19 +533,24 @@ func (c Config) processTrees(ret *types.Config, options common.TranslateOptions)
 
 	for i, tree := range c.Storage.Trees {
 		yamlPath := path.New("yaml", "storage", "trees", i)
-		if options.FilesDir == "" {
+		if len(baseutil.FilesDirs(options)) == 0 {
 			r.AddOnError(yamlPath, common.ErrNoFilesDir)
 			return ts, r
 		}
 
-		// calculate base path within FilesDir and check for
-		// path traversal
-		srcBaseDir := filepath.Join(options.FilesDir, filepath.FromSlash(tree.Local))
-		if err := baseutil.EnsurePathWithinFilesDir(srcBaseDir, options.FilesDir); err != nil {
+		// find base path within the files dirs and check for path
+		// traversal
+		root := ""
+		if util.NotEmpty(tree.FilesDir) {
+			root = *tree.FilesDir
+		}
+		srcBaseDir, dir, err := baseutil.ResolveLocalPath(tree.Local, root, options)
+		if err != nil {
 			r.AddOnError(yamlPath, err)
 			continue
 		}
-		info, err := os.Stat(srcBaseDir)
+		r.AddOnInfo(yamlPath, baseutil.LocalFileSource(dir, options))
+		info, err := baseutil.StatLocalPath(dir, srcBaseDir)
 		if err != nil {
 			r.AddOnError(yamlPath, err)
 			continue
@@ -504,6 +565,7 @@ func (c Config) processTrees(ret *types.Config, options common.TranslateOptions)
 		}
 
 		walkTree(yamlPath, &ts, &r, t, treeWalkOptions{
+			filesDir:         dir,
 			srcBaseDir:       srcBaseDir,
 			destBaseDir:      destBaseDir,
 			TranslateOptions: options,
@@ -517,6 +579,7 @@ func (c Config) processTrees(ret *types.Config, options common.TranslateOptions)
 }
 
 type treeWalkOptions struct {
+	filesDir    common.FilesDirEntry
 	srcBaseDir  string
 	destBaseDir string
 	common.TranslateOptions
@@ -527,17 +590,19 @@ type treeWalkOptions struct {
 }
 
 func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report.Report, t *nodeTracker, options treeWalkOptions) {
+	encoder := baseutil.NewTreeEncoder(yamlPath, ts, r, options.TranslateOptions)
 	// The strategy for errors within WalkFunc is to add an error to
 	// the report and return nil, so walking continues but translation
-	// will fail afterward.
-	err := filepath.Walk(options.srcBaseDir, func(srcPath string, info os.FileInfo, err error) error {
+	// will fail afterward.  Errors are added through the encoder so
+	// they're reported in walk order.
+	err := baseutil.WalkLocalPath(options.Context, options.filesDir, options.srcBaseDir, func(srcPath string, info os.FileInfo, err error) error {
 		if err != nil {
-			r.AddOnError(yamlPath, err)
+			encoder.AddError(err)
 			return nil
 		}
 		relPath, err := filepath.Rel(options.srcBaseDir, srcPath)
 		if err != nil {
-			r.AddOnError(yamlPath, err)
+			encoder.AddError(err)
 			return nil
 		}
 		destPath := slashpath.Join(options.destBaseDir, filepath.ToSlash(relPath))
@@ -549,7 +614,7 @@ func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report
 			}
 
 			if t.Exists(destPath) {
-				r.AddOnError(yamlPath, common.ErrNodeExists)
+				encoder.AddError(common.ErrNodeExists)
 				return nil
 			}
 			mode := util.IntToPtr(0755)
@@ -570,12 +635,12 @@ func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report
 			i, file := t.GetFile(destPath)
 			if file != nil {
 				if util.NotEmpty(file.Contents.Source) {
-					r.AddOnError(yamlPath, common.ErrNodeExists)
+					encoder.AddError(common.ErrNodeExists)
 					return nil
 				}
 			} else {
 				if t.Exists(destPath) {
-					r.AddOnError(yamlPath, common.ErrNodeExists)
+					encoder.AddError(common.ErrNodeExists)
 					return nil
 				}
 				i, file = t.AddFile(types.File{
@@ -586,44 +651,28 @@ func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report
 					ts.AddTranslation(yamlPath, path.New("json", "storage", "files"))
 				}
 			}
-			contents, err := os.ReadFile(srcPath)
-			if err != nil {
-				r.AddOnError(yamlPath, err)
-				return nil
-			}
-			url, compression, err := baseutil.MakeDataURL(contents, file.Contents.Compression, !options.NoResourceAutoCompression)
-			if err != nil {
-				r.AddOnError(yamlPath, err)
-				return nil
-			}
-			file.Contents.Source = &url
-			ts.AddTranslation(yamlPath, path.New("json", "storage", "files", i, "contents", "source"))
-			if compression != nil {
-				file.Contents.Compression = compression
-				ts.AddTranslation(yamlPath, path.New("json", "storage", "files", i, "contents", "compression"))
-			}
-			ts.AddTranslation(yamlPath, path.New("json", "storage", "files", i, "contents"))
-			if file.Mode == nil {
-				mode := 0644
-				if info.Mode()&0111 != 0 {
-					mode = 0755
-				}
-				if options.fileMode != nil {
-					mode = *options.fileMode
+			// read and encode on the worker pool; the file is filled
+			// in, in walk order, when the walk finishes
+			encoder.AddFile(options.filesDir, srcPath, i, file.Contents.Compression, baseutil.TreeFileMode(info, options.fileMode), func() baseutil.TreeFile {
+				// earlier pointers may have been invalidated by
+				// later additions to the tracker
+				_, file := t.GetFile(destPath)
+				return baseutil.TreeFile{
+					Source:      &file.Contents.Source,
+					Compression: &file.Contents.Compression,
+					Mode:        &file.Mode,
 				}
-				file.Mode = &mode
-				ts.AddTranslation(yamlPath, path.New("json", "storage", "files", i, "mode"))
-			}
+			})
 		} else if info.Mode()&os.ModeType == os.ModeSymlink {
 			i, link := t.GetLink(destPath)
 			if link != nil {
 				if util.NotEmpty(link.Target) {
-					r.AddOnError(yamlPath, common.ErrNodeExists)
+					encoder.AddError(common.ErrNodeExists)
 					return nil
 				}
 			} else {
 				if t.Exists(destPath) {
-					r.AddOnError(yamlPath, common.ErrNodeExists)
+					encoder.AddError(common.ErrNodeExists)
 					return nil
 				}
 				i, link = t.AddLink(types.Link{
@@ -634,19 +683,20 @@ func walkTree(yamlPath path.ContextPath, ts *translate.TranslationSet, r *report
 					ts.AddTranslation(yamlPath, path.New("json", "storage", "links"))
 				}
 			}
-			target, err := os.Readlink(srcPath)
+			target, err := baseutil.ReadLocalLink(options.filesDir, srcPath)
 			if err != nil {
-				r.AddOnError(yamlPath, err)
+				encoder.AddError(err)
 				return nil
 			}
 			link.Target = util.StrToPtr(filepath.ToSlash(target))
 			ts.AddTranslation(yamlPath, path.New("json", "storage", "links", i, "target"))
 		} else {
-			r.AddOnError(yamlPath, common.ErrFileType)
+			encoder.AddError(common.ErrFileType)
 			return nil
 		}
 		return nil
 	})
+	encoder.Wait()
 	r.AddOnError(yamlPath, err)
 }
 
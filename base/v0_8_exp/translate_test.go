@@ -16,6 +16,7 @@ package v0_8_exp
 
 import (
 	"fmt"
+	"io/fs"
 	"net"
 	"os"
 	"path/filepath"
@@ -23,10 +24,12 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	baseutil "github.com/coreos/butane/base/util"
 	"github.com/coreos/butane/config/common"
 	confutil "github.com/coreos/butane/config/util"
+	"github.com/coreos/butane/internal/butanelocal"
 	"github.com/coreos/butane/translate"
 
 	"github.com/coreos/ignition/v2/config/util"
@@ -585,6 +588,87 @@ func TestTranslateFile(t *testing.T) {
 				NoResourceAutoCompression: true,
 			},
 		},
+		// Test hash computation and verification
+		{
+			File{
+				Path: "/foo",
+				Contents: Resource{
+					Source: util.StrToPtr("http://example/com"),
+					Verification: Verification{
+						HashLocal: util.StrToPtr("file-1"),
+					},
+				},
+				Append: []Resource{
+					{
+						Inline: util.StrToPtr("hello"),
+						Verification: Verification{
+							Hash: util.StrToPtr("sha256-2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"),
+						},
+					},
+					{
+						Local: util.StrToPtr("file-1"),
+						Verification: Verification{
+							Hash: util.StrToPtr("sha256-2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"),
+						},
+					},
+				},
+			},
+			types.File{
+				Node: types.Node{
+					Path: "/foo",
+				},
+				FileEmbedded1: types.FileEmbedded1{
+					Contents: types.Resource{
+						Source: util.StrToPtr("http://example/com"),
+						Verification: types.Verification{
+							Hash: util.StrToPtr("sha512-8f4e7ccd073866160698f8506542a4ebcbf93ed30434ede402c1303bf9d87676aa4007c6ed9df6c3faf067575715ea1649cb18b64cfb0b89cdd11ee2052e5f4d"),
+						},
+					},
+					Append: []types.Resource{
+						{
+							Source:      util.StrToPtr("data:,hello"),
+							Compression: util.StrToPtr(""),
+							Verification: types.Verification{
+								Hash: util.StrToPtr("sha256-2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"),
+							},
+						},
+						{
+							Source:      util.StrToPtr("data:,file%20contents%0A"),
+							Compression: util.StrToPtr(""),
+							Verification: types.Verification{
+								Hash: util.StrToPtr("sha256-2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"),
+							},
+						},
+					},
+				},
+			},
+			[]translate.Translation{
+				{
+					From: path.New("yaml", "contents", "verification", "hash_local"),
+					To:   path.New("json", "contents", "verification", "hash"),
+				},
+				{
+					From: path.New("yaml", "append", 0, "inline"),
+					To:   path.New("json", "append", 0, "source"),
+				},
+				{
+					From: path.New("yaml", "append", 0, "inline"),
+					To:   path.New("json", "append", 0, "compression"),
+				},
+				{
+					From: path.New("yaml", "append", 1, "local"),
+					To:   path.New("json", "append", 1, "source"),
+				},
+				{
+					From: path.New("yaml", "append", 1, "local"),
+					To:   path.New("json", "append", 1, "compression"),
+				},
+			},
+			"error at $.append.1: " + common.ErrHashMismatch.Error() + "\n",
+			common.TranslateOptions{
+				FilesDir: filesDir,
+			},
+		},
 	}
 
 	for i, test := range tests {
@@ -1892,6 +1976,170 @@ func TestTranslateTree(t *testing.T) {
 	}
 }
 
+// TestTranslateTreeFilesDirs tests selecting among multiple files dirs
+// when translating storage.trees.
+func TestTranslateTreeFilesDirs(t *testing.T) {
+	site := t.TempDir()
+	shared := t.TempDir()
+	for _, dir := range []string{site, shared} {
+		if err := os.MkdirAll(filepath.Join(dir, "tree"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "tree", filepath.Base(dir)), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filesDirs := []common.FilesDirEntry{
+		{Path: site},
+		{Name: "shared", Path: shared},
+	}
+
+	tests := []struct {
+		in      Tree
+		strict  bool
+		outPath string
+		report  string
+	}{
+		// first files dir containing the tree wins
+		{
+			in: Tree{
+				Local: "tree",
+			},
+			outPath: "/" + filepath.Base(site),
+			report:  "info at $.storage.trees.0: " + common.ErrLocalFileSource{Dir: site}.Error() + "\n",
+		},
+		// explicitly selected files dir
+		{
+			in: Tree{
+				Local:    "tree",
+				FilesDir: util.StrToPtr("shared"),
+			},
+			outPath: "/" + filepath.Base(shared),
+			report:  "info at $.storage.trees.0: " + common.ErrLocalFileSource{Dir: "shared=" + shared}.Error() + "\n",
+		},
+		// unknown files dir
+		{
+			in: Tree{
+				Local:    "tree",
+				FilesDir: util.StrToPtr("other"),
+			},
+			report: "error at $.storage.trees.0: " + common.ErrUnknownFilesDir{Name: "other"}.Error() + "\n",
+		},
+		// ambiguous in strict mode
+		{
+			in: Tree{
+				Local: "tree",
+			},
+			strict: true,
+			report: "error at $.storage.trees.0: " + common.ErrAmbiguousLocalPath{Dirs: []string{site, "shared=" + shared}}.Error() + "\n",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("translate %d", i), func(t *testing.T) {
+			config := Config{
+				Storage: Storage{
+					Trees: []Tree{test.in},
+				},
+			}
+			actual, translations, r := config.ToIgn3_7Unvalidated(common.TranslateOptions{
+				FilesDirs:      filesDirs,
+				FilesDirStrict: test.strict,
+			})
+			r = confutil.TranslateReportPaths(r, translations)
+			baseutil.VerifyReport(t, config, r)
+			assert.Equal(t, test.report, r.String(), "bad report")
+			if r.IsFatal() {
+				return
+			}
+			assert.NoError(t, translations.DebugVerifyCoverage(actual), "incomplete TranslationSet coverage")
+			if assert.Len(t, actual.Storage.Files, 1) {
+				assert.Equal(t, test.outPath, actual.Storage.Files[0].Path, "bad file path")
+			}
+		})
+	}
+}
+
+// TestTranslateFilesFS tests reading local files and trees from an fs.FS.
+func TestTranslateFilesFS(t *testing.T) {
+	filesFS := fstest.MapFS{
+		"file":          {Data: []byte("file contents")},
+		"tree/subdir/a": {Data: []byte("a"), Mode: 0755},
+		"tree/b":        {Data: []byte("b")},
+	}
+
+	config := Config{
+		Storage: Storage{
+			Files: []File{
+				{
+					Path: "/file",
+					Contents: Resource{
+						Local: util.StrToPtr("file"),
+					},
+				},
+			},
+			Trees: []Tree{
+				{
+					Local: "tree",
+					Path:  util.StrToPtr("/tree"),
+				},
+			},
+		},
+	}
+	actual, translations, r := config.ToIgn3_7Unvalidated(common.TranslateOptions{
+		FilesFS: filesFS,
+	})
+	r = confutil.TranslateReportPaths(r, translations)
+	baseutil.VerifyReport(t, config, r)
+	assert.Empty(t, r.Entries, "non-empty report")
+	assert.NoError(t, translations.DebugVerifyCoverage(actual), "incomplete TranslationSet coverage")
+	assert.Equal(t, []types.File{
+		{
+			Node: types.Node{
+				Path: "/file",
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Contents: types.Resource{
+					Source:      util.StrToPtr("data:,file%20contents"),
+					Compression: util.StrToPtr(""),
+				},
+			},
+		},
+		{
+			Node: types.Node{
+				Path: "/tree/b",
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Contents: types.Resource{
+					Source:      util.StrToPtr("data:,b"),
+					Compression: util.StrToPtr(""),
+				},
+				Mode: util.IntToPtr(0644),
+			},
+		},
+		{
+			Node: types.Node{
+				Path: "/tree/subdir/a",
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Contents: types.Resource{
+					Source:      util.StrToPtr("data:,a"),
+					Compression: util.StrToPtr(""),
+				},
+				Mode: util.IntToPtr(0755),
+			},
+		},
+	}, actual.Storage.Files, "bad files")
+
+	// path traversal is still rejected
+	config.Storage.Files[0].Contents.Local = util.StrToPtr("../file")
+	config.Storage.Trees = nil
+	_, _, r = config.ToIgn3_7Unvalidated(common.TranslateOptions{
+		FilesFS: filesFS,
+	})
+	assert.Equal(t, "error at $.storage.files.0.contents.local: "+common.ErrFilesDirEscape.Error()+"\n", r.String(), "bad report")
+}
+
 // TestTranslateIgnition tests translating the ct config.ignition to the ignition config.ignition section.
 // It ensures that the version is set as well.
 func TestTranslateIgnition(t *testing.T) {
@@ -1908,12 +2156,12 @@ func TestTranslateIgnition(t *testing.T) {
 		{
 			Ignition{
 				Config: IgnitionConfig{
-					Merge: []Resource{
+					Merge: []ConfigResource{
 						{
 							Inline: util.StrToPtr("xyzzy"),
 						},
 					},
-					Replace: Resource{
+					Replace: ConfigResource{
 						Inline: util.StrToPtr("xyzzy"),
 					},
 				},
@@ -1992,6 +2240,127 @@ func TestTranslateIgnition(t *testing.T) {
 	}
 }
 
+func TestTranslateButaneLocal(t *testing.T) {
+	filesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(filesDir, "child.bu"), []byte("child"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	childPath, err := filepath.Abs(filepath.Join(filesDir, "child.bu"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the stub translator checks its options and wraps its input
+	translator := func(input []byte, options common.TranslateBytesOptions) ([]byte, report.Report, error) {
+		var r report.Report
+		if !options.Raw {
+			return nil, r, fmt.Errorf("child not translated in raw mode")
+		}
+		parents := butanelocal.Parents(options.TranslateOptions)
+		if len(parents) == 0 || parents[len(parents)-1] != childPath {
+			return nil, r, fmt.Errorf("bad parents %v", parents)
+		}
+		r.AddOnWarn(path.New("yaml", "storage", "files", 0, "mode"), common.ErrDecimalMode)
+		return []byte("ignition:" + string(input)), r, nil
+	}
+	warning := common.ErrChildConfig{
+		File:    "child.bu",
+		Path:    "$.storage.files.0.mode",
+		Message: common.ErrDecimalMode.Error(),
+	}
+
+	tests := []struct {
+		in         ConfigResource
+		parents    []string
+		out        types.Resource
+		exceptions []translate.Translation
+		report     report.Report
+	}{
+		{
+			ConfigResource{
+				ButaneLocal: util.StrToPtr("child.bu"),
+			},
+			nil,
+			types.Resource{
+				Source:      util.StrToPtr("data:,ignition%3Achild"),
+				Compression: util.StrToPtr(""),
+			},
+			[]translate.Translation{
+				{
+					From: path.New("yaml", "butane_local"),
+					To:   path.New("json", "source"),
+				},
+				{
+					From: path.New("yaml", "butane_local"),
+					To:   path.New("json", "compression"),
+				},
+			},
+			report.Report{
+				Entries: []report.Entry{
+					{
+						Kind:    report.Warn,
+						Message: warning.Error(),
+						Context: path.New("yaml", "butane_local"),
+					},
+				},
+			},
+		},
+		// loop
+		{
+			ConfigResource{
+				ButaneLocal: util.StrToPtr("child.bu"),
+			},
+			[]string{childPath},
+			types.Resource{},
+			nil,
+			report.Report{
+				Entries: []report.Entry{
+					{
+						Kind:    report.Error,
+						Message: common.ErrButaneLocalLoop.Error(),
+						Context: path.New("yaml", "butane_local"),
+					},
+				},
+			},
+		},
+		// missing file
+		{
+			ConfigResource{
+				ButaneLocal: util.StrToPtr("missing.bu"),
+			},
+			nil,
+			types.Resource{},
+			nil,
+			report.Report{
+				Entries: []report.Entry{
+					{
+						Kind:    report.Error,
+						Message: "open " + filepath.Join(filesDir, "missing.bu") + ": " + osNotFound,
+						Context: path.New("yaml", "butane_local"),
+					},
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("translate %d", i), func(t *testing.T) {
+			options := butanelocal.WithTranslator(common.TranslateOptions{
+				FilesDir: filesDir,
+			}, translator)
+			for _, parent := range test.parents {
+				options = butanelocal.WithParent(options, parent)
+			}
+			actual, translations, r := translateConfigResource(test.in, options)
+			assert.Equal(t, test.out, actual, "translation mismatch")
+			assert.Equal(t, test.report, r, "report mismatch")
+			baseutil.VerifyTranslations(t, translations, test.exceptions)
+			if !r.IsFatal() {
+				assert.NoError(t, translations.DebugVerifyCoverage(actual), "incomplete TranslationSet coverage")
+			}
+		})
+	}
+}
+
 // TestTranslateKernelArguments tests translating the butane kernel_arguments.{should_exist,should_not_exist}.[i] entries to
 // ignition kernelArguments.{shouldExist,shouldNotExist}.[i] entries.
 //
@@ -2907,3 +3276,123 @@ WantedBy=multi-user.target`
 		})
 	}
 }
+
+// writeTestTree writes count small files to dir/tree, spread across
+// subdirectories, and returns their expected output paths in walk order.
+func writeTestTree(tb testing.TB, dir string, count int) []string {
+	var paths []string
+	for i := 0; i < count; i++ {
+		rel := fmt.Sprintf("tree/%02d/file-%05d", i%100, i)
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, rel)), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		contents := strings.Repeat(fmt.Sprintf("line for file %d\n", i), i%50)
+		if err := os.WriteFile(filepath.Join(dir, rel), []byte(contents), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	// filepath.Walk visits in lexical order
+	for sub := 0; sub < 100; sub++ {
+		for i := sub; i < count; i += 100 {
+			paths = append(paths, fmt.Sprintf("/%02d/file-%05d", sub, i))
+		}
+	}
+	return paths
+}
+
+// TestTranslateTreeOrder checks that trees encoded on the worker pool are
+// emitted in walk order, with each file's own contents.
+func TestTranslateTreeOrder(t *testing.T) {
+	filesDir := t.TempDir()
+	paths := writeTestTree(t, filesDir, 500)
+	config := Config{
+		Storage: Storage{
+			Trees: []Tree{
+				{
+					Local: "tree",
+				},
+			},
+		},
+	}
+	actual, translations, r := config.ToIgn3_7Unvalidated(common.TranslateOptions{
+		FilesDir: filesDir,
+	})
+	r = confutil.TranslateReportPaths(r, translations)
+	baseutil.VerifyReport(t, config, r)
+	assert.Equal(t, "", r.String(), "bad report")
+	assert.NoError(t, translations.DebugVerifyCoverage(actual), "incomplete TranslationSet coverage")
+	if !assert.Len(t, actual.Storage.Files, len(paths)) {
+		return
+	}
+	for i, file := range actual.Storage.Files {
+		assert.Equal(t, paths[i], file.Path, "bad file path")
+		var num int
+		if _, err := fmt.Sscanf(filepath.Base(file.Path), "file-%d", &num); err != nil {
+			t.Fatal(err)
+		}
+		expectedURI, expectedCompression := baseutil.CompressDataURL(t, []byte(strings.Repeat(fmt.Sprintf("line for file %d\n", num), num%50)))
+		assert.Equal(t, expectedURI, *file.Contents.Source, "bad contents for %s", file.Path)
+		assert.Equal(t, expectedCompression, *file.Contents.Compression, "bad compression for %s", file.Path)
+	}
+}
+
+// unreadableFS is a MapFS whose files named "unreadable" can't be read.
+type unreadableFS struct {
+	fstest.MapFS
+}
+
+func (f unreadableFS) ReadFile(name string) ([]byte, error) {
+	if filepath.Base(name) == "unreadable" {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.ReadFile(name)
+}
+
+// TestTranslateTreeReportOrder checks that errors encoding files on the
+// worker pool are reported in walk order with errors found by the walk.
+func TestTranslateTreeReportOrder(t *testing.T) {
+	filesFS := unreadableFS{fstest.MapFS{
+		"tree/a/unreadable": {Data: []byte("a")},
+		"tree/b/pipe":       {Mode: fs.ModeNamedPipe},
+		"tree/c/unreadable": {Data: []byte("c")},
+	}}
+	config := Config{
+		Storage: Storage{
+			Trees: []Tree{
+				{
+					Local: "tree",
+				},
+			},
+		},
+	}
+	_, translations, r := config.ToIgn3_7Unvalidated(common.TranslateOptions{
+		FilesFS: filesFS,
+	})
+	r = confutil.TranslateReportPaths(r, translations)
+	assert.Equal(t, "error at $.storage.trees.0: read tree/a/unreadable: permission denied\n"+
+		"error at $.storage.trees.0: "+common.ErrFileType.Error()+"\n"+
+		"error at $.storage.trees.0: read tree/c/unreadable: permission denied\n", r.String(), "bad report")
+}
+
+func BenchmarkTranslateTree10k(b *testing.B) {
+	filesDir := b.TempDir()
+	writeTestTree(b, filesDir, 10000)
+	config := Config{
+		Storage: Storage{
+			Trees: []Tree{
+				{
+					Local: "tree",
+				},
+			},
+		},
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _, r := config.ToIgn3_7Unvalidated(common.TranslateOptions{
+			FilesDir: filesDir,
+		})
+		if r.IsFatal() {
+			b.Fatal(r.String())
+		}
+	}
+}
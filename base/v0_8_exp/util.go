@@ -156,3 +156,15 @@ func ValidateIgnitionConfig(c path.ContextPath, rawConfig []byte) (report.Report
 	}
 	return r, nil
 }
+
+// resource returns the Resource fields of a ConfigResource.
+func (rs ConfigResource) resource() Resource {
+	return Resource{
+		Compression:  rs.Compression,
+		HTTPHeaders:  rs.HTTPHeaders,
+		Source:       rs.Source,
+		Inline:       rs.Inline,
+		Local:        rs.Local,
+		Verification: rs.Verification,
+	}
+}
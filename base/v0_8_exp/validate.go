@@ -45,6 +45,13 @@ func (rs Resource) Validate(c path.ContextPath) (r report.Report) {
 		r.AddOnError(c.Append(field), common.ErrTooManyResourceSources)
 		return
 	}
+	if rs.Verification.HashLocal != nil {
+		if rs.Verification.Hash != nil {
+			r.AddOnError(c.Append("verification", "hash_local"), common.ErrTooManyHashSources)
+		} else if rs.Source == nil {
+			r.AddOnError(c.Append("verification", "hash_local"), common.ErrHashLocalWithoutSource)
+		}
+	}
 	if strings.HasPrefix(c.String(), "$.ignition.config") {
 		if field == "inline" {
 			rp, err := ValidateIgnitionConfig(c, []byte(*rs.Inline))
@@ -58,6 +65,14 @@ func (rs Resource) Validate(c path.ContextPath) (r report.Report) {
 	return
 }
 
+func (rs ConfigResource) Validate(c path.ContextPath) (r report.Report) {
+	if rs.ButaneLocal != nil && (rs.Source != nil || rs.Inline != nil || rs.Local != nil) {
+		r.AddOnError(c.Append("butane_local"), common.ErrTooManyConfigSources)
+		return
+	}
+	return rs.resource().Validate(c)
+}
+
 func (fs Filesystem) Validate(c path.ContextPath) (r report.Report) {
 	if !util.IsTrue(fs.WithMountUnit) {
 		return
@@ -126,6 +126,95 @@ func TestValidateResource(t *testing.T) {
 			common.ErrTooManyResourceSources,
 			path.New("yaml", "source"),
 		},
+		// source + hash_local
+		{
+			Resource{
+				Source: util.StrToPtr("http://example/com"),
+				Verification: Verification{
+					HashLocal: util.StrToPtr("hello"),
+				},
+			},
+			nil,
+			path.New("yaml"),
+		},
+		// hash + hash_local, invalid
+		{
+			Resource{
+				Source: util.StrToPtr("http://example/com"),
+				Verification: Verification{
+					Hash:      util.StrToPtr("this isn't validated"),
+					HashLocal: util.StrToPtr("hello"),
+				},
+			},
+			common.ErrTooManyHashSources,
+			path.New("yaml", "verification", "hash_local"),
+		},
+		// inline + hash_local, invalid
+		{
+			Resource{
+				Inline: util.StrToPtr("hello"),
+				Verification: Verification{
+					HashLocal: util.StrToPtr("hello"),
+				},
+			},
+			common.ErrHashLocalWithoutSource,
+			path.New("yaml", "verification", "hash_local"),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("validate %d", i), func(t *testing.T) {
+			actual := test.in.Validate(path.New("yaml"))
+			baseutil.VerifyReport(t, test.in, actual)
+			expected := report.Report{}
+			expected.AddOnError(test.errPath, test.out)
+			assert.Equal(t, expected, actual, "bad report")
+		})
+	}
+}
+
+func TestValidateConfigResource(t *testing.T) {
+	tests := []struct {
+		in      ConfigResource
+		out     error
+		errPath path.ContextPath
+	}{
+		{},
+		// butane_local, valid
+		{
+			ConfigResource{
+				ButaneLocal: util.StrToPtr("child.bu"),
+			},
+			nil,
+			path.New("yaml"),
+		},
+		// butane_local + local, invalid
+		{
+			ConfigResource{
+				Local:       util.StrToPtr("child.ign"),
+				ButaneLocal: util.StrToPtr("child.bu"),
+			},
+			common.ErrTooManyConfigSources,
+			path.New("yaml", "butane_local"),
+		},
+		// butane_local + source, invalid
+		{
+			ConfigResource{
+				Source:      util.StrToPtr("http://example.com/child.ign"),
+				ButaneLocal: util.StrToPtr("child.bu"),
+			},
+			common.ErrTooManyConfigSources,
+			path.New("yaml", "butane_local"),
+		},
+		// source + inline, invalid
+		{
+			ConfigResource{
+				Source: util.StrToPtr("http://example.com/child.ign"),
+				Inline: util.StrToPtr("{}"),
+			},
+			common.ErrTooManyResourceSources,
+			path.New("yaml", "source"),
+		},
 	}
 
 	for i, test := range tests {
@@ -0,0 +1,239 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package butane
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/coreos/butane/config"
+	cutil "github.com/coreos/butane/config/util"
+
+	"github.com/coreos/vcontext/report"
+	"github.com/coreos/vcontext/validate"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownSpec = errors.New("struct isn't a registered Butane spec")
+)
+
+// FromSpec returns a Config for a spec struct constructed in code, such as
+// a v1_7.Config or a pointer to one.  The variant and version fields are
+// filled in from the struct type; spec itself isn't modified.  Call
+// Validate to check the result, and Marshal to produce Butane YAML.
+func FromSpec(spec cutil.Config) (*Config, error) {
+	variant, version, ok := config.SpecVersion(spec)
+	if !ok {
+		return nil, ErrUnknownSpec
+	}
+	v := reflect.ValueOf(spec)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	copied.FieldByName("Variant").SetString(variant)
+	copied.FieldByName("Version").SetString(version.String())
+
+	// Parse the canonical YAML so reports can point into it.
+	input, err := marshalSpec(copied)
+	if err != nil {
+		return nil, err
+	}
+	c, _, err := Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	c.spec = copied.Interface().(cutil.Config)
+	return c, nil
+}
+
+// Validate runs the spec's validation, the same checks translation runs
+// before translating.  For configs from FromSpec, locations in the report
+// refer to the output of Marshal.
+func (c *Config) Validate() report.Report {
+	r := validate.Validate(c.spec, "yaml")
+	r.Correlate(c.contextTree)
+	return r
+}
+
+// Marshal returns c as canonical Butane YAML: variant and version first,
+// then fields in spec order, omitting unset fields.  Comments and
+// formatting of parsed configs aren't preserved.  Translating the result
+// gives the same output as translating c.
+func (c *Config) Marshal() ([]byte, error) {
+	return marshalSpec(reflect.ValueOf(c.spec))
+}
+
+// Ptr returns a pointer to v, for setting optional spec fields.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+func marshalSpec(v reflect.Value) ([]byte, error) {
+	node, err := encodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	// Put variant and version first.
+	var variant, version, rest []*yaml.Node
+	for i := 0; i < len(node.Content); i += 2 {
+		pair := node.Content[i : i+2 : i+2]
+		switch pair[0].Value {
+		case "variant":
+			variant = pair
+		case "version":
+			version = pair
+		default:
+			rest = append(rest, pair...)
+		}
+	}
+	node.Content = append(append(variant, version...), rest...)
+	return yaml.Marshal(node)
+}
+
+// encodeValue converts a spec value to a YAML node, omitting empty
+// fields.  It returns nil for an empty value.  Non-nil pointers are never
+// empty, since an explicit zero value can translate differently from an
+// unset field.
+func encodeValue(v reflect.Value) (*yaml.Node, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		node, err := encodeValue(v.Elem())
+		if err != nil || node != nil {
+			return node, err
+		}
+		return encodeZero(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return encodeValue(v.Elem())
+	case reflect.Struct:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		if err := encodeFields(node, v); err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return node, nil
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for i := 0; i < v.Len(); i++ {
+			child, err := encodeValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			if child == nil {
+				// keep the entry, so indexes are unchanged
+				child = &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range keys {
+			child, err := encodeValue(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			if child == nil {
+				continue
+			}
+			node.Content = append(node.Content, keyNode(key.String()), child)
+		}
+		return node, nil
+	default:
+		if v.IsZero() {
+			return nil, nil
+		}
+		var node yaml.Node
+		if err := node.Encode(v.Interface()); err != nil {
+			return nil, err
+		}
+		return &node, nil
+	}
+}
+
+// encodeZero returns the YAML node for an explicitly set empty value.
+func encodeZero(v reflect.Value) (*yaml.Node, error) {
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map:
+		return &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle}, nil
+	case reflect.Slice:
+		return &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}, nil
+	default:
+		var node yaml.Node
+		if err := node.Encode(v.Interface()); err != nil {
+			return nil, err
+		}
+		return &node, nil
+	}
+}
+
+// encodeFields appends the non-empty fields of struct v to node, flattening
+// inline structs.
+func encodeFields(node *yaml.Node, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && strings.Contains(opts, "inline") {
+			if err := encodeFields(node, v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		child, err := encodeValue(v.Field(i))
+		if err != nil {
+			return err
+		}
+		if child == nil {
+			continue
+		}
+		node.Content = append(node.Content, keyNode(name), child)
+	}
+	return nil
+}
+
+func keyNode(name string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+}
@@ -0,0 +1,197 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package butane
+
+import (
+	"context"
+	"testing"
+
+	base "github.com/coreos/butane/base/v0_7"
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+	fcos1_7 "github.com/coreos/butane/config/fcos/v1_7"
+	cutil "github.com/coreos/butane/config/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type notSpec struct{}
+
+func (notSpec) FieldFilters() *cutil.FieldFilters {
+	return nil
+}
+
+func TestBuilder(t *testing.T) {
+	spec := fcos1_7.Config{
+		Config: base.Config{
+			Passwd: base.Passwd{
+				Users: []base.PasswdUser{{
+					Name:              "core",
+					SSHAuthorizedKeys: []base.SSHAuthorizedKey{"ssh-ed25519 AAAA"},
+				}},
+			},
+			Storage: base.Storage{
+				Files: []base.File{{
+					Path: "/etc/motd",
+					Mode: Ptr(0644),
+					Contents: base.Resource{
+						Inline: Ptr("hello\nworld\n"),
+					},
+				}, {
+					Path: "/etc/true",
+					Contents: base.Resource{
+						Inline: Ptr("true"),
+					},
+				}},
+			},
+		},
+		BootDevice: fcos1_7.BootDevice{
+			Layout: Ptr("x86_64"),
+			Mirror: fcos1_7.BootDeviceMirror{
+				Devices: []string{"/dev/vda", "/dev/vdb"},
+			},
+		},
+	}
+	c, err := FromSpec(&spec)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "", spec.Variant, "input modified")
+	assert.Equal(t, "fcos", c.Variant())
+	assert.Equal(t, "1.7.0", c.Version().String())
+	assert.Empty(t, c.Validate().Entries, "bad validation")
+
+	out, err := c.Marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, `variant: fcos
+version: 1.7.0
+passwd:
+    users:
+        - name: core
+          ssh_authorized_keys:
+            - ssh-ed25519 AAAA
+storage:
+    files:
+        - path: /etc/motd
+          contents:
+            inline: |
+                hello
+                world
+          mode: 420
+        - path: /etc/true
+          contents:
+            inline: "true"
+boot_device:
+    layout: x86_64
+    mirror:
+        devices:
+            - /dev/vda
+            - /dev/vdb
+`, string(out), "bad YAML")
+
+	// round trip
+	parsed, _, err := Parse(out)
+	assert.NoError(t, err)
+	spec.Variant = "fcos"
+	spec.Version = "1.7.0"
+	assert.Equal(t, spec, parsed.Spec(), "round trip changed spec")
+	_, _, err = config.TranslateBytes(out, common.TranslateBytesOptions{})
+	assert.NoError(t, err)
+	ign, err := ToIgnition[any](context.Background(), c, common.TranslateOptions{})
+	assert.NoError(t, err)
+	parsedIgn, err := ToIgnition[any](context.Background(), parsed, common.TranslateOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, ign.Output, parsedIgn.Output, "round trip changed translation")
+}
+
+func TestBuilderZeroValues(t *testing.T) {
+	spec := fcos1_7.Config{
+		Config: base.Config{
+			Storage: base.Storage{
+				Files: []base.File{{
+					Path: "/etc/empty",
+					Mode: Ptr(0),
+					Contents: base.Resource{
+						Inline: Ptr(""),
+					},
+				}},
+			},
+			Systemd: base.Systemd{
+				Units: []base.Unit{{
+					Name:    "a.service",
+					Enabled: Ptr(false),
+				}},
+			},
+		},
+	}
+	c, err := FromSpec(spec)
+	if !assert.NoError(t, err) {
+		return
+	}
+	out, err := c.Marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, `variant: fcos
+version: 1.7.0
+storage:
+    files:
+        - path: /etc/empty
+          contents:
+            inline: ""
+          mode: 0
+systemd:
+    units:
+        - enabled: false
+          name: a.service
+`, string(out), "bad YAML")
+
+	parsed, _, err := Parse(out)
+	assert.NoError(t, err)
+	spec.Variant = "fcos"
+	spec.Version = "1.7.0"
+	assert.Equal(t, spec, parsed.Spec(), "round trip changed spec")
+	ign, err := ToIgnition[any](context.Background(), c, common.TranslateOptions{})
+	assert.NoError(t, err)
+	parsedIgn, err := ToIgnition[any](context.Background(), parsed, common.TranslateOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, ign.Output, parsedIgn.Output, "round trip changed translation")
+	translated, _, err := config.TranslateBytes(out, common.TranslateBytesOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"ignition":{"version":"3.6.0"},"storage":{"files":[{"path":"/etc/empty","contents":{"compression":"","source":"data:,"},"mode":0}]},"systemd":{"units":[{"enabled":false,"name":"a.service"}]}}`, string(translated), "bad translation")
+}
+
+func TestBuilderValidate(t *testing.T) {
+	c, err := FromSpec(fcos1_7.Config{
+		Config: base.Config{
+			Storage: base.Storage{
+				Files: []base.File{{
+					Path: "/a",
+					Contents: base.Resource{
+						Inline: Ptr("a"),
+						Source: Ptr("https://example.com/a"),
+					},
+				}},
+			},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "error at $.storage.files.0.contents.source, line 7 col 21: "+common.ErrTooManyResourceSources.Error()+"\n", c.Validate().String())
+	_, err = ToIgnition[any](context.Background(), c, common.TranslateOptions{})
+	assert.Equal(t, common.ErrInvalidSourceConfig, err)
+
+	_, err = FromSpec(notSpec{})
+	assert.Equal(t, ErrUnknownSpec, err)
+}
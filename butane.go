@@ -0,0 +1,180 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+// Package butane is a typed API for parsing or building Butane configs of
+// any variant and spec version and translating them to Ignition configs or
+// MachineConfigs.
+package butane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+	cutil "github.com/coreos/butane/config/util"
+	"github.com/coreos/butane/internal/butanelocal"
+	"github.com/coreos/butane/translate"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/coreos/vcontext/report"
+	"github.com/coreos/vcontext/tree"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrNoMachineConfig = errors.New("variant doesn't support MachineConfig output")
+
+	ignitionMethodRe      = regexp.MustCompile(`^ToIgn\d+_\d+Unvalidated$`)
+	machineConfigMethodRe = regexp.MustCompile(`^ToMachineConfig\d+_\d+Unvalidated$`)
+)
+
+// ErrOutputType is returned when the requested output type doesn't match
+// the type the config translates to.
+type ErrOutputType struct {
+	Requested string
+	Actual    string
+}
+
+func (e ErrOutputType) Error() string {
+	return fmt.Sprintf("config translates to %s, not %s", e.Actual, e.Requested)
+}
+
+// Config is a parsed Butane config.
+type Config struct {
+	variant     string
+	version     semver.Version
+	spec        cutil.Config
+	contextTree tree.Node
+}
+
+// Translation is the result of translating a Config.
+type Translation[T any] struct {
+	// Output is the translated config.
+	Output T
+	// Translations maps paths in Output to paths in the Butane config.
+	Translations translate.TranslationSet
+	// Report lists errors and warnings, located in the Butane config.
+	Report report.Report
+}
+
+// Parse parses a Butane config of any registered variant and spec
+// version.  The returned report lists any unused keys.
+func Parse(input []byte) (*Config, report.Report, error) {
+	var fields struct {
+		Variant string `yaml:"variant"`
+		Version string `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(input, &fields); err != nil {
+		return nil, report.Report{}, common.ErrUnmarshal{
+			Detail: err.Error(),
+		}
+	}
+	if fields.Variant == "" {
+		return nil, report.Report{}, common.ErrNoVariant
+	}
+	version, err := semver.NewVersion(fields.Version)
+	if err != nil {
+		return nil, report.Report{}, common.ErrInvalidVersion
+	}
+	spec, err := config.NewSpecConfig(fields.Variant, *version)
+	if err != nil {
+		return nil, report.Report{}, err
+	}
+	contextTree, r, err := cutil.Parse(input, spec)
+	if err != nil {
+		return nil, r, err
+	}
+	return &Config{
+		variant:     fields.Variant,
+		version:     *version,
+		spec:        reflect.ValueOf(spec).Elem().Interface().(cutil.Config),
+		contextTree: contextTree,
+	}, r, nil
+}
+
+// Variant returns the variant of c.
+func (c *Config) Variant() string {
+	return c.variant
+}
+
+// Version returns the spec version of c.
+func (c *Config) Version() semver.Version {
+	return c.version
+}
+
+// Spec returns the spec-specific struct c was parsed into, such as a
+// v1_6.Config for fcos 1.6.0.
+func (c *Config) Spec() any {
+	return c.spec
+}
+
+// ToIgnition translates c to an Ignition config.  T must be the
+// types.Config of the Ignition spec version produced by c's spec version,
+// such as the v3_5 types.Config for fcos 1.6.0, or any.  Translation
+// stops with ctx's error if ctx is canceled.
+func ToIgnition[T any](ctx context.Context, c *Config, options common.TranslateOptions) (Translation[T], error) {
+	return translateTo[T](ctx, c, ignitionMethodRe, options)
+}
+
+// ToMachineConfig translates c to a MachineConfig.  c must have the
+// openshift variant, and T must be the result.MachineConfig of its spec
+// version, or any.  Translation stops with ctx's error if ctx is
+// canceled.
+func ToMachineConfig[T any](ctx context.Context, c *Config, options common.TranslateOptions) (Translation[T], error) {
+	return translateTo[T](ctx, c, machineConfigMethodRe, options)
+}
+
+func translateTo[T any](ctx context.Context, c *Config, methodRe *regexp.Regexp, options common.TranslateOptions) (Translation[T], error) {
+	var result Translation[T]
+	method, ok := findMethod(reflect.TypeOf(c.spec), methodRe)
+	if !ok {
+		return result, ErrNoMachineConfig
+	}
+	options.Context = ctx
+	options = butanelocal.WithTranslator(options, config.TranslateBytes)
+
+	final, translations, r, err := cutil.TranslateWithTranslations(c.spec, method.Name, options)
+	r.Correlate(c.contextTree)
+	if options.SourceMap != nil {
+		cutil.LocateSources(options.SourceMap, c.contextTree)
+	}
+	result.Translations = translations
+	result.Report = r
+	if err != nil {
+		return result, err
+	}
+	output, ok := final.(T)
+	if !ok {
+		return result, ErrOutputType{
+			Requested: reflect.TypeOf(&result.Output).Elem().String(),
+			Actual:    method.Type.Out(0).String(),
+		}
+	}
+	result.Output = output
+	return result, nil
+}
+
+// findMethod returns the translation method of t matching re.
+func findMethod(t reflect.Type, re *regexp.Regexp) (reflect.Method, bool) {
+	for i := 0; i < t.NumMethod(); i++ {
+		if method := t.Method(i); re.MatchString(method.Name) {
+			return method, true
+		}
+	}
+	return reflect.Method{}, false
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package butane
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/butane/config/common"
+	fcos1_6 "github.com/coreos/butane/config/fcos/v1_6"
+	"github.com/coreos/butane/config/openshift/v4_22/result"
+
+	"github.com/coreos/go-semver/semver"
+	v3_4 "github.com/coreos/ignition/v2/config/v3_4/types"
+	v3_5 "github.com/coreos/ignition/v2/config/v3_5/types"
+	"github.com/coreos/vcontext/path"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	c, r, err := Parse([]byte(`variant: fcos
+version: 1.6.0
+storage:
+  files:
+    - path: /a
+      mode: 0600
+foo: bar
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "warning at $.foo, line 7 col 1: unused key foo\n", r.String(), "bad parse report")
+	assert.Equal(t, "fcos", c.Variant())
+	assert.Equal(t, *semver.New("1.6.0"), c.Version())
+	assert.IsType(t, fcos1_6.Config{}, c.Spec())
+
+	ign, err := ToIgnition[v3_5.Config](context.Background(), c, common.TranslateOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "3.5.0", ign.Output.Ignition.Version)
+	assert.Equal(t, 0600, *ign.Output.Storage.Files[0].Mode)
+	assert.Equal(t, path.New("yaml", "storage", "files", 0, "mode"), ign.Translations.Set["$.storage.files.0.mode"].From)
+
+	anyIgn, err := ToIgnition[any](context.Background(), c, common.TranslateOptions{})
+	assert.NoError(t, err)
+	assert.IsType(t, v3_5.Config{}, anyIgn.Output)
+
+	_, err = ToIgnition[v3_4.Config](context.Background(), c, common.TranslateOptions{})
+	assert.Equal(t, ErrOutputType{Requested: "types.Config", Actual: "types.Config"}, err, "wrong type accepted")
+
+	_, err = ToMachineConfig[any](context.Background(), c, common.TranslateOptions{})
+	assert.Equal(t, ErrNoMachineConfig, err, "bad error for MachineConfig")
+}
+
+func TestTranslateMachineConfig(t *testing.T) {
+	c, _, err := Parse([]byte(`variant: openshift
+version: 4.22.0
+metadata:
+  name: worker
+  labels:
+    machineconfiguration.openshift.io/role: worker
+passwd:
+  users:
+    - name: admin
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = ToMachineConfig[result.MachineConfig](context.Background(), c, common.TranslateOptions{})
+	assert.Equal(t, common.ErrInvalidSourceConfig, err, "bad error")
+
+	c, _, err = Parse([]byte(`variant: openshift
+version: 4.22.0
+metadata:
+  name: worker
+  labels:
+    machineconfiguration.openshift.io/role: worker
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	mc, err := ToMachineConfig[result.MachineConfig](context.Background(), c, common.TranslateOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "worker", mc.Output.Metadata.Name)
+	assert.Empty(t, mc.Report.Entries)
+}
+
+func TestTranslateCanceled(t *testing.T) {
+	filesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(filesDir, "a"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, _, err := Parse([]byte(`variant: fcos
+version: 1.6.0
+storage:
+  trees:
+    - local: .
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ToIgnition[any](ctx, c, common.TranslateOptions{FilesDir: filesDir})
+	assert.Equal(t, context.Canceled, err, "bad error")
+}
+
+func TestParseErrors(t *testing.T) {
+	_, _, err := Parse([]byte("version: 1.6.0\n"))
+	assert.Equal(t, common.ErrNoVariant, err)
+	_, _, err = Parse([]byte("variant: fcos\nversion: 1.9.9\n"))
+	assert.Equal(t, common.ErrUnknownVersion{Variant: "fcos", Version: *semver.New("1.9.9"), Suggestions: []semver.Version{*semver.New("1.7.0")}}, err)
+	_, r, err := Parse([]byte("variant: fcos\nversion: 1.6.0\nstorage: 5\n"))
+	assert.Error(t, err)
+	assert.Empty(t, r.Entries)
+}
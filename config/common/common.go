@@ -14,14 +14,109 @@
 
 package common
 
+import (
+	"context"
+	"io/fs"
+	"net/http"
+)
+
 type TranslateOptions struct {
-	FilesDir                  string // allow embedding local files relative to this directory
-	NoResourceAutoCompression bool   // skip automatic compression of inline/local resources
-	DebugPrintTranslations    bool   // report translations to stderr
+	FilesDir                  string          // allow embedding local files relative to this directory
+	FilesFS                   fs.FS           // allow embedding local files from this filesystem, searched after FilesDir
+	FilesDirs                 []FilesDirEntry // additional directories searched in order after FilesDir and FilesFS
+	FilesDirStrict            bool            // fail if a local path exists in more than one files directory
+	NoResourceAutoCompression bool            // skip automatic compression of inline/local resources
+	InlineRemote              bool            // fetch remote resources and embed them in the config
+	Fetcher                   Fetcher         // fetcher for InlineRemote; defaults to HTTP(S) only
+	SourceMap                 SourceMap       // if non-nil, filled with the source location of each output field
+	ReportAll                 bool            // keep checking after translation errors, so the report lists every problem
+	IgnitionVersion           string          // check that the output fits this older Ignition spec version; TranslateBytes also rewrites it
+	Context                   context.Context // if set, cancels translation, including storage.trees walks
+	DataURLCache              DataURLCache    // if set, memoizes the data URLs of inline and local resources
+	DebugPrintTranslations    bool            // report translations to stderr
+}
+
+// FilesDirEntry is a directory to be searched for local files.
+type FilesDirEntry struct {
+	Name string // optional name, for selecting the directory from the config
+	Path string // directory in the host filesystem, or description of FS
+	FS   fs.FS  // if set, read from this filesystem rather than Path; must allow concurrent reads
+}
+
+func (d FilesDirEntry) String() string {
+	desc := d.Path
+	if desc == "" && d.FS != nil {
+		desc = "<filesystem>"
+	}
+	if d.Name != "" {
+		return d.Name + "=" + desc
+	}
+	return desc
+}
+
+// SymlinkFS is an fs.FS which can report on symlinks without following
+// them.  If the FS of a FilesDirEntry implements it, storage.trees can
+// contain symlinks.
+type SymlinkFS interface {
+	fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+	ReadLink(name string) (string, error)
+}
+
+// SourceLocation is the location of a field in a source config.
+type SourceLocation struct {
+	File      string `json:"file,omitempty"` // empty for the input config
+	Path      string `json:"path"`
+	Line      int64  `json:"line,omitempty"` // 0 if unknown
+	Column    int64  `json:"column,omitempty"`
+	EndLine   int64  `json:"end_line,omitempty"` // last character of the field; 0 if unknown
+	EndColumn int64  `json:"end_column,omitempty"`
+}
+
+// SourceMap maps paths in an output config, such as
+// "$.storage.files.0.path", to the locations they were generated from.
+type SourceMap map[string]SourceLocation
+
+// DataURLCache memoizes the data URL encoding of resource contents.  Keys
+// are derived from a hash of the contents and the compression settings.
+// Implementations must be safe for concurrent use.
+type DataURLCache interface {
+	// Get returns the data URL and compression value stored for key.
+	Get(key string) (uri string, compression string, ok bool)
+	// Put stores a data URL and compression value for key.
+	Put(key string, uri string, compression string) error
+}
+
+// Fetcher retrieves the contents of remote resources when InlineRemote is
+// set.
+type Fetcher interface {
+	Fetch(url string, options FetchOptions) ([]byte, error)
+}
+
+// FetchOptions are the config-specified parameters for fetching a
+// resource.
+type FetchOptions struct {
+	Headers                http.Header
+	HTTPProxy              string
+	HTTPSProxy             string
+	NoProxy                []string
+	CertificateAuthorities [][]byte        // PEM-encoded, in addition to the system roots
+	Context                context.Context // if set, cancels the fetch
 }
 
 type TranslateBytesOptions struct {
 	TranslateOptions
-	Pretty bool
-	Raw    bool // encode only the Ignition config, not any wrapper
+	Pretty  bool
+	Raw     bool     // encode only the Ignition config, not any wrapper
+	Wrapper *Wrapper // if set, embed the output in a Kubernetes object
+}
+
+// Wrapper describes a Kubernetes Secret or ConfigMap for embedding a
+// translated config, such as a Cluster API bootstrap data secret.
+type Wrapper struct {
+	Kind      string            // "Secret" or "ConfigMap"
+	Name      string            // required
+	Namespace string            // optional
+	Labels    map[string]string // optional
+	Key       string            // data key for the config; defaults to "value"
 }
@@ -17,6 +17,7 @@ package common
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/coreos/go-semver/semver"
 )
@@ -41,6 +42,14 @@ var (
 	ErrNoFilesDir             = errors.New("local file paths are relative to a files directory that must be specified with -d/--files-dir")
 	ErrTreeNotDirectory       = errors.New("root of tree must be a directory")
 	ErrTreeNoLocal            = errors.New("local is required")
+	ErrHashMismatch           = errors.New("contents don't match verification.hash")
+	ErrTooManyHashSources     = errors.New("only one of the following can be set: hash, hash_local")
+	ErrHashLocalWithoutSource = errors.New("hash_local can only be used with source")
+	ErrHashCompression        = errors.New("can't compute hash of contents with this compression type")
+	ErrTooManyConfigSources   = errors.New("only one of the following can be set: inline, local, source, butane_local")
+	ErrButaneLocalLoop        = errors.New("butane_local config includes itself")
+	ErrNoButaneTranslator     = errors.New("butane_local requires translating with config.TranslateBytes")
+	ErrFlattenRemote          = errors.New("can't flatten remote config; embed it with --inline-remote")
 
 	// filesystem nodes
 	ErrDecimalMode = errors.New("unreasonable mode would be reasonable if specified in octal; remember to add a leading zero")
@@ -99,6 +108,7 @@ var (
 	ErrLinkSupport       = errors.New("links are not supported in this spec version")
 	ErrLuksSupport       = errors.New("luks is not supported in this spec version")
 	ErrRaidSupport       = errors.New("raid is not supported in this spec version")
+	ErrPathForbidden     = errors.New("path is not allowed in this spec version")
 
 	// Grub
 	ErrGrubUserNameNotSpecified = errors.New("field \"name\" is required")
@@ -109,6 +119,13 @@ var (
 
 	// Unkown ignition version
 	ErrUnkownIgnitionVersion = errors.New("skipping validation for the merge/replace ignition config due to an unkown version")
+
+	// Ignition output version
+	ErrIgnitionVersionOutput = errors.New("Ignition spec version can only be selected for Ignition output; use -r/--raw")
+
+	// Kubernetes wrappers
+	ErrWrapperKind = errors.New("wrapper kind must be \"Secret\" or \"ConfigMap\"")
+	ErrWrapperName = errors.New("wrapper name must be specified")
 )
 
 type ErrUnmarshal struct {
@@ -121,11 +138,171 @@ func (e ErrUnmarshal) Error() string {
 	return fmt.Sprintf("Error unmarshaling yaml: %v", e.Detail)
 }
 
+type ErrUnknownFilesDir struct {
+	Name string
+}
+
+func (e ErrUnknownFilesDir) Error() string {
+	return fmt.Sprintf("no files directory named %q was specified with -d/--files-dir", e.Name)
+}
+
+type ErrAmbiguousLocalPath struct {
+	Dirs []string
+}
+
+func (e ErrAmbiguousLocalPath) Error() string {
+	return fmt.Sprintf("local path exists in multiple files directories: %s", strings.Join(e.Dirs, ", "))
+}
+
+// Informational, not an error; reported when multiple files directories
+// are in use.
+type ErrLocalFileSource struct {
+	Dir string
+}
+
+func (e ErrLocalFileSource) Error() string {
+	return fmt.Sprintf("read from files directory %s", e.Dir)
+}
+
+type ErrFetchScheme struct {
+	Scheme string
+}
+
+func (e ErrFetchScheme) Error() string {
+	return fmt.Sprintf("can't fetch %q URLs; use --url-map to map them to a local directory", e.Scheme)
+}
+
+type ErrFetchStatus struct {
+	URL    string
+	Status string
+}
+
+func (e ErrFetchStatus) Error() string {
+	return fmt.Sprintf("fetching %s: %s", e.URL, e.Status)
+}
+
+type ErrFetchTooLarge struct {
+	URL     string
+	MaxSize int64
+}
+
+func (e ErrFetchTooLarge) Error() string {
+	return fmt.Sprintf("fetching %s: response larger than %d bytes", e.URL, e.MaxSize)
+}
+
+// Wraps a report entry from a butane_local child config.
+type ErrChildConfig struct {
+	File    string
+	Line    int64 // 0 if unknown
+	Column  int64
+	Path    string // empty if unknown
+	Message string
+}
+
+func (e ErrChildConfig) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", location, e.Line, e.Column)
+	}
+	if e.Path != "" {
+		return fmt.Sprintf("%s [%s]: %s", location, e.Path, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", location, e.Message)
+}
+
+type ErrFlattenVersion struct {
+	Version  string
+	Expected string
+}
+
+func (e ErrFlattenVersion) Error() string {
+	return fmt.Sprintf("can't flatten config with Ignition spec version %q into version %q", e.Version, e.Expected)
+}
+
 type ErrUnknownVersion struct {
 	Variant string
 	Version semver.Version
+	// closest supported versions of the variant, if any
+	Suggestions []semver.Version
 }
 
 func (e ErrUnknownVersion) Error() string {
-	return fmt.Sprintf("No translator exists for variant %s with version %s", e.Variant, e.Version)
+	msg := fmt.Sprintf("No translator exists for variant %s with version %s", e.Variant, e.Version)
+	if len(e.Suggestions) == 0 {
+		return msg
+	}
+	var suggestions []string
+	for _, v := range e.Suggestions {
+		suggestions = append(suggestions, v.String())
+	}
+	return fmt.Sprintf("%s; did you mean %s?", msg, strings.Join(suggestions, " or "))
+}
+
+type ErrUnknownIgnitionSpec struct {
+	Version string
+}
+
+func (e ErrUnknownIgnitionSpec) Error() string {
+	return fmt.Sprintf("unknown Ignition spec version %q", e.Version)
+}
+
+type ErrIgnitionVersionNewer struct {
+	Version string
+	Current string
+}
+
+func (e ErrIgnitionVersionNewer) Error() string {
+	return fmt.Sprintf("can't convert Ignition spec version %s to newer version %s; use a newer Butane spec version", e.Current, e.Version)
+}
+
+type ErrIgnitionVersionField struct {
+	Version string
+}
+
+func (e ErrIgnitionVersionField) Error() string {
+	return fmt.Sprintf("field is not supported in Ignition spec version %s", e.Version)
+}
+
+type ErrExtensionOutput struct {
+	Variant string
+	Version string
+}
+
+func (e ErrExtensionOutput) Error() string {
+	return fmt.Sprintf("can't extend variant %s version %s; only specs with Ignition config output can be extended", e.Variant, e.Version)
+}
+
+type ErrSectionName struct {
+	Name string
+}
+
+func (e ErrSectionName) Error() string {
+	return fmt.Sprintf("section name %q is empty or already in use", e.Name)
+}
+
+type ErrSectionOutput struct {
+	Name     string
+	Type     string
+	Expected string
+}
+
+func (e ErrSectionOutput) Error() string {
+	return fmt.Sprintf("section %s desugared to %s, not %s", e.Name, e.Type, e.Expected)
+}
+
+type ErrVariantRegistered struct {
+	Variant string
+	Version string
+}
+
+func (e ErrVariantRegistered) Error() string {
+	return fmt.Sprintf("variant %s version %s is already registered", e.Variant, e.Version)
+}
+
+type ErrVariantDefField struct {
+	Field string
+}
+
+func (e ErrVariantDefField) Error() string {
+	return fmt.Sprintf("variant definition must specify %s", e.Field)
 }
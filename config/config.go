@@ -16,6 +16,10 @@ package config
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/coreos/butane/config/common"
 	fcos1_0 "github.com/coreos/butane/config/fcos/v1_0"
@@ -51,6 +55,8 @@ import (
 	r4e1_0 "github.com/coreos/butane/config/r4e/v1_0"
 	r4e1_1 "github.com/coreos/butane/config/r4e/v1_1"
 	r4e1_2_exp "github.com/coreos/butane/config/r4e/v1_2_exp"
+	cutil "github.com/coreos/butane/config/util"
+	"github.com/coreos/butane/internal/butanelocal"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/coreos/vcontext/report"
@@ -58,9 +64,20 @@ import (
 )
 
 var (
-	registry = map[string]translator{}
+	// registryLock guards registry and specConfigs
+	registryLock sync.RWMutex
+	registry     = map[string]translator{}
+	specConfigs  = map[string]reflect.Type{}
 )
 
+// RegisteredVersion is a variant and spec version with a registered
+// translator.
+type RegisteredVersion struct {
+	Variant      string
+	Version      semver.Version
+	Experimental bool
+}
+
 // Fields that must be included in the root struct of every spec version.
 type commonFields struct {
 	Version string `yaml:"version"`
@@ -68,64 +85,202 @@ type commonFields struct {
 }
 
 func init() {
-	RegisterTranslator("fcos", "1.0.0", fcos1_0.ToIgn3_0Bytes)
-	RegisterTranslator("fcos", "1.1.0", fcos1_1.ToIgn3_1Bytes)
-	RegisterTranslator("fcos", "1.2.0", fcos1_2.ToIgn3_2Bytes)
-	RegisterTranslator("fcos", "1.3.0", fcos1_3.ToIgn3_2Bytes)
-	RegisterTranslator("fcos", "1.4.0", fcos1_4.ToIgn3_3Bytes)
-	RegisterTranslator("fcos", "1.5.0", fcos1_5.ToIgn3_4Bytes)
-	RegisterTranslator("fcos", "1.6.0", fcos1_6.ToIgn3_5Bytes)
-	RegisterTranslator("fcos", "1.7.0", fcos1_7.ToIgn3_6Bytes)
-	RegisterTranslator("fcos", "1.8.0-experimental", fcos1_8_exp.ToIgn3_7Bytes)
-	RegisterTranslator("flatcar", "1.0.0", flatcar1_0.ToIgn3_3Bytes)
-	RegisterTranslator("flatcar", "1.1.0", flatcar1_1.ToIgn3_4Bytes)
-	RegisterTranslator("flatcar", "1.2.0-experimental", flatcar1_2_exp.ToIgn3_7Bytes)
-	RegisterTranslator("openshift", "4.8.0", openshift4_8.ToConfigBytes)
-	RegisterTranslator("openshift", "4.9.0", openshift4_9.ToConfigBytes)
-	RegisterTranslator("openshift", "4.10.0", openshift4_10.ToConfigBytes)
-	RegisterTranslator("openshift", "4.11.0", openshift4_11.ToConfigBytes)
-	RegisterTranslator("openshift", "4.12.0", openshift4_12.ToConfigBytes)
-	RegisterTranslator("openshift", "4.13.0", openshift4_13.ToConfigBytes)
-	RegisterTranslator("openshift", "4.14.0", openshift4_14.ToConfigBytes)
-	RegisterTranslator("openshift", "4.15.0", openshift4_15.ToConfigBytes)
-	RegisterTranslator("openshift", "4.16.0", openshift4_16.ToConfigBytes)
-	RegisterTranslator("openshift", "4.17.0", openshift4_17.ToConfigBytes)
-	RegisterTranslator("openshift", "4.18.0", openshift4_18.ToConfigBytes)
-	RegisterTranslator("openshift", "4.19.0", openshift4_19.ToConfigBytes)
-	RegisterTranslator("openshift", "4.20.0", openshift4_20.ToConfigBytes)
-	RegisterTranslator("openshift", "4.21.0", openshift4_21.ToConfigBytes)
-	RegisterTranslator("openshift", "4.22.0", openshift4_22.ToConfigBytes)
-	RegisterTranslator("openshift", "4.23.0-experimental", openshift4_23_exp.ToConfigBytes)
-	RegisterTranslator("r4e", "1.0.0", r4e1_0.ToIgn3_3Bytes)
-	RegisterTranslator("r4e", "1.1.0", r4e1_1.ToIgn3_4Bytes)
-	RegisterTranslator("r4e", "1.2.0-experimental", r4e1_2_exp.ToIgn3_7Bytes)
-	RegisterTranslator("fiot", "1.0.0", fiot1_0.ToIgn3_4Bytes)
-	RegisterTranslator("fiot", "1.1.0-experimental", fiot1_1_exp.ToIgn3_7Bytes)
+	registerSpec("fcos", "1.0.0", fcos1_0.ToIgn3_0Bytes, fcos1_0.Config{})
+	registerSpec("fcos", "1.1.0", fcos1_1.ToIgn3_1Bytes, fcos1_1.Config{})
+	registerSpec("fcos", "1.2.0", fcos1_2.ToIgn3_2Bytes, fcos1_2.Config{})
+	registerSpec("fcos", "1.3.0", fcos1_3.ToIgn3_2Bytes, fcos1_3.Config{})
+	registerSpec("fcos", "1.4.0", fcos1_4.ToIgn3_3Bytes, fcos1_4.Config{})
+	registerSpec("fcos", "1.5.0", fcos1_5.ToIgn3_4Bytes, fcos1_5.Config{})
+	registerSpec("fcos", "1.6.0", fcos1_6.ToIgn3_5Bytes, fcos1_6.Config{})
+	registerSpec("fcos", "1.7.0", fcos1_7.ToIgn3_6Bytes, fcos1_7.Config{})
+	registerSpec("fcos", "1.8.0-experimental", fcos1_8_exp.ToIgn3_7Bytes, fcos1_8_exp.Config{})
+	registerSpec("flatcar", "1.0.0", flatcar1_0.ToIgn3_3Bytes, flatcar1_0.Config{})
+	registerSpec("flatcar", "1.1.0", flatcar1_1.ToIgn3_4Bytes, flatcar1_1.Config{})
+	registerSpec("flatcar", "1.2.0-experimental", flatcar1_2_exp.ToIgn3_7Bytes, flatcar1_2_exp.Config{})
+	registerSpec("openshift", "4.8.0", openshift4_8.ToConfigBytes, openshift4_8.Config{})
+	registerSpec("openshift", "4.9.0", openshift4_9.ToConfigBytes, openshift4_9.Config{})
+	registerSpec("openshift", "4.10.0", openshift4_10.ToConfigBytes, openshift4_10.Config{})
+	registerSpec("openshift", "4.11.0", openshift4_11.ToConfigBytes, openshift4_11.Config{})
+	registerSpec("openshift", "4.12.0", openshift4_12.ToConfigBytes, openshift4_12.Config{})
+	registerSpec("openshift", "4.13.0", openshift4_13.ToConfigBytes, openshift4_13.Config{})
+	registerSpec("openshift", "4.14.0", openshift4_14.ToConfigBytes, openshift4_14.Config{})
+	registerSpec("openshift", "4.15.0", openshift4_15.ToConfigBytes, openshift4_15.Config{})
+	registerSpec("openshift", "4.16.0", openshift4_16.ToConfigBytes, openshift4_16.Config{})
+	registerSpec("openshift", "4.17.0", openshift4_17.ToConfigBytes, openshift4_17.Config{})
+	registerSpec("openshift", "4.18.0", openshift4_18.ToConfigBytes, openshift4_18.Config{})
+	registerSpec("openshift", "4.19.0", openshift4_19.ToConfigBytes, openshift4_19.Config{})
+	registerSpec("openshift", "4.20.0", openshift4_20.ToConfigBytes, openshift4_20.Config{})
+	registerSpec("openshift", "4.21.0", openshift4_21.ToConfigBytes, openshift4_21.Config{})
+	registerSpec("openshift", "4.22.0", openshift4_22.ToConfigBytes, openshift4_22.Config{})
+	registerSpec("openshift", "4.23.0-experimental", openshift4_23_exp.ToConfigBytes, openshift4_23_exp.Config{})
+	registerSpec("r4e", "1.0.0", r4e1_0.ToIgn3_3Bytes, r4e1_0.Config{})
+	registerSpec("r4e", "1.1.0", r4e1_1.ToIgn3_4Bytes, r4e1_1.Config{})
+	registerSpec("r4e", "1.2.0-experimental", r4e1_2_exp.ToIgn3_7Bytes, r4e1_2_exp.Config{})
+	registerSpec("fiot", "1.0.0", fiot1_0.ToIgn3_4Bytes, fiot1_0.Config{})
+	registerSpec("fiot", "1.1.0-experimental", fiot1_1_exp.ToIgn3_7Bytes, fiot1_1_exp.Config{})
 	RegisterTranslator("rhcos", "0.1.0", unsupportedRhcosVariant)
 }
 
+// registerSpec registers the translator for a built-in spec version, along
+// with the spec's config struct for use by NewSpecConfig.
+func registerSpec(variant, version string, trans translator, cfg cutil.Config) {
+	RegisterTranslator(variant, version, trans)
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	specConfigs[fmt.Sprintf("%s+%s", variant, version)] = reflect.TypeOf(cfg)
+}
+
 // RegisterTranslator registers a translator for the specified variant and
 // version to be available for use by TranslateBytes.  This is only needed
 // by users implementing their own translators outside the Butane package.
+// It's safe to call concurrently with translation, but panics if the
+// variant and version are already registered; use AddTranslator to get an
+// error instead.
 func RegisterTranslator(variant, version string, trans translator) {
+	if err := AddTranslator(variant, version, trans); err != nil {
+		panic(err)
+	}
+}
+
+// AddTranslator is like RegisterTranslator, but returns
+// ErrVariantRegistered if the variant and version are already registered.
+func AddTranslator(variant, version string, trans translator) error {
 	key := fmt.Sprintf("%s+%s", variant, version)
+	registryLock.Lock()
+	defer registryLock.Unlock()
 	if _, ok := registry[key]; ok {
-		panic("tried to reregister existing translator")
+		return common.ErrVariantRegistered{
+			Variant: variant,
+			Version: version,
+		}
 	}
 	registry[key] = trans
+	return nil
+}
+
+// RegisteredVersions returns the variants and spec versions with
+// registered translators, sorted by variant and then version.  The rhcos
+// variant is only registered to report its removal, so it isn't included.
+func RegisteredVersions() []RegisteredVersion {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	var versions []RegisteredVersion
+	for key := range registry {
+		variant, ver, _ := strings.Cut(key, "+")
+		parsed, err := semver.NewVersion(ver)
+		if err != nil || variant == "rhcos" {
+			continue
+		}
+		versions = append(versions, RegisteredVersion{
+			Variant:      variant,
+			Version:      *parsed,
+			Experimental: parsed.PreRelease == "experimental",
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].Variant != versions[j].Variant {
+			return versions[i].Variant < versions[j].Variant
+		}
+		return versions[i].Version.LessThan(versions[j].Version)
+	})
+	return versions
+}
+
+// Variants returns the names of the variants with registered translators,
+// in sorted order.  The rhcos variant is only registered to report its
+// removal, so it isn't included.
+func Variants() []string {
+	var variants []string
+	for _, v := range RegisteredVersions() {
+		if len(variants) == 0 || variants[len(variants)-1] != v.Variant {
+			variants = append(variants, v.Variant)
+		}
+	}
+	return variants
+}
+
+// Versions returns the spec versions with registered translators for the
+// specified variant, in ascending order.
+func Versions(variant string) []semver.Version {
+	var versions []semver.Version
+	for _, v := range RegisteredVersions() {
+		if v.Variant == variant {
+			versions = append(versions, v.Version)
+		}
+	}
+	return versions
+}
+
+// NewSpecConfig returns a pointer to a new, empty config struct for the
+// specified built-in variant and spec version, such as *v1_6.Config for
+// fcos 1.6.0.
+func NewSpecConfig(variant string, version semver.Version) (cutil.Config, error) {
+	registryLock.RLock()
+	t, ok := specConfigs[fmt.Sprintf("%s+%s", variant, version.String())]
+	registryLock.RUnlock()
+	if !ok {
+		return nil, unknownVersion(variant, version)
+	}
+	return reflect.New(t).Interface().(cutil.Config), nil
+}
+
+// SpecVersion returns the variant and spec version of a built-in config
+// struct such as v1_6.Config, or a pointer to one.  ok is false if the
+// struct isn't a registered spec.
+func SpecVersion(cfg cutil.Config) (variant string, version semver.Version, ok bool) {
+	t := reflect.TypeOf(cfg)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	for key, specType := range specConfigs {
+		if specType != t {
+			continue
+		}
+		v, ver, _ := strings.Cut(key, "+")
+		return v, *semver.New(ver), true
+	}
+	return "", semver.Version{}, false
 }
 
 func getTranslator(variant string, version semver.Version) (translator, error) {
+	registryLock.RLock()
 	t, ok := registry[fmt.Sprintf("%s+%s", variant, version.String())]
+	registryLock.RUnlock()
 	if !ok {
-		return nil, common.ErrUnknownVersion{
-			Variant: variant,
-			Version: version,
-		}
+		return nil, unknownVersion(variant, version)
 	}
 	return t, nil
 }
 
+// unknownVersion returns an ErrUnknownVersion suggesting the closest
+// stable versions of the variant below and above version.  Experimental
+// versions are only suggested for an experimental version.
+func unknownVersion(variant string, version semver.Version) error {
+	var below, above *semver.Version
+	for _, v := range Versions(variant) {
+		if v.PreRelease != "" && v.PreRelease != version.PreRelease {
+			continue
+		}
+		if v.LessThan(version) {
+			below = &v
+		} else if above == nil {
+			above = &v
+		}
+	}
+	err := common.ErrUnknownVersion{
+		Variant: variant,
+		Version: version,
+	}
+	for _, v := range []*semver.Version{below, above} {
+		if v != nil {
+			err.Suggestions = append(err.Suggestions, *v)
+		}
+	}
+	return err
+}
+
 // translators take a raw config and translate it to a raw Ignition config. The report returned should include any
 // errors, warnings, etc. and may or may not be fatal. If report is fatal, or other errors are encountered while translating
 // translators should return an error.
@@ -133,6 +288,7 @@ type translator func([]byte, common.TranslateBytesOptions) ([]byte, report.Repor
 
 // TranslateBytes wraps all of the individual TranslateBytes functions in a switch that determines the correct one to call.
 // TranslateBytes returns an error if the report had fatal errors or if other errors occured during translation.
+// If options.Wrapper is set, the output is embedded in a Kubernetes Secret or ConfigMap.
 func TranslateBytes(input []byte, options common.TranslateBytesOptions) ([]byte, report.Report, error) {
 	// first determine version; this will ignore most fields
 	ver := commonFields{}
@@ -157,7 +313,22 @@ func TranslateBytes(input []byte, options common.TranslateBytesOptions) ([]byte,
 		return nil, report.Report{}, err
 	}
 
-	return translator(input, options)
+	options.TranslateOptions = butanelocal.WithTranslator(options.TranslateOptions, TranslateBytes)
+	output, r, err := translator(input, options)
+	if err != nil || options.Wrapper == nil {
+		return output, r, err
+	}
+	output, err = cutil.Wrap(output, *options.Wrapper)
+	return output, r, err
+}
+
+// Flatten translates a Butane config to an Ignition config and merges in
+// its local and inline child configs the same way Ignition would.  It
+// returns the effective config and the source location of each of its
+// fields.  See util.Flatten for details.
+func Flatten(input []byte, options common.TranslateBytesOptions) ([]byte, common.SourceMap, report.Report, error) {
+	options.TranslateOptions = butanelocal.WithTranslator(options.TranslateOptions, TranslateBytes)
+	return cutil.Flatten(input, options)
 }
 
 func unsupportedRhcosVariant(input []byte, options common.TranslateBytesOptions) ([]byte, report.Report, error) {
@@ -0,0 +1,462 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/coreos/butane/config/common"
+	fcos1_7 "github.com/coreos/butane/config/fcos/v1_7"
+	cutil "github.com/coreos/butane/config/util"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersions(t *testing.T) {
+	var versions []string
+	for _, v := range Versions("flatcar") {
+		versions = append(versions, v.String())
+	}
+	assert.Equal(t, []string{"1.0.0", "1.1.0", "1.2.0-experimental"}, versions, "bad flatcar versions")
+	assert.Empty(t, Versions("nonexistent"), "versions for nonexistent variant")
+	assert.Equal(t, []string{"fcos", "fiot", "flatcar", "openshift", "r4e"}, Variants(), "bad variants")
+
+	variant, version, ok := SpecVersion(&fcos1_7.Config{})
+	assert.True(t, ok, "spec not found")
+	assert.Equal(t, "fcos", variant, "bad spec variant")
+	assert.Equal(t, "1.7.0", version.String(), "bad spec version")
+}
+
+func TestRegisteredVersions(t *testing.T) {
+	var fcos []string
+	for _, v := range RegisteredVersions() {
+		if v.Variant == "fcos" && (v.Experimental || v.Version.Minor >= 7) {
+			fcos = append(fcos, fmt.Sprintf("%s %v", v.Version, v.Experimental))
+		}
+	}
+	assert.Equal(t, []string{"1.7.0 false", "1.8.0-experimental true"}, fcos, "bad fcos versions")
+
+	// register concurrently with lookups
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			RegisterTranslator("test-concurrent", fmt.Sprintf("1.%d.0", i), unsupportedRhcosVariant)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, _, _ = TranslateBytes([]byte("variant: test-concurrent\nversion: 1.0.0\n"), common.TranslateBytesOptions{})
+			Versions("test-concurrent")
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, Versions("test-concurrent"), 10, "bad concurrent registration")
+
+	// duplicates
+	assert.Equal(t, common.ErrVariantRegistered{Variant: "test-concurrent", Version: "1.0.0"}, AddTranslator("test-concurrent", "1.0.0", unsupportedRhcosVariant), "duplicate registration")
+	assert.PanicsWithError(t, common.ErrVariantRegistered{Variant: "fcos", Version: "1.7.0"}.Error(), func() {
+		RegisterTranslator("fcos", "1.7.0", unsupportedRhcosVariant)
+	}, "duplicate registration")
+}
+
+func TestUnknownVersion(t *testing.T) {
+	tests := []struct {
+		variant     string
+		version     string
+		suggestions []string
+	}{
+		{"fcos", "1.9.0", []string{"1.7.0"}},
+		{"fcos", "1.7.1", []string{"1.7.0"}},
+		{"fcos", "1.7.0-experimental", []string{"1.6.0", "1.7.0"}},
+		{"fcos", "1.9.0-experimental", []string{"1.8.0-experimental"}},
+		{"openshift", "4.7.0", []string{"4.8.0"}},
+		{"openshift", "4.12.1", []string{"4.12.0", "4.13.0"}},
+		{"nonexistent", "1.0.0", nil},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("unknown version %d", i), func(t *testing.T) {
+			_, _, err := TranslateBytes([]byte(fmt.Sprintf("variant: %s\nversion: %s\n", test.variant, test.version)), common.TranslateBytesOptions{})
+			var expected []semver.Version
+			for _, v := range test.suggestions {
+				expected = append(expected, *semver.New(v))
+			}
+			assert.Equal(t, common.ErrUnknownVersion{
+				Variant:     test.variant,
+				Version:     *semver.New(test.version),
+				Suggestions: expected,
+			}, err, "bad error")
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	filesDir := t.TempDir()
+	files := map[string]string{
+		"child.bu": `variant: fcos
+version: 1.8.0-experimental
+storage:
+  files:
+    - path: /a
+      mode: 0644
+`,
+		"child.ign": `{
+  "ignition": {"version": "3.7.0-experimental"},
+  "storage": {
+    "files": [
+      {"path": "/a", "mode": 420, "overwrite": true},
+      {"path": "/c"}
+    ]
+  }
+}`,
+		"old.ign": `{"ignition": {"version": "3.4.0"}, "storage": {"files": [{"path": "/old"}]}}`,
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(filesDir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		in        string
+		out       string // without the ignition section
+		sourceMap map[string]common.SourceLocation
+		report    string
+	}{
+		// merge chain
+		{
+			in: `variant: fcos
+version: 1.8.0-experimental
+ignition:
+  config:
+    merge:
+      - butane_local: child.bu
+      - local: child.ign
+      - inline: '{"ignition": {"version": "3.7.0-experimental"}, "passwd": {"users": [{"name": "core"}]}}'
+storage:
+  files:
+    - path: /a
+      mode: 0600
+    - path: /b
+`,
+			out: `{"passwd":{"users":[{"name":"core"}]},"storage":{"files":[{"mode":420,"overwrite":true,"path":"/a"},{"path":"/b"},{"path":"/c"}]}}`,
+			sourceMap: map[string]common.SourceLocation{
+				"$.storage.files.0.mode": {
+					File:      "child.ign",
+					Path:      "$.storage.files.0.mode",
+					Line:      5,
+					Column:    30,
+					EndLine:   5,
+					EndColumn: 32,
+				},
+				"$.storage.files.1.path": {
+					Path:      "$.storage.files.1.path",
+					Line:      13,
+					Column:    13,
+					EndLine:   13,
+					EndColumn: 14,
+				},
+				"$.storage.files.2.path": {
+					File:      "child.ign",
+					Path:      "$.storage.files.1.path",
+					Line:      6,
+					Column:    16,
+					EndLine:   6,
+					EndColumn: 19,
+				},
+				"$.passwd.users.0.name": {
+					Path:      "$.ignition.config.merge.2.inline",
+					Line:      8,
+					Column:    17,
+					EndLine:   8,
+					EndColumn: 106,
+				},
+			},
+		},
+		// replace, with butane_local source locations
+		{
+			in: `variant: fcos
+version: 1.8.0-experimental
+ignition:
+  config:
+    replace:
+      butane_local: child.bu
+storage:
+  files:
+    - path: /b
+`,
+			out: `{"storage":{"files":[{"mode":420,"path":"/a"}]}}`,
+			sourceMap: map[string]common.SourceLocation{
+				"$.storage.files.0.mode": {
+					File:      "child.bu",
+					Path:      "$.storage.files.0.mode",
+					Line:      6,
+					Column:    13,
+					EndLine:   6,
+					EndColumn: 16,
+				},
+			},
+		},
+		// older child configs are translated to the parent's version
+		{
+			in: `variant: fcos
+version: 1.8.0-experimental
+ignition:
+  config:
+    merge:
+      - local: old.ign
+`,
+			out: `{"storage":{"files":[{"path":"/old"}]}}`,
+			sourceMap: map[string]common.SourceLocation{
+				"$.storage.files.0.path": {
+					File:      "old.ign",
+					Path:      "$.storage.files.0.path",
+					Line:      1,
+					Column:    67,
+					EndLine:   1,
+					EndColumn: 72,
+				},
+			},
+		},
+		// remote child configs are skipped; newer child configs are fatal
+		{
+			in: `variant: fcos
+version: 1.5.0
+ignition:
+  config:
+    merge:
+      - source: https://example.com/child.ign
+      - local: child.ign
+`,
+			report: "warning at $.ignition.config.merge.0.source, line 6 col 17: " + common.ErrFlattenRemote.Error() + "\n" +
+				"error at $.ignition.config.merge.1.local, line 7 col 16: " + common.ErrFlattenVersion{Version: "3.7.0-experimental", Expected: "3.4.0"}.Error() + "\n",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("flatten %d", i), func(t *testing.T) {
+			out, sourceMap, r, err := Flatten([]byte(test.in), common.TranslateBytesOptions{
+				TranslateOptions: common.TranslateOptions{
+					FilesDir: filesDir,
+				},
+			})
+			assert.Equal(t, test.report, r.String(), "report mismatch")
+			if r.IsFatal() {
+				assert.Equal(t, common.ErrInvalidSourceConfig, err, "bad error")
+				return
+			}
+			assert.NoError(t, err, "translation failed")
+			var cfg map[string]interface{}
+			if err := json.Unmarshal(out, &cfg); err != nil {
+				t.Fatal(err)
+			}
+			delete(cfg, "ignition")
+			actual, err := json.Marshal(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.out, string(actual), "bad output")
+			for key, loc := range test.sourceMap {
+				assert.Equal(t, loc, sourceMap[key], "bad source location for %s", key)
+			}
+		})
+	}
+}
+
+func TestSourceMap(t *testing.T) {
+	tests := []struct {
+		in string
+		// JSON Pointer -> source location
+		pointers map[string]common.SourceLocation
+		// path string, as given to "butane blame" -> JSON Pointer
+		paths map[string]string
+	}{
+		// boot_device.mirror and quadlets are desugared from one field
+		// to many
+		{
+			in: `variant: fcos
+version: 1.8.0-experimental
+boot_device:
+  layout: x86_64
+  mirror:
+    devices:
+      - /dev/sda
+      - /dev/sdb
+systemd:
+  quadlets:
+    - name: web.container
+      contents: |
+        [Container]
+        Image=quay.io/example/web
+`,
+			pointers: map[string]common.SourceLocation{
+				"/storage/disks/1":                 {Path: "$.boot_device.mirror.devices.1", Line: 8, Column: 9, EndLine: 8, EndColumn: 16},
+				"/storage/disks/1/partitions/2":    {Path: "$.boot_device.mirror.devices.1", Line: 8, Column: 9, EndLine: 8, EndColumn: 16},
+				"/storage/raid/0/devices/0":        {Path: "$.boot_device.mirror", Line: 6, Column: 5, EndLine: 8, EndColumn: 16},
+				"/storage/files/0":                 {Path: "$.systemd.quadlets.0", Line: 11, Column: 7, EndLine: 14, EndColumn: 33},
+				"/storage/files/0/path":            {Path: "$.systemd.quadlets.0.name", Line: 11, Column: 13, EndLine: 11, EndColumn: 25},
+				"/storage/files/0/contents/source": {Path: "$.systemd.quadlets.0.contents", Line: 12, Column: 17, EndLine: 14, EndColumn: 33},
+			},
+			paths: map[string]string{
+				"$":                              "",
+				"$.storage.disks.1.partitions.2": "/storage/disks/1/partitions/2",
+				"$.storage.files.0.path":         "/storage/files/0/path",
+			},
+		},
+		// MachineConfig labels contain dots
+		{
+			in: `variant: openshift
+version: 4.22.0
+metadata:
+  name: a
+  labels:
+    machineconfiguration.openshift.io/role: worker
+    example.com/team: core
+`,
+			pointers: map[string]common.SourceLocation{
+				"/metadata/labels/machineconfiguration.openshift.io~1role": {Path: "$.metadata.labels.machineconfiguration.openshift.io/role", Line: 6, Column: 45, EndLine: 6, EndColumn: 50},
+				"/metadata/labels/example.com~1team":                       {Path: "$.metadata.labels.example.com/team", Line: 7, Column: 23, EndLine: 7, EndColumn: 26},
+			},
+			paths: map[string]string{
+				"$.metadata.labels.machineconfiguration.openshift.io/role": "/metadata/labels/machineconfiguration.openshift.io~1role",
+				"$.metadata.labels.example.com/team":                       "/metadata/labels/example.com~1team",
+			},
+		},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("source map %d", i), func(t *testing.T) {
+			sourceMap := common.SourceMap{}
+			out, r, err := TranslateBytes([]byte(test.in), common.TranslateBytesOptions{
+				TranslateOptions: common.TranslateOptions{
+					SourceMap: sourceMap,
+				},
+			})
+			assert.NoError(t, err, "translation failed")
+			assert.Empty(t, r.Entries, "non-empty report")
+			pointers, err := cutil.PointerSourceMap(out, sourceMap)
+			if !assert.NoError(t, err, "building pointer map") {
+				return
+			}
+			for pointer, loc := range test.pointers {
+				assert.Equal(t, loc, pointers[pointer], "bad source location for %s", pointer)
+			}
+			for p, pointer := range test.paths {
+				actual, ok, err := cutil.PathPointer(out, p)
+				assert.NoError(t, err, "resolving %s", p)
+				assert.True(t, ok, "%s not found", p)
+				assert.Equal(t, pointer, actual, "bad pointer for %s", p)
+			}
+		})
+	}
+}
+
+func TestIgnitionVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		version string
+		raw     bool
+		out     string
+		report  string
+	}{
+		// supported fields
+		{
+			in: `variant: fcos
+version: 1.8.0-experimental
+storage:
+  files:
+    - path: /a
+`,
+			version: "3.4.0",
+			out:     `{"ignition":{"version":"3.4.0"},"storage":{"files":[{"path":"/a"}]}}`,
+		},
+		// newer fields block the conversion
+		{
+			in: `variant: fcos
+version: 1.8.0-experimental
+storage:
+  luks:
+    - name: data
+      device: /dev/vdb
+      discard: true
+`,
+			version: "3.3.0",
+			report:  "error at $.storage.luks.0.discard, line 7 col 16: " + common.ErrIgnitionVersionField{Version: "3.3.0"}.Error() + "\n",
+		},
+		// newer version
+		{
+			in: `variant: fcos
+version: 1.5.0
+`,
+			version: "3.5.0",
+			report:  "error at $.version, line 2 col 10: " + common.ErrIgnitionVersionNewer{Version: "3.5.0", Current: "3.4.0"}.Error() + "\n",
+		},
+		// unknown version
+		{
+			in: `variant: fcos
+version: 1.5.0
+`,
+			version: "3.2.1",
+			report:  "error at $.version, line 2 col 10: " + common.ErrUnknownIgnitionSpec{Version: "3.2.1"}.Error() + "\n",
+		},
+		// MachineConfig output
+		{
+			in: `variant: openshift
+version: 4.22.0
+metadata:
+  name: a
+  labels:
+    machineconfiguration.openshift.io/role: worker
+`,
+			version: "3.4.0",
+			report:  "error at $.version, line 2 col 10: " + common.ErrIgnitionVersionOutput.Error() + "\n",
+		},
+		{
+			in: `variant: openshift
+version: 4.22.0
+metadata:
+  name: a
+  labels:
+    machineconfiguration.openshift.io/role: worker
+`,
+			version: "3.4.0",
+			raw:     true,
+			out:     `{"ignition":{"version":"3.4.0"}}`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("ignition version %d", i), func(t *testing.T) {
+			out, r, err := TranslateBytes([]byte(test.in), common.TranslateBytesOptions{
+				TranslateOptions: common.TranslateOptions{
+					IgnitionVersion: test.version,
+				},
+				Raw: test.raw,
+			})
+			assert.Equal(t, test.report, r.String(), "report mismatch")
+			if test.report != "" {
+				assert.Equal(t, common.ErrInvalidSourceConfig, err, "bad error")
+				return
+			}
+			assert.NoError(t, err, "translation failed")
+			assert.Equal(t, test.out, string(out), "bad output")
+		})
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	baseutil "github.com/coreos/butane/base/util"
+	"github.com/coreos/butane/config/common"
+	cutil "github.com/coreos/butane/config/util"
+	"github.com/coreos/butane/translate"
+
+	"github.com/coreos/go-semver/semver"
+	ignvalidate "github.com/coreos/ignition/v2/config/validate"
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"github.com/coreos/vcontext/tree"
+	"github.com/coreos/vcontext/validate"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ignitionMethodRe      = regexp.MustCompile(`^ToIgn\d+_\d+Unvalidated$`)
+	machineConfigMethodRe = regexp.MustCompile(`^ToMachineConfig\d+_\d+Unvalidated$`)
+
+	// extensions maps registry keys to their sections; guarded by
+	// registryLock
+	extensions = map[string][]Section{}
+)
+
+// Section is a custom top-level section of a Butane config, added to a
+// spec version with RegisterExtension.
+type Section struct {
+	// Name is the top-level key of the section.
+	Name string
+	// New returns a pointer to a new, empty struct to unmarshal the
+	// section into.  Unused keys are reported and Validate methods are
+	// run as for built-in sections.
+	New func() interface{}
+	// Desugar translates the section to a fragment of the spec's
+	// Ignition types.Config, and returns translations from yaml paths in
+	// the Butane config, such as $.company.motd, to json paths in the
+	// fragment.  Report paths can use either.  The fragment is merged
+	// under the rest of the config, so fields the config sets directly
+	// take precedence.  A fragment of any other type is reported as an
+	// error in the section.
+	Desugar func(section interface{}, options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report)
+}
+
+// RegisterExtension wraps the translator of a built-in variant and spec
+// version so configs can also contain the specified sections.  It can be
+// called more than once for a spec version, but section names must be
+// unique and can't shadow fields of the spec.  Specs with MachineConfig
+// output can't be extended.
+func RegisterExtension(variant, version string, sections ...Section) error {
+	ver, err := semver.NewVersion(version)
+	if err != nil {
+		return common.ErrInvalidVersion
+	}
+	spec, err := NewSpecConfig(variant, *ver)
+	if err != nil {
+		return err
+	}
+	specType := reflect.TypeOf(spec)
+	method, ok := findMethod(specType, ignitionMethodRe)
+	if _, isMachineConfig := findMethod(specType, machineConfigMethodRe); !ok || isMachineConfig {
+		return common.ErrExtensionOutput{
+			Variant: variant,
+			Version: version,
+		}
+	}
+
+	key := fmt.Sprintf("%s+%s", variant, ver)
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	names := map[string]struct{}{}
+	for _, field := range validate.GetFields(reflect.ValueOf(spec).Elem()) {
+		names[validate.FieldName(field, "yaml")] = struct{}{}
+	}
+	for _, section := range extensions[key] {
+		names[section.Name] = struct{}{}
+	}
+	for _, section := range sections {
+		if _, ok := names[section.Name]; ok || section.Name == "" {
+			return common.ErrSectionName{
+				Name: section.Name,
+			}
+		}
+		names[section.Name] = struct{}{}
+	}
+	extensions[key] = append(extensions[key], sections...)
+	registry[key] = func(input []byte, options common.TranslateBytesOptions) ([]byte, report.Report, error) {
+		registryLock.RLock()
+		sections := extensions[key]
+		registryLock.RUnlock()
+		return translateExtended(input, specType.Elem(), method.Name, sections, options)
+	}
+	return nil
+}
+
+// translateExtended translates input to an Ignition config using the named
+// unvalidated translation method of specType, merging in the desugared
+// sections.
+func translateExtended(input []byte, specType reflect.Type, methodName string, sections []Section, options common.TranslateBytesOptions) ([]byte, report.Report, error) {
+	spec := reflect.New(specType).Interface().(cutil.Config)
+	contextTree, parseReport, err := cutil.Parse(input, spec)
+	if err != nil {
+		return nil, parseReport, err
+	}
+
+	// The spec doesn't know about the sections, so drop its unused key
+	// warnings for them and check the section structs instead.
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(input, &raw); err != nil {
+		return nil, parseReport, common.ErrUnmarshal{
+			Detail: err.Error(),
+		}
+	}
+	var r report.Report
+	for _, entry := range parseReport.Entries {
+		if isSection(sections, entry.Context) {
+			continue
+		}
+		r.Entries = append(r.Entries, entry)
+	}
+	values := make([]interface{}, len(sections))
+	for i, section := range sections {
+		node, ok := raw[section.Name]
+		if !ok {
+			continue
+		}
+		values[i] = section.New()
+		if err := node.Decode(values[i]); err != nil {
+			return nil, r, common.ErrUnmarshal{
+				Detail: err.Error(),
+			}
+		}
+		prefix := path.New("yaml", section.Name)
+		unusedKeyCheck := func(v reflect.Value, c path.ContextPath) report.Report {
+			return ignvalidate.ValidateUnusedKeys(v, prefixPath(prefix, c), contextTree)
+		}
+		unusedReport := validate.ValidateCustom(values[i], "yaml", unusedKeyCheck)
+		unusedReport.Correlate(contextTree)
+		r.Merge(unusedReport)
+	}
+
+	translateMethod := boundMethod(spec, methodName)
+	translateFunc := func(options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report) {
+		var r report.Report
+		for i, section := range sections {
+			if values[i] != nil {
+				r.Merge(prefixReport(path.New("yaml", section.Name), validate.Validate(values[i], "yaml")))
+			}
+		}
+		final, ts, translateReport := translateMethod(options)
+		r.Merge(cutil.TranslateReportPaths(translateReport, ts))
+		if r.IsFatal() {
+			return final, ts, r
+		}
+		for i, section := range sections {
+			if values[i] == nil {
+				continue
+			}
+			fragment, fragmentTranslations, desugarReport := section.Desugar(values[i], options)
+			r.Merge(cutil.TranslateReportPaths(desugarReport, fragmentTranslations))
+			if reflect.TypeOf(fragment) != reflect.TypeOf(final) {
+				// a bug in the extension, not the config
+				r.AddOnError(path.New("yaml", section.Name), common.ErrSectionOutput{
+					Name:     section.Name,
+					Type:     fmt.Sprintf("%T", fragment),
+					Expected: fmt.Sprintf("%T", final),
+				})
+				continue
+			}
+			merged, mergedTranslations := baseutil.MergeTranslatedConfigs(fragment, fragmentTranslations, final, ts)
+			final, ts = merged, mergedTranslations
+		}
+		return final, ts, r
+	}
+	return translateParsed(spec, translateFunc, contextTree, r, options)
+}
+
+// boundMethod returns the named unvalidated translation method of spec.
+func boundMethod(spec cutil.Config, methodName string) cutil.TranslateFunc {
+	method := reflect.ValueOf(spec).MethodByName(methodName)
+	return func(options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report) {
+		ret := method.Call([]reflect.Value{reflect.ValueOf(options)})
+		return ret[0].Interface(), ret[1].Interface().(translate.TranslationSet), ret[2].Interface().(report.Report)
+	}
+}
+
+// translateParsed translates a parsed config with translateFunc and
+// marshals the result, like util.TranslateBytes.  r is the parse report.
+func translateParsed(cfg cutil.Config, translateFunc cutil.TranslateFunc, contextTree tree.Node, r report.Report, options common.TranslateBytesOptions) ([]byte, report.Report, error) {
+	final, _, translateReport, err := cutil.TranslateWithFunc(cfg, translateFunc, options.TranslateOptions)
+	translateReport.Correlate(contextTree)
+	r.Merge(translateReport)
+	if err != nil {
+		return nil, r, err
+	}
+	if options.SourceMap != nil {
+		cutil.LocateSources(options.SourceMap, contextTree)
+	}
+	output, err := cutil.MarshalOutput(final, options)
+	return output, r, err
+}
+
+// findMethod returns the method of t matching re.
+func findMethod(t reflect.Type, re *regexp.Regexp) (reflect.Method, bool) {
+	for i := 0; i < t.NumMethod(); i++ {
+		if method := t.Method(i); re.MatchString(method.Name) {
+			return method, true
+		}
+	}
+	return reflect.Method{}, false
+}
+
+// isSection returns true if c is the top-level key of one of sections.
+func isSection(sections []Section, c path.ContextPath) bool {
+	for _, section := range sections {
+		if len(c.Path) == 1 && fmt.Sprint(c.Path[0]) == section.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func prefixPath(prefix path.ContextPath, c path.ContextPath) path.ContextPath {
+	return path.New(prefix.Tag, append(append([]interface{}{}, prefix.Path...), c.Path...)...)
+}
+
+// prefixReport prepends prefix to the yaml paths in r.
+func prefixReport(prefix path.ContextPath, r report.Report) report.Report {
+	for i, entry := range r.Entries {
+		if entry.Context.Tag == "yaml" {
+			r.Entries[i].Context = prefixPath(prefix, entry.Context)
+		}
+	}
+	return r
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coreos/butane/config/common"
+	"github.com/coreos/butane/translate"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/coreos/ignition/v2/config/util"
+	"github.com/coreos/ignition/v2/config/v3_4/types"
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"github.com/stretchr/testify/assert"
+)
+
+var errEmptyMotd = errors.New("motd must not be empty")
+
+type companySection struct {
+	Motd  *string  `yaml:"motd"`
+	Units []string `yaml:"units"`
+}
+
+func (c companySection) Validate(cp path.ContextPath) (r report.Report) {
+	if c.Motd != nil && *c.Motd == "" {
+		r.AddOnError(cp.Append("motd"), errEmptyMotd)
+	}
+	return
+}
+
+func desugarCompany(section interface{}, options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report) {
+	c := section.(*companySection)
+	var ret types.Config
+	ts := translate.NewTranslationSet("yaml", "json")
+	if c.Motd != nil {
+		from := path.New("yaml", "company", "motd")
+		ret.Storage.Files = append(ret.Storage.Files, types.File{
+			Node: types.Node{
+				Path: "/etc/motd",
+			},
+			FileEmbedded1: types.FileEmbedded1{
+				Contents: types.Resource{
+					Source: util.StrToPtr("data:," + *c.Motd),
+				},
+			},
+		})
+		ts.AddFromCommonSource(from, path.New("json", "storage", "files", 0), ret.Storage.Files[0])
+	}
+	for i, unit := range c.Units {
+		ret.Systemd.Units = append(ret.Systemd.Units, types.Unit{
+			Name:    unit,
+			Enabled: util.BoolToPtr(true),
+		})
+		ts.AddFromCommonSource(path.New("yaml", "company", "units", i), path.New("json", "systemd", "units", i), ret.Systemd.Units[i])
+	}
+	return ret, ts, report.Report{}
+}
+
+func TestRegisterExtension(t *testing.T) {
+	err := RegisterExtension("r4e", "1.1.0", Section{
+		Name: "company",
+		New: func() interface{} {
+			return &companySection{}
+		},
+		Desugar: desugarCompany,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		in        string
+		out       string
+		report    string
+		sourceMap map[string]string
+	}{
+		// desugaring
+		{
+			in: `variant: r4e
+version: 1.1.0
+company:
+  motd: hello
+  units:
+    - a.service
+`,
+			out:       `{"ignition":{"version":"3.4.0"},"storage":{"files":[{"path":"/etc/motd","contents":{"source":"data:,hello"}}]},"systemd":{"units":[{"enabled":true,"name":"a.service"}]}}`,
+			sourceMap: map[string]string{"$": "$", "$.storage.files.0.contents.source": "$.company.motd", "$.systemd.units.0.name": "$.company.units.0"},
+		},
+		// explicit fields take precedence
+		{
+			in: `variant: r4e
+version: 1.1.0
+company:
+  motd: hello
+storage:
+  files:
+    - path: /etc/motd
+      contents:
+        inline: bye
+`,
+			out:       `{"ignition":{"version":"3.4.0"},"storage":{"files":[{"path":"/etc/motd","contents":{"compression":"","source":"data:,bye"}}]}}`,
+			sourceMap: map[string]string{"$.storage.files.0.contents.source": "$.storage.files.0.contents.inline"},
+		},
+		// unused keys and validation
+		{
+			in: `variant: r4e
+version: 1.1.0
+company:
+  motd: ""
+  color: blue
+`,
+			report: "warning at $.company.color, line 5 col 3: unused key color\n" +
+				"error at $.company.motd, line 4 col 9: " + errEmptyMotd.Error() + "\n",
+		},
+		// other unused keys are still reported
+		{
+			in: `variant: r4e
+version: 1.1.0
+companies: {}
+`,
+			out:    `{"ignition":{"version":"3.4.0"}}`,
+			report: "warning at $.companies, line 3 col 1: unused key companies\n",
+		},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("extension %d", i), func(t *testing.T) {
+			sourceMap := common.SourceMap{}
+			out, r, err := TranslateBytes([]byte(test.in), common.TranslateBytesOptions{
+				TranslateOptions: common.TranslateOptions{
+					SourceMap: sourceMap,
+				},
+			})
+			assert.Equal(t, test.report, r.String(), "bad report")
+			if r.IsFatal() {
+				assert.Equal(t, common.ErrInvalidSourceConfig, err, "bad error")
+				return
+			}
+			assert.NoError(t, err, "translation failed")
+			assert.Equal(t, test.out, string(out), "bad output")
+			for key, from := range test.sourceMap {
+				assert.Equal(t, from, sourceMap[key].Path, "bad source for %s", key)
+			}
+		})
+	}
+
+	// a section desugaring to the wrong type is an error, not a crash
+	err = RegisterExtension("r4e", "1.1.0", Section{
+		Name: "broken",
+		New: func() interface{} {
+			return &companySection{}
+		},
+		Desugar: func(section interface{}, options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report) {
+			return "", translate.NewTranslationSet("yaml", "json"), report.Report{}
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, r, err := TranslateBytes([]byte("variant: r4e\nversion: 1.1.0\nbroken: {}\n"), common.TranslateBytesOptions{})
+	assert.Equal(t, common.ErrInvalidSourceConfig, err, "bad error")
+	assert.Equal(t, "error at $.broken, line 3 col 9: "+common.ErrSectionOutput{Name: "broken", Type: "string", Expected: "types.Config"}.Error()+"\n", r.String(), "bad report")
+
+	// registration errors
+	assert.Equal(t, common.ErrSectionName{Name: "company"}, RegisterExtension("r4e", "1.1.0", Section{Name: "company"}), "duplicate section")
+	assert.Equal(t, common.ErrSectionName{Name: "storage"}, RegisterExtension("r4e", "1.1.0", Section{Name: "storage"}), "spec field")
+	assert.Equal(t, common.ErrSectionName{Name: ""}, RegisterExtension("r4e", "1.1.0", Section{}), "empty name")
+	assert.Equal(t, common.ErrExtensionOutput{Variant: "openshift", Version: "4.22.0"}, RegisterExtension("openshift", "4.22.0"), "MachineConfig spec")
+	assert.Equal(t, common.ErrUnknownVersion{Variant: "r4e", Version: *semver.New("1.9.0"), Suggestions: []semver.Version{*semver.New("1.1.0")}}, RegisterExtension("r4e", "1.9.0"), "unknown version")
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// DirCache is a common.DataURLCache that stores each entry as a file under
+// Dir, creating it if needed.  Entries are written to a temporary file and
+// renamed into place, so processes can share Dir concurrently.
+type DirCache struct {
+	Dir string
+}
+
+type dirCacheEntry struct {
+	Key         string `json:"key"`
+	URI         string `json:"uri"`
+	Compression string `json:"compression"`
+}
+
+func (c DirCache) Get(key string) (string, string, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return "", "", false
+	}
+	var entry dirCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return "", "", false
+	}
+	return entry.URI, entry.Compression, true
+}
+
+func (c DirCache) Put(key string, uri string, compression string) error {
+	data, err := json.Marshal(dirCacheEntry{
+		Key:         key,
+		URI:         uri,
+		Compression: compression,
+	})
+	if err != nil {
+		return err
+	}
+	entryPath := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(entryPath), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		// CreateTemp uses 0600; make entries readable by other users
+		// sharing Dir, as with any other file we'd create
+		err = f.Chmod(0644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), entryPath)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// path returns the file for key.  Keys are hashed since they needn't be
+// valid filenames.
+func (c DirCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.Dir, name[:2], name)
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	baseutil "github.com/coreos/butane/base/util"
+
+	"github.com/coreos/ignition/v2/config/util"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingCache struct {
+	DirCache
+	hits *atomic.Int32
+}
+
+func (c countingCache) Get(key string) (string, string, bool) {
+	uri, compression, ok := c.DirCache.Get(key)
+	if ok {
+		c.hits.Add(1)
+	}
+	return uri, compression, ok
+}
+
+func TestDirCache(t *testing.T) {
+	cache := countingCache{
+		DirCache: DirCache{Dir: t.TempDir() + "/cache"},
+		hits:     &atomic.Int32{},
+	}
+	_, _, ok := cache.Get("missing")
+	assert.False(t, ok, "missing entry found")
+
+	tests := []struct {
+		contents    []byte
+		compression *string
+	}{
+		{[]byte(strings.Repeat("compressible ", 100)), nil},
+		{[]byte("short"), nil},
+		{[]byte("already compressed"), util.StrToPtr("gzip")},
+	}
+	for _, test := range tests {
+		expectedURI, expectedCompression, err := baseutil.MakeDataURL(test.contents, test.compression, true)
+		assert.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			uri, compression, err := baseutil.MakeDataURLCached(test.contents, test.compression, true, cache)
+			assert.NoError(t, err)
+			assert.Equal(t, expectedURI, uri, "bad uri")
+			assert.Equal(t, expectedCompression, compression, "bad compression")
+		}
+	}
+	assert.Equal(t, int32(len(tests)), cache.hits.Load(), "bad cache hits")
+
+	// entries are world-readable
+	assert.NoError(t, cache.Put("mode", "data:,", ""))
+	info, err := os.Stat(cache.path("mode"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm(), "bad entry mode")
+	}
+
+	// compression setting is part of the key
+	uri, compression, err := baseutil.MakeDataURLCached(tests[0].contents, nil, false, cache)
+	assert.NoError(t, err)
+	assert.Equal(t, util.StrToPtr(""), compression, "bad uncompressed compression")
+	assert.False(t, strings.Contains(uri, "base64"), "compressed despite setting")
+
+	// concurrent use
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			uri, _, err := baseutil.MakeDataURLCached([]byte("concurrent"), nil, true, DirCache{Dir: cache.Dir})
+			assert.NoError(t, err)
+			assert.Equal(t, "data:,concurrent", uri, "bad concurrent uri")
+		}()
+	}
+	wg.Wait()
+}
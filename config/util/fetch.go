@@ -0,0 +1,216 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	baseutil "github.com/coreos/butane/base/util"
+	"github.com/coreos/butane/config/common"
+)
+
+var (
+	errBadCertificateAuthority = errors.New("couldn't parse certificate authority")
+)
+
+const (
+	// DefaultFetchTimeout bounds an entire HTTPFetcher request, including
+	// reading the response body.
+	DefaultFetchTimeout = 5 * time.Minute
+	// DefaultMaxFetchSize is the largest response body HTTPFetcher will
+	// read.  Fetched resources are embedded in the config, so anything
+	// larger is almost certainly a mistake.
+	DefaultMaxFetchSize = 64 * 1024 * 1024
+)
+
+// HTTPFetcher fetches http and https URLs.  Requests are canceled if
+// options.Context is canceled.
+type HTTPFetcher struct {
+	Timeout time.Duration // defaults to DefaultFetchTimeout
+	MaxSize int64         // defaults to DefaultMaxFetchSize
+}
+
+func (f HTTPFetcher) Fetch(rawURL string, options common.FetchOptions) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, common.ErrFetchScheme{Scheme: u.Scheme}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(options)
+	if len(options.CertificateAuthorities) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, ca := range options.CertificateAuthorities {
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, errBadCertificateAuthority
+			}
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	timeout := f.Timeout
+	if timeout == 0 {
+		timeout = DefaultFetchTimeout
+	}
+	client := http.Client{Transport: transport, Timeout: timeout}
+	defer client.CloseIdleConnections()
+
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range options.Headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, common.ErrFetchStatus{URL: rawURL, Status: resp.Status}
+	}
+	maxSize := f.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxFetchSize
+	}
+	// read one byte more than allowed so we can tell whether the body
+	// was truncated
+	contents, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(contents)) > maxSize {
+		return nil, common.ErrFetchTooLarge{URL: rawURL, MaxSize: maxSize}
+	}
+	return contents, nil
+}
+
+// proxyFunc returns a proxy selection function for http.Transport which
+// uses the proxy settings from the config, or from the environment if the
+// config doesn't specify any.
+func proxyFunc(options common.FetchOptions) func(*http.Request) (*url.URL, error) {
+	if options.HTTPProxy == "" && options.HTTPSProxy == "" {
+		return http.ProxyFromEnvironment
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		var proxy string
+		switch req.URL.Scheme {
+		case "http":
+			proxy = options.HTTPProxy
+		case "https":
+			proxy = options.HTTPSProxy
+		}
+		if proxy == "" || bypassProxy(req.URL.Hostname(), options.NoProxy) {
+			return nil, nil
+		}
+		return url.Parse(proxy)
+	}
+}
+
+// bypassProxy returns true if host matches an entry in noProxy.  Entries
+// can be "*", a domain name which also matches its subdomains, an IP
+// address, or a CIDR block.
+func bypassProxy(host string, noProxy []string) bool {
+	ip := net.ParseIP(host)
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		case ip != nil:
+			if _, cidr, err := net.ParseCIDR(entry); err == nil && cidr.Contains(ip) {
+				return true
+			}
+			if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+				return true
+			}
+		default:
+			domain := strings.TrimPrefix(entry, ".")
+			host := strings.ToLower(host)
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// URLMapping maps URLs beginning with Prefix to files in Dir.
+type URLMapping struct {
+	Prefix string
+	Dir    string
+}
+
+// URLMapFetcher fetches URLs matching one of its mappings from the local
+// filesystem, and all other URLs with Fallback.  If more than one mapping
+// matches, the longest prefix wins.
+type URLMapFetcher struct {
+	Mappings []URLMapping
+	Fallback common.Fetcher // defaults to HTTPFetcher
+}
+
+func (f URLMapFetcher) Fetch(rawURL string, options common.FetchOptions) ([]byte, error) {
+	var mapping *URLMapping
+	for i, m := range f.Mappings {
+		if strings.HasPrefix(rawURL, m.Prefix) && (mapping == nil || len(m.Prefix) > len(mapping.Prefix)) {
+			mapping = &f.Mappings[i]
+		}
+	}
+	if mapping == nil {
+		if f.Fallback == nil {
+			return HTTPFetcher{}.Fetch(rawURL, options)
+		}
+		return f.Fallback.Fetch(rawURL, options)
+	}
+
+	rel := strings.TrimPrefix(rawURL, mapping.Prefix)
+	if i := strings.IndexAny(rel, "?#"); i >= 0 {
+		rel = rel[:i]
+	}
+	rel, err := url.PathUnescape(rel)
+	if err != nil {
+		return nil, err
+	}
+	filePath := filepath.Join(mapping.Dir, filepath.FromSlash(rel))
+	if err := baseutil.EnsurePathWithinFilesDir(filePath, mapping.Dir); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filePath)
+}
@@ -55,6 +55,31 @@ func NewFiltersIgnoreZero(v any, filters FilterMap, ignoreZero []string) FieldFi
 	}
 }
 
+// Extend returns a copy of ff, which may be nil, with additional filters.
+// Unlike NewFilters, it returns an error for a filter that isn't a valid
+// path in v's type, since the filters may come from user input.
+func (ff *FieldFilters) Extend(v any, filters FilterMap) (FieldFilters, error) {
+	ret := FieldFilters{
+		filters:    FilterMap{},
+		ignoreZero: map[string]struct{}{},
+	}
+	if ff != nil {
+		for filter, err := range ff.filters {
+			ret.filters[filter] = err
+		}
+		for filter := range ff.ignoreZero {
+			ret.ignoreZero[filter] = struct{}{}
+		}
+	}
+	for filter, err := range filters {
+		if !isValidFilter(reflect.TypeOf(v), filter) {
+			return FieldFilters{}, fmt.Errorf("invalid filter path: %s", filter)
+		}
+		ret.filters[filter] = err
+	}
+	return ret, nil
+}
+
 func isValidFilter(typ reflect.Type, filter string) bool {
 	if filter == "" {
 		return true
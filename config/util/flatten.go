@@ -0,0 +1,362 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	baseutil "github.com/coreos/butane/base/util"
+	"github.com/coreos/butane/config/common"
+	"github.com/coreos/butane/internal/butanelocal"
+
+	"github.com/clarketm/json"
+	"github.com/coreos/go-semver/semver"
+	"github.com/coreos/ignition/v2/config/merge"
+	v3_0 "github.com/coreos/ignition/v2/config/v3_0/types"
+	v3_1 "github.com/coreos/ignition/v2/config/v3_1/types"
+	v3_2 "github.com/coreos/ignition/v2/config/v3_2/types"
+	v3_3 "github.com/coreos/ignition/v2/config/v3_3/types"
+	v3_4 "github.com/coreos/ignition/v2/config/v3_4/types"
+	v3_5 "github.com/coreos/ignition/v2/config/v3_5/types"
+	v3_6 "github.com/coreos/ignition/v2/config/v3_6/types"
+	v3_7_exp "github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	vjson "github.com/coreos/vcontext/json"
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"github.com/coreos/vcontext/tree"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errNoChildPath = errors.New("couldn't find child config path in source config")
+
+	ignitionConfigTypes = map[string]reflect.Type{
+		v3_0.MaxVersion.String():     reflect.TypeOf(v3_0.Config{}),
+		v3_1.MaxVersion.String():     reflect.TypeOf(v3_1.Config{}),
+		v3_2.MaxVersion.String():     reflect.TypeOf(v3_2.Config{}),
+		v3_3.MaxVersion.String():     reflect.TypeOf(v3_3.Config{}),
+		v3_4.MaxVersion.String():     reflect.TypeOf(v3_4.Config{}),
+		v3_5.MaxVersion.String():     reflect.TypeOf(v3_5.Config{}),
+		v3_6.MaxVersion.String():     reflect.TypeOf(v3_6.Config{}),
+		v3_7_exp.MaxVersion.String(): reflect.TypeOf(v3_7_exp.Config{}),
+	}
+)
+
+// flattener renders a merge chain into a single config.
+type flattener struct {
+	options    common.TranslateBytesOptions
+	version    string
+	configType reflect.Type
+	r          report.Report
+}
+
+// flattenLevel describes the origin of one config in the merge chain.
+type flattenLevel struct {
+	// Butane source of the config, or nil if it's an Ignition config
+	butane []byte
+	// location in the input config of the reference that led here, or
+	// nil for the input config itself
+	top *common.SourceLocation
+}
+
+// Flatten translates the Butane config in input to an Ignition config,
+// then merges in each child config referenced from ignition.config the
+// same way Ignition would, producing the effective config.  It returns
+// the marshaled config and the source location of each of its fields.
+// Locations in the input config have an empty File.
+//
+// Child configs from inline, local, and butane_local, or from data URLs,
+// are flattened.  Remote child configs are reported as warnings and left
+// in place, unless InlineRemote is set.  Child configs with an older
+// Ignition spec version than the translated input config are translated
+// to its version; newer child configs are an error.
+func Flatten(input []byte, options common.TranslateBytesOptions) ([]byte, common.SourceMap, report.Report, error) {
+	if butanelocal.GetTranslator(options.TranslateOptions) == nil {
+		return nil, nil, report.Report{}, common.ErrNoButaneTranslator
+	}
+	f := flattener{
+		options: options,
+	}
+
+	out, sm, r, err := f.translate(input)
+	if err != nil {
+		return nil, nil, r, err
+	}
+	version, err := ignitionVersion(out)
+	if err != nil {
+		return nil, nil, r, err
+	}
+	configType, ok := ignitionConfigTypes[version]
+	if !ok {
+		return nil, nil, r, common.ErrFlattenVersion{Version: version}
+	}
+	f.version = version
+	f.configType = configType
+	cfg := reflect.New(configType)
+	if err := json.Unmarshal(out, cfg.Interface()); err != nil {
+		return nil, nil, r, err
+	}
+
+	result, sm := f.render(cfg.Elem(), sm, flattenLevel{butane: input})
+	r.Merge(f.r)
+	if r.IsFatal() {
+		return nil, nil, r, common.ErrInvalidSourceConfig
+	}
+	flattened, err := marshal(result.Interface(), options.Pretty)
+	return flattened, sm, r, err
+}
+
+// translate translates a Butane config to Ignition, recording the source
+// of each field.
+func (f *flattener) translate(input []byte) ([]byte, common.SourceMap, report.Report, error) {
+	options := f.options
+	// the effective config is always an unwrapped Ignition config
+	options.Raw = true
+	options.Wrapper = nil
+	options.SourceMap = make(common.SourceMap)
+	out, r, err := butanelocal.GetTranslator(options.TranslateOptions)(input, options)
+	return out, options.SourceMap, r, err
+}
+
+// render renders cfg, an Ignition config of type f.configType, the same
+// way Ignition does: a replacement config replaces it entirely, and each
+// merged config is rendered and then merged in.  sm is the source map of
+// cfg.
+func (f *flattener) render(cfg reflect.Value, sm common.SourceMap, level flattenLevel) (reflect.Value, common.SourceMap) {
+	refs := cfg.FieldByName("Ignition").FieldByName("Config")
+	replace := refs.FieldByName("Replace")
+	if stringField(replace, "Source") != "" {
+		child, childMap, childLevel, ok := f.fetch(replace, path.New("json", "ignition", "config", "replace"), sm, level)
+		if !ok {
+			return cfg, sm
+		}
+		return f.render(child, childMap, childLevel)
+	}
+
+	result, resultMap := cfg, sm
+	merges := refs.FieldByName("Merge")
+	for i := 0; i < merges.Len(); i++ {
+		child, childMap, childLevel, ok := f.fetch(merges.Index(i), path.New("json", "ignition", "config", "merge", i), sm, level)
+		if !ok {
+			continue
+		}
+		child, childMap = f.render(child, childMap, childLevel)
+		result, resultMap = mergeConfigs(result, resultMap, child, childMap)
+	}
+	return result, resultMap
+}
+
+// mergeConfigs merges child into parent and follows the merge transcript
+// to build the source map of the result.
+func mergeConfigs(parent reflect.Value, parentMap common.SourceMap, child reflect.Value, childMap common.SourceMap) (reflect.Value, common.SourceMap) {
+	result, transcript := merge.MergeStructTranscribe(parent.Interface(), child.Interface())
+	resultMap := make(common.SourceMap)
+	for _, m := range transcript.Mappings {
+		from := parentMap
+		if m.From.Tag == merge.TAG_CHILD {
+			from = childMap
+		}
+		if loc, ok := from[m.From.String()]; ok {
+			resultMap[m.To.String()] = loc
+		}
+	}
+	return reflect.ValueOf(result), resultMap
+}
+
+// fetch reads and parses the child config referenced by ref at p, and
+// builds its source map.  It returns false if the child can't be
+// flattened.
+func (f *flattener) fetch(ref reflect.Value, p path.ContextPath, sm common.SourceMap, level flattenLevel) (reflect.Value, common.SourceMap, flattenLevel, bool) {
+	loc, ok := sm[p.Append("source").String()]
+	if !ok {
+		loc = sm[p.String()]
+	}
+	contents := resourceContents(ref)
+	if contents == nil {
+		f.report(level, loc, report.Warn, common.ErrFlattenRemote)
+		return reflect.Value{}, nil, flattenLevel{}, false
+	}
+
+	childLevel := flattenLevel{
+		top: level.top,
+	}
+	if childLevel.top == nil {
+		childLevel.top = &loc
+	}
+	childMap := make(common.SourceMap)
+	switch {
+	case level.butane != nil && strings.HasSuffix(loc.Path, ".butane_local"):
+		name, err := yamlString(level.butane, loc.Path)
+		if err != nil {
+			f.report(level, loc, report.Error, err)
+			return reflect.Value{}, nil, flattenLevel{}, false
+		}
+		input, _, err := baseutil.ReadLocalFileFromDirs(name, f.options.TranslateOptions)
+		if err != nil {
+			f.report(level, loc, report.Error, err)
+			return reflect.Value{}, nil, flattenLevel{}, false
+		}
+		// any problems were already reported when translating the
+		// parent, so ignore the report
+		var childOut []byte
+		childOut, childMap, _, err = f.translate(input)
+		if err != nil {
+			f.report(level, loc, report.Error, err)
+			return reflect.Value{}, nil, flattenLevel{}, false
+		}
+		for key, childLoc := range childMap {
+			childLoc.File = name
+			childMap[key] = childLoc
+		}
+		contents = childOut
+		childLevel.butane = input
+	case level.butane != nil && strings.HasSuffix(loc.Path, ".local"):
+		name, err := yamlString(level.butane, loc.Path)
+		if err != nil {
+			f.report(level, loc, report.Error, err)
+			return reflect.Value{}, nil, flattenLevel{}, false
+		}
+		contextTree, err := vjson.UnmarshalToContext(contents)
+		if err != nil {
+			f.report(level, loc, report.Error, err)
+			return reflect.Value{}, nil, flattenLevel{}, false
+		}
+		treeSourceMap(contextTree, path.New("json"), name, childMap)
+	default:
+		// inline contents or a data URL; attribute every field to
+		// the reference
+		contextTree, err := vjson.UnmarshalToContext(contents)
+		if err != nil {
+			f.report(level, loc, report.Error, err)
+			return reflect.Value{}, nil, flattenLevel{}, false
+		}
+		treeSourceMap(contextTree, path.New("json"), "", childMap)
+		for key := range childMap {
+			childMap[key] = loc
+		}
+	}
+
+	version, err := ignitionVersion(contents)
+	if err != nil {
+		f.report(level, loc, report.Error, err)
+		return reflect.Value{}, nil, flattenLevel{}, false
+	}
+	child, err := f.upTranslate(contents, version)
+	if err != nil {
+		f.report(level, loc, report.Error, err)
+		return reflect.Value{}, nil, flattenLevel{}, false
+	}
+	return child, childMap, childLevel, true
+}
+
+// upTranslate parses contents, an Ignition config with the specified spec
+// version, and translates it to f.version.  Like Ignition, it accepts
+// child configs with older spec versions, whose fields are a subset of
+// the newer spec's.
+func (f *flattener) upTranslate(contents []byte, version string) (reflect.Value, error) {
+	configType, ok := ignitionConfigTypes[version]
+	if !ok || !versionAtMost(version, f.version) {
+		return reflect.Value{}, common.ErrFlattenVersion{Version: version, Expected: f.version}
+	}
+	if version != f.version {
+		// drop any fields the child's own spec doesn't have
+		cfg := reflect.New(configType)
+		if err := json.Unmarshal(contents, cfg.Interface()); err != nil {
+			return reflect.Value{}, err
+		}
+		var err error
+		if contents, err = json.Marshal(cfg.Interface()); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	child := reflect.New(f.configType)
+	if err := json.Unmarshal(contents, child.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	child.Elem().FieldByName("Ignition").FieldByName("Version").SetString(f.version)
+	return child.Elem(), nil
+}
+
+// versionAtMost returns true if spec version a is no newer than b.
+func versionAtMost(a, b string) bool {
+	va, err := semver.NewVersion(a)
+	if err != nil {
+		return false
+	}
+	vb, err := semver.NewVersion(b)
+	if err != nil {
+		return false
+	}
+	return !vb.LessThan(*va)
+}
+
+// report reports err at loc.  Locations outside the input config are
+// reported at the reference in the input config that led to them.
+func (f *flattener) report(level flattenLevel, loc common.SourceLocation, kind report.EntryKind, err error) {
+	if loc.File != "" && level.top != nil {
+		err = common.ErrChildConfig{
+			File:    loc.File,
+			Line:    loc.Line,
+			Column:  loc.Column,
+			Path:    loc.Path,
+			Message: err.Error(),
+		}
+		loc = *level.top
+	}
+	entry := report.Entry{
+		Kind:    kind,
+		Message: err.Error(),
+		Context: parsePath("yaml", loc.Path),
+	}
+	if loc.Line > 0 {
+		entry.Marker = tree.Marker{
+			StartP: &tree.Pos{
+				Line:   loc.Line,
+				Column: loc.Column,
+			},
+		}
+	}
+	f.r.Entries = append(f.r.Entries, entry)
+}
+
+// ignitionVersion returns the spec version of an Ignition config.
+func ignitionVersion(contents []byte) (string, error) {
+	var cfg struct {
+		Ignition struct {
+			Version string `json:"version"`
+		} `json:"ignition"`
+	}
+	if err := json.Unmarshal(contents, &cfg); err != nil {
+		return "", err
+	}
+	return cfg.Ignition.Version, nil
+}
+
+// yamlString returns the string at p, a path string such as
+// "$.ignition.config.merge.0.local", in a YAML document.
+func yamlString(input []byte, p string) (string, error) {
+	var node interface{}
+	if err := yaml.Unmarshal(input, &node); err != nil {
+		return "", err
+	}
+	node, _, found := resolvePath(node, p, valueChild)
+	str, ok := node.(string)
+	if !found || !ok {
+		return "", errNoChildPath
+	}
+	return str, nil
+}
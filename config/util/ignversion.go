@@ -0,0 +1,113 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"reflect"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/clarketm/json"
+	"github.com/coreos/go-semver/semver"
+	ignvalidate "github.com/coreos/ignition/v2/config/validate"
+	vjson "github.com/coreos/vcontext/json"
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"github.com/coreos/vcontext/validate"
+)
+
+// checkIgnitionVersion reports the fields of final, an Ignition config,
+// that can't be expressed in the specified older Ignition spec version,
+// and anything that the older spec's validation rules reject.  Entries are
+// in JSON space, except for problems with the version itself, which are
+// reported against the Butane version field.
+func checkIgnitionVersion(final interface{}, version string) (r report.Report) {
+	versionPath := path.New("yaml", "version")
+	configType, ok := ignitionConfigTypes[version]
+	if !ok {
+		r.AddOnError(versionPath, common.ErrUnknownIgnitionSpec{Version: version})
+		return
+	}
+	current, ok := configVersion(reflect.ValueOf(final))
+	if !ok {
+		r.AddOnError(versionPath, common.ErrIgnitionVersionOutput)
+		return
+	}
+	if current.String() == version {
+		return
+	}
+	if current.LessThan(*semver.New(version)) {
+		r.AddOnError(versionPath, common.ErrIgnitionVersionNewer{Version: version, Current: current.String()})
+		return
+	}
+
+	// round-trip through the older spec's types
+	raw, err := json.Marshal(final)
+	if err != nil {
+		r.AddOnError(versionPath, err)
+		return
+	}
+	contextTree, err := vjson.UnmarshalToContext(raw)
+	if err != nil {
+		r.AddOnError(versionPath, err)
+		return
+	}
+	cfg := reflect.New(configType)
+	if err := json.Unmarshal(raw, cfg.Interface()); err != nil {
+		r.AddOnError(versionPath, err)
+		return
+	}
+	cfg.Elem().FieldByName("Ignition").FieldByName("Version").SetString(version)
+
+	// fields the older spec doesn't have would be silently dropped
+	unusedKeyCheck := func(v reflect.Value, c path.ContextPath) report.Report {
+		return ignvalidate.ValidateUnusedKeys(v, c, contextTree)
+	}
+	for _, e := range validate.ValidateCustom(cfg.Elem().Interface(), "json", unusedKeyCheck).Entries {
+		r.AddOnError(e.Context, common.ErrIgnitionVersionField{Version: version})
+	}
+	r.Merge(validate.Validate(cfg.Elem().Interface(), "json"))
+	return
+}
+
+// setIgnitionVersion returns a copy of final, an Ignition config, with its
+// spec version set to version.
+func setIgnitionVersion(final interface{}, version string) interface{} {
+	cfg := reflect.New(reflect.TypeOf(final)).Elem()
+	cfg.Set(reflect.ValueOf(final))
+	cfg.FieldByName("Ignition").FieldByName("Version").SetString(version)
+	return cfg.Interface()
+}
+
+// configVersion returns the spec version of v if it's an Ignition
+// config.
+func configVersion(v reflect.Value) (semver.Version, bool) {
+	if v.Kind() != reflect.Struct {
+		return semver.Version{}, false
+	}
+	ignition := v.FieldByName("Ignition")
+	if !ignition.IsValid() || ignition.Kind() != reflect.Struct {
+		return semver.Version{}, false
+	}
+	field := ignition.FieldByName("Version")
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return semver.Version{}, false
+	}
+	version, err := semver.NewVersion(field.String())
+	if err != nil {
+		return semver.Version{}, false
+	}
+	return *version, true
+}
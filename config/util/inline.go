@@ -0,0 +1,217 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	baseutil "github.com/coreos/butane/base/util"
+	"github.com/coreos/butane/config/common"
+	"github.com/coreos/butane/translate"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"github.com/vincent-petithory/dataurl"
+)
+
+// resourceRef is a pointer to an Ignition Resource struct somewhere in
+// an Ignition config.
+type resourceRef struct {
+	value reflect.Value
+	path  path.ContextPath
+}
+
+// inlineRemoteResources fetches every remote resource in cfg, an Ignition
+// config or a struct containing one, and replaces its source with a data
+// URL.  It updates ts for any fields it adds, and returns the updated
+// config and a report in `json` paths.
+//
+// Ignition resource types vary by spec version, so we find them by
+// reflection rather than walking a particular config version.
+func inlineRemoteResources(cfg interface{}, ts translate.TranslationSet, options common.TranslateOptions) (interface{}, report.Report) {
+	var r report.Report
+	fetcher := options.Fetcher
+	if fetcher == nil {
+		fetcher = HTTPFetcher{}
+	}
+
+	// get an addressable copy of the config
+	v := reflect.New(reflect.TypeOf(cfg)).Elem()
+	v.Set(reflect.ValueOf(cfg))
+
+	var resources, cas []resourceRef
+	fetchOptions := common.FetchOptions{
+		Context: options.Context,
+	}
+	findResources(v, path.New("json"), &resources, &cas, &fetchOptions)
+
+	// CAs can't be fetched using themselves, so fetch them first, then
+	// use them for everything else
+	for _, ca := range cas {
+		inlineResource(ca, fetcher, fetchOptions, ts, options, &r)
+		if contents := resourceContents(ca.value); contents != nil {
+			fetchOptions.CertificateAuthorities = append(fetchOptions.CertificateAuthorities, contents)
+		}
+	}
+	for _, res := range resources {
+		inlineResource(res, fetcher, fetchOptions, ts, options, &r)
+	}
+	return v.Interface(), r
+}
+
+// findResources walks v, adding resources to resources, certificate
+// authorities to cas, and proxy settings to fetchOptions.
+func findResources(v reflect.Value, p path.ContextPath, resources, cas *[]resourceRef, fetchOptions *common.FetchOptions) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			findResources(v.Elem(), p, resources, cas, fetchOptions)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			findResources(v.Index(i), p.Append(i), resources, cas, fetchOptions)
+		}
+	case reflect.Struct:
+		if isResource(v.Type()) {
+			*resources = append(*resources, resourceRef{v, p.Copy()})
+			return
+		}
+		if proxy := v.FieldByName("Proxy"); proxy.IsValid() && v.FieldByName("Security").IsValid() {
+			// the ignition section
+			fetchOptions.HTTPProxy = stringField(proxy, "HTTPProxy")
+			fetchOptions.HTTPSProxy = stringField(proxy, "HTTPSProxy")
+			noProxy := proxy.FieldByName("NoProxy")
+			for i := 0; i < noProxy.Len(); i++ {
+				fetchOptions.NoProxy = append(fetchOptions.NoProxy, noProxy.Index(i).String())
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath := p
+			if !field.Anonymous {
+				fieldPath = p.Append(strings.Split(field.Tag.Get("json"), ",")[0])
+			}
+			if field.Name == "CertificateAuthorities" {
+				findResources(v.Field(i), fieldPath, cas, cas, fetchOptions)
+			} else {
+				findResources(v.Field(i), fieldPath, resources, cas, fetchOptions)
+			}
+		}
+	}
+}
+
+func isResource(t reflect.Type) bool {
+	source, ok := t.FieldByName("Source")
+	if !ok || source.Type != reflect.TypeOf((*string)(nil)) {
+		return false
+	}
+	_, ok = t.FieldByName("Verification")
+	return ok
+}
+
+func stringField(v reflect.Value, name string) string {
+	field := v.FieldByName(name)
+	if !field.IsValid() || field.IsNil() {
+		return ""
+	}
+	return field.Elem().String()
+}
+
+// inlineResource fetches a resource if it's remote, and replaces its
+// source with a data URL.
+func inlineResource(res resourceRef, fetcher common.Fetcher, fetchOptions common.FetchOptions, ts translate.TranslationSet, options common.TranslateOptions, r *report.Report) {
+	source := stringField(res.value, "Source")
+	if u, err := url.Parse(source); source == "" || err != nil || u.Scheme == "data" {
+		// nothing to fetch, or left for validation to report
+		return
+	}
+	sourcePath := res.path.Append("source")
+
+	headers := res.value.FieldByName("HTTPHeaders")
+	if headers.IsValid() && headers.Len() > 0 {
+		fetchOptions.Headers = make(http.Header)
+		for i := 0; i < headers.Len(); i++ {
+			header := headers.Index(i)
+			fetchOptions.Headers.Add(header.FieldByName("Name").String(), stringField(header, "Value"))
+		}
+	}
+	contents, err := fetcher.Fetch(source, fetchOptions)
+	if err != nil {
+		r.AddOnError(sourcePath, err)
+		return
+	}
+
+	compressionField := res.value.FieldByName("Compression")
+	compression := compressionField.Interface().(*string)
+	if hash := stringField(res.value.FieldByName("Verification"), "Hash"); hash != "" {
+		if err := baseutil.CheckHash(hash, contents, compression); err != nil {
+			r.AddOnError(res.path, err)
+			return
+		}
+	}
+
+	uri, newCompression, err := baseutil.MakeDataURLCached(contents, compression, !options.NoResourceAutoCompression, options.DataURLCache)
+	if err != nil {
+		r.AddOnError(sourcePath, err)
+		return
+	}
+	res.value.FieldByName("Source").Set(reflect.ValueOf(&uri))
+	if newCompression != nil {
+		compressionField.Set(reflect.ValueOf(newCompression))
+		if t, ok := ts.Get(sourcePath); ok {
+			ts.AddTranslation(t.From, res.path.Append("compression"))
+		}
+	}
+	// headers aren't allowed with data URLs
+	if headers.IsValid() {
+		headers.Set(reflect.Zero(headers.Type()))
+		ts.DeleteSubtree(res.path.Append("httpHeaders"))
+	}
+}
+
+// resourceContents returns the contents of a resource with a data URL,
+// or nil if it can't be decoded.
+func resourceContents(v reflect.Value) []byte {
+	decoded, err := dataurl.DecodeString(stringField(v, "Source"))
+	if err != nil {
+		return nil
+	}
+	switch stringField(v, "Compression") {
+	case "":
+		return decoded.Data
+	case "gzip":
+		reader, err := gzip.NewReader(bytes.NewReader(decoded.Data))
+		if err != nil {
+			return nil
+		}
+		defer reader.Close()
+		contents, err := io.ReadAll(reader)
+		if err != nil {
+			return nil
+		}
+		return contents
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coreos/butane/config/common"
+	"github.com/coreos/butane/translate"
+
+	"github.com/coreos/ignition/v2/config/util"
+	// config version doesn't matter; just pick one
+	"github.com/coreos/ignition/v2/config/v3_5/types"
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/vincent-petithory/dataurl"
+)
+
+func TestInlineRemoteResources(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/file":
+			fmt.Fprint(w, "hello")
+		case "/private":
+			if req.Header.Get("X-Auth") != "secret" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, "private")
+		default:
+			http.NotFound(w, req)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: tlsServer.Certificate().Raw,
+	})
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// a proxied request has an absolute URL
+		fmt.Fprintf(w, "proxied %s", req.URL)
+	}))
+	defer proxy.Close()
+
+	mirror := t.TempDir()
+	if err := os.WriteFile(filepath.Join(mirror, "file"), []byte("mirrored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sha256Hello := "sha256-2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	dataURL := func(contents string) *string {
+		return util.StrToPtr("data:," + dataurl.Escape([]byte(contents)))
+	}
+	file := func(source string, resource types.Resource) types.Config {
+		resource.Source = util.StrToPtr(source)
+		return types.Config{
+			Storage: types.Storage{
+				Files: []types.File{
+					{
+						Node: types.Node{
+							Path: "/f",
+						},
+						FileEmbedded1: types.FileEmbedded1{
+							Contents: resource,
+						},
+					},
+				},
+			},
+		}
+	}
+	contentsPath := path.New("json", "storage", "files", 0, "contents")
+
+	tests := []struct {
+		in      types.Config
+		options common.TranslateOptions
+		out     types.Config
+		report  report.Report
+	}{
+		// plain HTTP, with hash
+		{
+			in: file(server.URL+"/file", types.Resource{
+				Verification: types.Verification{
+					Hash: util.StrToPtr(sha256Hello),
+				},
+			}),
+			out: file("data:,hello", types.Resource{
+				Compression: util.StrToPtr(""),
+				Verification: types.Verification{
+					Hash: util.StrToPtr(sha256Hello),
+				},
+			}),
+		},
+		// hash mismatch
+		{
+			in: file(server.URL+"/private", types.Resource{
+				HTTPHeaders: types.HTTPHeaders{
+					{
+						Name:  "X-Auth",
+						Value: util.StrToPtr("secret"),
+					},
+				},
+				Verification: types.Verification{
+					Hash: util.StrToPtr(sha256Hello),
+				},
+			}),
+			out: file(server.URL+"/private", types.Resource{
+				HTTPHeaders: types.HTTPHeaders{
+					{
+						Name:  "X-Auth",
+						Value: util.StrToPtr("secret"),
+					},
+				},
+				Verification: types.Verification{
+					Hash: util.StrToPtr(sha256Hello),
+				},
+			}),
+			report: report.Report{
+				Entries: []report.Entry{
+					{
+						Kind:    report.Error,
+						Message: common.ErrHashMismatch.Error(),
+						Context: contentsPath,
+					},
+				},
+			},
+		},
+		// HTTP headers; removed from output
+		{
+			in: file(server.URL+"/private", types.Resource{
+				HTTPHeaders: types.HTTPHeaders{
+					{
+						Name:  "X-Auth",
+						Value: util.StrToPtr("secret"),
+					},
+				},
+			}),
+			out: file("data:,private", types.Resource{
+				Compression: util.StrToPtr(""),
+			}),
+		},
+		// HTTP error
+		{
+			in:  file(server.URL+"/private", types.Resource{}),
+			out: file(server.URL+"/private", types.Resource{}),
+			report: report.Report{
+				Entries: []report.Entry{
+					{
+						Kind:    report.Error,
+						Message: common.ErrFetchStatus{URL: server.URL + "/private", Status: "403 Forbidden"}.Error(),
+						Context: contentsPath.Append("source"),
+					},
+				},
+			},
+		},
+		// HTTPS with a CA from the config
+		{
+			in: func() types.Config {
+				cfg := file(tlsServer.URL+"/file", types.Resource{})
+				cfg.Ignition.Security.TLS.CertificateAuthorities = []types.Resource{
+					{
+						Source: dataURL(string(caPEM)),
+					},
+				}
+				return cfg
+			}(),
+			out: func() types.Config {
+				cfg := file("data:,hello", types.Resource{
+					Compression: util.StrToPtr(""),
+				})
+				cfg.Ignition.Security.TLS.CertificateAuthorities = []types.Resource{
+					{
+						Source: dataURL(string(caPEM)),
+					},
+				}
+				return cfg
+			}(),
+		},
+		// proxy from the config
+		{
+			in: func() types.Config {
+				cfg := file("http://example.invalid/file", types.Resource{})
+				cfg.Ignition.Proxy.HTTPProxy = util.StrToPtr(proxy.URL)
+				return cfg
+			}(),
+			out: func() types.Config {
+				cfg := file(*dataURL("proxied http://example.invalid/file"), types.Resource{
+					Compression: util.StrToPtr(""),
+				})
+				cfg.Ignition.Proxy.HTTPProxy = util.StrToPtr(proxy.URL)
+				return cfg
+			}(),
+		},
+		// URL map
+		{
+			in: file("https://mirror.invalid/dir/file", types.Resource{}),
+			options: common.TranslateOptions{
+				Fetcher: URLMapFetcher{
+					Mappings: []URLMapping{
+						{
+							Prefix: "https://mirror.invalid/",
+							Dir:    "/nonexistent",
+						},
+						{
+							Prefix: "https://mirror.invalid/dir/",
+							Dir:    mirror,
+						},
+					},
+				},
+			},
+			out: file("data:,mirrored", types.Resource{
+				Compression: util.StrToPtr(""),
+			}),
+		},
+		// URL map, escape
+		{
+			in: file("https://mirror.invalid/../file", types.Resource{}),
+			options: common.TranslateOptions{
+				Fetcher: URLMapFetcher{
+					Mappings: []URLMapping{
+						{
+							Prefix: "https://mirror.invalid/",
+							Dir:    filepath.Join(mirror, "subdir"),
+						},
+					},
+				},
+			},
+			out: file("https://mirror.invalid/../file", types.Resource{}),
+			report: report.Report{
+				Entries: []report.Entry{
+					{
+						Kind:    report.Error,
+						Message: common.ErrFilesDirEscape.Error(),
+						Context: contentsPath.Append("source"),
+					},
+				},
+			},
+		},
+		// unsupported scheme
+		{
+			in:  file("tftp://example.invalid/file", types.Resource{}),
+			out: file("tftp://example.invalid/file", types.Resource{}),
+			report: report.Report{
+				Entries: []report.Entry{
+					{
+						Kind:    report.Error,
+						Message: common.ErrFetchScheme{Scheme: "tftp"}.Error(),
+						Context: contentsPath.Append("source"),
+					},
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("inline %d", i), func(t *testing.T) {
+			ts := translate.NewTranslationSet("yaml", "json")
+			ts.AddTranslation(path.New("yaml", "source"), contentsPath.Append("source"))
+			ts.AddTranslation(path.New("yaml", "http_headers"), contentsPath.Append("httpHeaders"))
+			actual, r := inlineRemoteResources(test.in, ts, test.options)
+			assert.Equal(t, test.out, actual, "bad config")
+			assert.Equal(t, test.report, r, "bad report")
+			if len(test.report.Entries) == 0 {
+				assert.Equal(t, path.New("yaml", "source"), ts.Set[contentsPath.Append("compression").String()].From, "missing compression translation")
+				_, ok := ts.Set[contentsPath.Append("httpHeaders").String()]
+				assert.False(t, ok, "stale http headers translation")
+			}
+		})
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	noProxy := []string{"example.com", ".example.net", "10.0.0.0/8", "192.168.1.1"}
+	tests := []struct {
+		host   string
+		bypass bool
+	}{
+		{"example.com", true},
+		{"www.example.com", true},
+		{"EXAMPLE.com", true},
+		{"notexample.com", false},
+		{"example.net", true},
+		{"www.example.net", true},
+		{"10.1.2.3", true},
+		{"11.1.2.3", false},
+		{"192.168.1.1", true},
+		{"192.168.1.2", false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("bypass %d", i), func(t *testing.T) {
+			assert.Equal(t, test.bypass, bypassProxy(test.host, noProxy), test.host)
+		})
+	}
+	assert.True(t, bypassProxy("anything", []string{"*"}))
+}
+
+func TestHTTPFetcherLimits(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/small":
+			fmt.Fprint(w, "hello")
+		case "/large":
+			fmt.Fprint(w, "hello world")
+		case "/slow":
+			select {
+			case <-release:
+			case <-req.Context().Done():
+			}
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		fetcher HTTPFetcher
+		path    string
+		options common.FetchOptions
+		out     []byte
+		err     error
+	}{
+		// at the limit
+		{
+			fetcher: HTTPFetcher{MaxSize: 5},
+			path:    "/small",
+			out:     []byte("hello"),
+		},
+		// over the limit
+		{
+			fetcher: HTTPFetcher{MaxSize: 5},
+			path:    "/large",
+			err:     common.ErrFetchTooLarge{URL: server.URL + "/large", MaxSize: 5},
+		},
+		// timeout
+		{
+			fetcher: HTTPFetcher{Timeout: 100 * time.Millisecond},
+			path:    "/slow",
+			err:     context.DeadlineExceeded,
+		},
+		// canceled context
+		{
+			path:    "/slow",
+			options: common.FetchOptions{Context: canceled},
+			err:     context.Canceled,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("fetch %d", i), func(t *testing.T) {
+			out, err := test.fetcher.Fetch(server.URL+test.path, test.options)
+			switch test.err.(type) {
+			case nil:
+				assert.NoError(t, err)
+			case common.ErrFetchTooLarge:
+				assert.Equal(t, test.err, err, "bad error")
+			default:
+				assert.ErrorIs(t, err, test.err, "bad error")
+			}
+			assert.Equal(t, test.out, out, "bad contents")
+		})
+	}
+}
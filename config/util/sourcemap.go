@@ -0,0 +1,211 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/tree"
+	"gopkg.in/yaml.v3"
+)
+
+// LocateSources fills in the line and column range of each entry in sm
+// from the context tree of the source config.
+func LocateSources(sm common.SourceMap, n tree.Node) {
+	for key, loc := range sm {
+		node := sourceNode(n, loc.Path)
+		loc.Line, loc.Column = node.Start()
+		loc.EndLine, loc.EndColumn = node.End()
+		sm[key] = loc
+	}
+}
+
+// sourceNode returns the deepest node in n along p, a path string such
+// as "$.storage.files.0".
+func sourceNode(n tree.Node, p string) tree.Node {
+	node, _, _ := resolvePath(n, p, func(n interface{}, elem string) (interface{}, bool) {
+		switch node := n.(type) {
+		case tree.MapNode:
+			child, ok := node.Children[elem]
+			return child, ok
+		case tree.SliceNode:
+			if i, err := strconv.Atoi(elem); err == nil && i >= 0 && i < len(node.Children) {
+				return node.Children[i], true
+			}
+		}
+		return nil, false
+	})
+	return node.(tree.Node)
+}
+
+// resolvePath looks up p, a path string such as "$.storage.files.0", in
+// the config rooted at n, using child to find the child of a node with a
+// given map key or list index.  Path strings join elements with dots, so
+// map keys containing dots, such as the
+// "machineconfiguration.openshift.io/role" label, are found by also
+// trying each element joined with the ones after it.  resolvePath
+// returns the node at p and the keys and indexes leading to it, or the
+// deepest node it found and false.
+func resolvePath(n interface{}, p string, child func(n interface{}, elem string) (interface{}, bool)) (interface{}, []string, bool) {
+	elems := strings.Split(p, ".")[1:]
+	node, keys, consumed := resolveElems(n, elems, child)
+	return node, keys, consumed == len(elems)
+}
+
+// resolveElems returns the deepest node found along elems from n, the
+// keys leading to it, and the number of elems they cover.
+func resolveElems(n interface{}, elems []string, child func(n interface{}, elem string) (interface{}, bool)) (interface{}, []string, int) {
+	bestNode, bestKeys, bestConsumed := n, []string(nil), 0
+	for i := 1; i <= len(elems); i++ {
+		key := strings.Join(elems[:i], ".")
+		c, ok := child(n, key)
+		if !ok {
+			continue
+		}
+		node, keys, consumed := resolveElems(c, elems[i:], child)
+		if consumed += i; consumed > bestConsumed {
+			bestNode, bestKeys, bestConsumed = node, append([]string{key}, keys...), consumed
+		}
+		if consumed == len(elems) {
+			break
+		}
+	}
+	return bestNode, bestKeys, bestConsumed
+}
+
+// treeSourceMap adds every node in n to sm, as located in file.
+func treeSourceMap(n tree.Node, p path.ContextPath, file string, sm common.SourceMap) {
+	line, column := n.Start()
+	endLine, endColumn := n.End()
+	sm[p.String()] = common.SourceLocation{
+		File:      file,
+		Path:      p.String(),
+		Line:      line,
+		Column:    column,
+		EndLine:   endLine,
+		EndColumn: endColumn,
+	}
+	switch node := n.(type) {
+	case tree.MapNode:
+		for key, child := range node.Children {
+			treeSourceMap(child, p.Append(key), file, sm)
+		}
+	case tree.SliceNode:
+		for i, child := range node.Children {
+			treeSourceMap(child, p.Append(i), file, sm)
+		}
+	}
+}
+
+// parsePath converts a path string such as "$.storage.files.0" back to
+// a ContextPath, for reporting.  Map keys containing dots are split into
+// several elements, which doesn't change how the path is displayed;
+// use resolvePath to look paths up in a config.
+func parsePath(tag, p string) path.ContextPath {
+	ret := path.New(tag)
+	for _, elem := range strings.Split(p, ".")[1:] {
+		if i, err := strconv.Atoi(elem); err == nil {
+			ret = ret.Append(i)
+		} else {
+			ret = ret.Append(elem)
+		}
+	}
+	return ret
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// JSONPointer returns the RFC 6901 JSON Pointer for p.
+func JSONPointer(p path.ContextPath) string {
+	var b strings.Builder
+	for _, elem := range p.Path {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(fmt.Sprintf("%v", elem)))
+	}
+	return b.String()
+}
+
+// valueChild returns the child of n, a decoded JSON or YAML value, at
+// elem, a map key or list index.
+func valueChild(n interface{}, elem string) (interface{}, bool) {
+	switch value := n.(type) {
+	case map[string]interface{}:
+		child, ok := value[elem]
+		return child, ok
+	case []interface{}:
+		if i, err := strconv.Atoi(elem); err == nil && i >= 0 && i < len(value) {
+			return value[i], true
+		}
+	}
+	return nil, false
+}
+
+// PathPointer returns the JSON Pointer for p, a path string such as
+// "$.storage.files.0.path", in output, a JSON config or a MachineConfig.
+// It returns false if output has no such field.
+func PathPointer(output []byte, p string) (string, bool, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(output, &v); err != nil {
+		return "", false, err
+	}
+	_, keys, found := resolvePath(v, p, valueChild)
+	if !found {
+		return "", false, nil
+	}
+	ret := path.New("json")
+	for _, key := range keys {
+		ret = ret.Append(key)
+	}
+	return JSONPointer(ret), true, nil
+}
+
+// PointerSourceMap returns the source location of every field, list
+// entry, and object in output, a JSON config or a MachineConfig
+// translated with sm, keyed by JSON Pointer.  Fields without an entry in sm take the location of
+// their closest ancestor that has one.
+func PointerSourceMap(output []byte, sm common.SourceMap) (map[string]common.SourceLocation, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(output, &v); err != nil {
+		return nil, err
+	}
+	ret := make(map[string]common.SourceLocation)
+	addPointers(v, path.New("json"), common.SourceLocation{}, false, sm, ret)
+	return ret, nil
+}
+
+func addPointers(v interface{}, p path.ContextPath, parent common.SourceLocation, haveParent bool, sm common.SourceMap, ret map[string]common.SourceLocation) {
+	loc, ok := sm[p.String()]
+	if !ok {
+		loc, ok = parent, haveParent
+	}
+	if ok {
+		ret[JSONPointer(p)] = loc
+	}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			addPointers(child, p.Append(key), loc, ok, sm, ret)
+		}
+	case []interface{}:
+		for i, child := range value {
+			addPointers(child, p.Append(i), loc, ok, sm, ret)
+		}
+	}
+}
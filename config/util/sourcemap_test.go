@@ -0,0 +1,83 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"testing"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPointer(t *testing.T) {
+	assert.Equal(t, "", JSONPointer(path.New("json")))
+	assert.Equal(t, "/storage/files/0/path", JSONPointer(path.New("json", "storage", "files", 0, "path")))
+	assert.Equal(t, "/metadata/labels/example.com~1role~0x", JSONPointer(path.New("json", "metadata", "labels", "example.com/role~x")))
+}
+
+func TestPathPointer(t *testing.T) {
+	output := []byte(`{"storage":{"files":[{"path":"/a"}]},"metadata":{"labels":{"a.b":"x","a.b.c/d":"y","a":{"b":"z"}}}}`)
+	tests := []struct {
+		in  string
+		out string
+		ok  bool
+	}{
+		{"$", "", true},
+		{"$.storage.files.0.path", "/storage/files/0/path", true},
+		// keys containing dots
+		{"$.metadata.labels.a.b.c/d", "/metadata/labels/a.b.c~1d", true},
+		{"$.metadata.labels.a.b", "/metadata/labels/a/b", true},
+		// missing fields
+		{"$.storage.files.1", "", false},
+		{"$.metadata.labels.a.c", "", false},
+	}
+	for _, test := range tests {
+		actual, ok, err := PathPointer(output, test.in)
+		assert.NoError(t, err, test.in)
+		assert.Equal(t, test.ok, ok, test.in)
+		assert.Equal(t, test.out, actual, test.in)
+	}
+
+	_, _, err := PathPointer([]byte("{"), "$")
+	assert.Error(t, err)
+}
+
+func TestPointerSourceMap(t *testing.T) {
+	root := common.SourceLocation{Path: "$", Line: 1, Column: 1}
+	files := common.SourceLocation{Path: "$.storage.trees.0", Line: 3, Column: 5, EndLine: 4, EndColumn: 10}
+	label := common.SourceLocation{Path: "$.metadata.labels.example.com/role", Line: 8, Column: 7}
+	sm := common.SourceMap{
+		"$":                                  root,
+		"$.storage.files.0":                  files,
+		"$.metadata.labels.example.com/role": label,
+	}
+	actual, err := PointerSourceMap([]byte(`{"storage":{"files":[{"path":"/a"}]},"metadata":{"labels":{"example.com/role":"x"}}}`), sm)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]common.SourceLocation{
+		"":                                   root,
+		"/storage":                           root,
+		"/storage/files":                     root,
+		"/storage/files/0":                   files,
+		"/storage/files/0/path":              files,
+		"/metadata":                          root,
+		"/metadata/labels":                   root,
+		"/metadata/labels/example.com~1role": label,
+	}, actual)
+
+	_, err = PointerSourceMap([]byte("{"), sm)
+	assert.Error(t, err)
+}
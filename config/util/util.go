@@ -32,7 +32,6 @@ import (
 	"github.com/coreos/vcontext/report"
 	"github.com/coreos/vcontext/tree"
 	"github.com/coreos/vcontext/validate"
-	vyaml "github.com/coreos/vcontext/yaml"
 	"gopkg.in/yaml.v3"
 )
 
@@ -46,31 +45,99 @@ type Config interface {
 	FieldFilters() *FieldFilters
 }
 
+// filteredConfig replaces the FieldFilters of a Config.
+type filteredConfig struct {
+	cfg     Config
+	filters *FieldFilters
+}
+
+func (c filteredConfig) FieldFilters() *FieldFilters {
+	return c.filters
+}
+
+// WithFieldFilters returns cfg with its FieldFilters replaced by filters,
+// for translating with TranslateWithFunc.
+func WithFieldFilters(cfg Config, filters *FieldFilters) Config {
+	return filteredConfig{
+		cfg:     cfg,
+		filters: filters,
+	}
+}
+
 // Translate translates cfg to the corresponding Ignition config version
 // using the named translation method on cfg, and returns the marshaled
 // Ignition config.  It returns a report of any errors or warnings in the
 // source and resultant config.  If the report has fatal errors or it
 // encounters other problems translating, an error is returned.
 func Translate(cfg Config, translateMethod string, options common.TranslateOptions) (interface{}, report.Report, error) {
+	final, _, r, err := TranslateWithTranslations(cfg, translateMethod, options)
+	return final, r, err
+}
+
+// TranslateWithTranslations is like Translate, but also returns the set
+// of translations from paths in cfg to paths in the result.  The named
+// translation method must be the unvalidated variant, such as
+// ToIgn3_5Unvalidated.
+func TranslateWithTranslations(cfg Config, translateMethod string, options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report, error) {
 	// Get method, and zero return value for error returns.
 	method := reflect.ValueOf(cfg).MethodByName(translateMethod)
 	zeroValue := reflect.Zero(method.Type().Out(0)).Interface()
+	translateFunc := func(options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report) {
+		ret := method.Call([]reflect.Value{reflect.ValueOf(options)})
+		return ret[0].Interface(), ret[1].Interface().(translate.TranslationSet), ret[2].Interface().(report.Report)
+	}
+	final, translations, r, err := TranslateWithFunc(cfg, translateFunc, options)
+	if err != nil {
+		final = zeroValue
+	}
+	return final, translations, r, err
+}
+
+// TranslateFunc is an unvalidated translation method bound to its config,
+// such as cfg.ToIgn3_5Unvalidated with its result boxed.
+type TranslateFunc func(options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report)
 
-	// Validate the input.
-	r := validate.Validate(cfg, "yaml")
+// TranslateWithFunc is like TranslateWithTranslations, but translates with
+// translateFunc.  cfg is validated and supplies the FieldFilters.  On
+// error, the returned config is nil.
+func TranslateWithFunc(cfg Config, translateFunc TranslateFunc, options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report, error) {
+	var translations translate.TranslationSet
+	if err := contextErr(options); err != nil {
+		return nil, translations, report.Report{}, err
+	}
+
+	// Validate the input.  Translators assume their input is valid, so
+	// an invalid config is never translated, even with ReportAll.
+	validated := cfg
+	if filtered, ok := cfg.(filteredConfig); ok {
+		validated = filtered.cfg
+	}
+	r := validate.Validate(validated, "yaml")
 	if r.IsFatal() {
-		return zeroValue, r, common.ErrInvalidSourceConfig
+		return nil, translations, r, common.ErrInvalidSourceConfig
 	}
 
 	// Perform the translation.
-	translateRet := method.Call([]reflect.Value{reflect.ValueOf(options)})
-	final := translateRet[0].Interface()
-	translations := translateRet[1].Interface().(translate.TranslationSet)
-	translateReport := translateRet[2].Interface().(report.Report)
+	final, translations, translateReport := translateFunc(options)
 	r.Merge(TranslateReportPaths(translateReport, translations))
-	if r.IsFatal() {
-		return zeroValue, r, common.ErrInvalidSourceConfig
+	if err := contextErr(options); err != nil {
+		return nil, translations, r, err
+	}
+	if r.IsFatal() && !options.ReportAll {
+		return nil, translations, r, common.ErrInvalidSourceConfig
 	}
+	sourceFatal := r.IsFatal()
+
+	// Embed remote resources.
+	if options.InlineRemote && !sourceFatal {
+		var inlineReport report.Report
+		final, inlineReport = inlineRemoteResources(final, translations, options)
+		r.Merge(TranslateReportPaths(inlineReport, translations))
+		if r.IsFatal() {
+			return nil, translations, r, common.ErrInvalidSourceConfig
+		}
+	}
+
 	if options.DebugPrintTranslations {
 		fmt.Fprint(os.Stderr, translations)
 		if err := translations.DebugVerifyCoverage(final); err != nil {
@@ -78,14 +145,28 @@ func Translate(cfg Config, translateMethod string, options common.TranslateOptio
 		}
 	}
 
+	// Record source paths; TranslateBytes fills in their positions.
+	if options.SourceMap != nil {
+		for _, t := range translations.Translations() {
+			options.SourceMap[t.To.String()] = common.SourceLocation{
+				Path: t.From.String(),
+			}
+		}
+		// the root has no translation of its own
+		if _, ok := options.SourceMap["$"]; !ok {
+			options.SourceMap["$"] = common.SourceLocation{Path: "$"}
+		}
+	}
+
 	// Check for fields forbidden by this spec.
 	filters := cfg.FieldFilters()
 	if filters != nil {
 		filterReport := filters.Verify(final)
 		r.Merge(TranslateReportPaths(filterReport, translations))
-		if r.IsFatal() {
-			return zeroValue, r, common.ErrInvalidSourceConfig
+		if r.IsFatal() && !options.ReportAll {
+			return nil, translations, r, common.ErrInvalidSourceConfig
 		}
+		sourceFatal = r.IsFatal()
 	}
 
 	// Check for invalid duplicated keys.
@@ -96,10 +177,30 @@ func Translate(cfg Config, translateMethod string, options common.TranslateOptio
 	jsonReport := validate.Validate(final, "json")
 	r.Merge(TranslateReportPaths(jsonReport, translations))
 
-	if r.IsFatal() {
-		return zeroValue, r, common.ErrInvalidGeneratedConfig
+	// Check that the config fits the requested Ignition spec version.
+	// TranslateBytes rewrites the version.
+	if options.IgnitionVersion != "" {
+		versionReport := checkIgnitionVersion(final, options.IgnitionVersion)
+		r.Merge(TranslateReportPaths(versionReport, translations))
+		if versionReport.IsFatal() {
+			sourceFatal = true
+		}
 	}
-	return final, r, nil
+
+	if sourceFatal {
+		return nil, translations, r, common.ErrInvalidSourceConfig
+	} else if r.IsFatal() {
+		return nil, translations, r, common.ErrInvalidGeneratedConfig
+	}
+	return final, translations, r, nil
+}
+
+// contextErr returns the error of options.Context, if it's set and done.
+func contextErr(options common.TranslateOptions) error {
+	if options.Context == nil {
+		return nil
+	}
+	return options.Context.Err()
 }
 
 // TranslateBytes unmarshals the Butane config specified in input into the
@@ -110,21 +211,9 @@ func Translate(cfg Config, translateMethod string, options common.TranslateOptio
 // encounters other problems translating, an error is returned.
 func TranslateBytes(input []byte, container interface{}, translateMethod string, options common.TranslateBytesOptions) ([]byte, report.Report, error) {
 	cfg := container
-
-	// Unmarshal the YAML.
-	contextTree, err := unmarshal(input, cfg)
+	contextTree, r, err := Parse(input, cfg)
 	if err != nil {
-		return nil, report.Report{}, err
-	}
-
-	// Check for unused keys.
-	unusedKeyCheck := func(v reflect.Value, c path.ContextPath) report.Report {
-		return ignvalidate.ValidateUnusedKeys(v, c, contextTree)
-	}
-	r := validate.ValidateCustom(cfg, "yaml", unusedKeyCheck)
-	r.Correlate(contextTree)
-	if r.IsFatal() {
-		return nil, r, common.ErrInvalidSourceConfig
+		return nil, r, err
 	}
 
 	// Perform the translation.
@@ -140,12 +229,45 @@ func TranslateBytes(input []byte, container interface{}, translateMethod string,
 	if r.IsFatal() {
 		return nil, r, common.ErrInvalidSourceConfig
 	}
-
-	// Marshal the JSON.
-	outbytes, err := marshal(final, options.Pretty)
+	if options.SourceMap != nil {
+		LocateSources(options.SourceMap, contextTree)
+	}
+	outbytes, err := MarshalOutput(final, options)
 	return outbytes, r, err
 }
 
+// MarshalOutput marshals a translated config to JSON, rewriting its
+// Ignition version if options.IgnitionVersion is set.
+func MarshalOutput(final interface{}, options common.TranslateBytesOptions) ([]byte, error) {
+	if options.IgnitionVersion != "" {
+		final = setIgnitionVersion(final, options.IgnitionVersion)
+	}
+	return marshal(final, options.Pretty)
+}
+
+// Parse unmarshals the Butane config specified in input into the struct
+// pointed to by container and checks it for unused keys.  It returns the
+// context tree of input, for correlating reports with it, and a report
+// that is already correlated.
+func Parse(input []byte, container interface{}) (tree.Node, report.Report, error) {
+	// Unmarshal the YAML.
+	contextTree, err := unmarshal(input, container)
+	if err != nil {
+		return nil, report.Report{}, err
+	}
+
+	// Check for unused keys.
+	unusedKeyCheck := func(v reflect.Value, c path.ContextPath) report.Report {
+		return ignvalidate.ValidateUnusedKeys(v, c, contextTree)
+	}
+	r := validate.ValidateCustom(container, "yaml", unusedKeyCheck)
+	r.Correlate(contextTree)
+	if r.IsFatal() {
+		return contextTree, r, common.ErrInvalidSourceConfig
+	}
+	return contextTree, r, nil
+}
+
 func TranslateBytesYAML(input []byte, container interface{}, translateMethod string, options common.TranslateBytesOptions) ([]byte, report.Report, error) {
 	// marshal to JSON, unmarshal, remarshal to YAML.  there's no other
 	// good way to respect the `json` struct tags.
@@ -154,10 +276,16 @@ func TranslateBytesYAML(input []byte, container interface{}, translateMethod str
 	if err != nil {
 		return jsonCfg, r, err
 	}
+	yamlCfg, err := jsonToYAML(jsonCfg)
+	return yamlCfg, r, err
+}
 
+// jsonToYAML re-encodes a JSON document as YAML with a generated-file
+// header.
+func jsonToYAML(jsonCfg []byte) ([]byte, error) {
 	var ifaceCfg interface{}
 	if err := json.Unmarshal(jsonCfg, &ifaceCfg); err != nil {
-		return []byte{}, r, err
+		return []byte{}, err
 	}
 
 	var yamlCfgBuf bytes.Buffer
@@ -165,13 +293,12 @@ func TranslateBytesYAML(input []byte, container interface{}, translateMethod str
 	encoder := yaml.NewEncoder(&yamlCfgBuf)
 	encoder.SetIndent(2)
 	if err := encoder.Encode(ifaceCfg); err != nil {
-		return []byte{}, r, err
+		return []byte{}, err
 	}
 	if err := encoder.Close(); err != nil {
-		return []byte{}, r, err
+		return []byte{}, err
 	}
-	yamlCfg := bytes.Trim(yamlCfgBuf.Bytes(), "\n")
-	return yamlCfg, r, err
+	return bytes.Trim(yamlCfgBuf.Bytes(), "\n"), nil
 }
 
 // Report an ErrFieldElided warning for any non-zero top-level fields in the
@@ -202,7 +329,7 @@ func unmarshal(data []byte, to interface{}) (tree.Node, error) {
 	if err := dec.Decode(to); err != nil {
 		return nil, err
 	}
-	return vyaml.UnmarshalToContext(data)
+	return unmarshalToContext(data)
 }
 
 // marshal is a wrapper for marshaling to json with or without pretty-printing the output
@@ -257,7 +384,7 @@ func TranslateReportPaths(r report.Report, ts translate.TranslationSet) report.R
 		if context.Tag == "yaml" {
 			continue
 		}
-		if t, ok := ts.Set[context.String()]; ok {
+		if t, ok := ts.Get(context); ok {
 			context = t.From
 		} else {
 			// Missing translation.  As a fallback, convert
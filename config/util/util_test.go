@@ -119,3 +119,16 @@ func TestTranslateReportPaths(t *testing.T) {
 	assert.Equal(t, makeReport(false), r, "TranslateReportPaths changed original report")
 	assert.Equal(t, makeReport(true), r2, "TranslateReportPaths returned incorrect report")
 }
+
+func BenchmarkTranslateReportPaths(b *testing.B) {
+	ts := translate.NewTranslationSet("yaml", "json")
+	var r report.Report
+	for i := 0; i < 10000; i++ {
+		ts.AddTranslation(path.New("yaml", "storage", "trees", 0), path.New("json", "storage", "files", i, "mode"))
+		r.AddOnWarn(path.New("json", "storage", "files", i, "mode"), common.ErrDecimalMode)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		TranslateReportPaths(r, ts)
+	}
+}
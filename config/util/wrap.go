@@ -0,0 +1,74 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"encoding/base64"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/clarketm/json"
+)
+
+type wrapperMetadata struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+type wrapperObject struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   wrapperMetadata   `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string]string `json:"data"`
+}
+
+// Wrap embeds config, the output of a translation, in the Kubernetes
+// Secret or ConfigMap described by w, and returns the object as YAML.
+// Secret data is base64-encoded, as Kubernetes requires.
+func Wrap(config []byte, w common.Wrapper) ([]byte, error) {
+	obj := wrapperObject{
+		APIVersion: "v1",
+		Kind:       w.Kind,
+		Metadata: wrapperMetadata{
+			Name:      w.Name,
+			Namespace: w.Namespace,
+			Labels:    w.Labels,
+		},
+	}
+	if w.Name == "" {
+		return nil, common.ErrWrapperName
+	}
+	key := w.Key
+	if key == "" {
+		key = "value"
+	}
+	switch w.Kind {
+	case "Secret":
+		obj.Type = "Opaque"
+		obj.Data = map[string]string{key: base64.StdEncoding.EncodeToString(config)}
+	case "ConfigMap":
+		obj.Data = map[string]string{key: string(config)}
+	default:
+		return nil, common.ErrWrapperKind
+	}
+
+	jsonObj, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return jsonToYAML(jsonObj)
+}
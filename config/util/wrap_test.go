@@ -0,0 +1,94 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		in      string
+		wrapper common.Wrapper
+		out     string
+		err     error
+	}{
+		{
+			in: `{"ignition":{"version":"3.5.0"}}`,
+			wrapper: common.Wrapper{
+				Kind:      "Secret",
+				Name:      "worker-bootstrap",
+				Namespace: "default",
+				Labels: map[string]string{
+					"cluster.x-k8s.io/cluster-name": "c1",
+				},
+			},
+			out: `# Generated by Butane; do not edit
+apiVersion: v1
+data:
+  value: eyJpZ25pdGlvbiI6eyJ2ZXJzaW9uIjoiMy41LjAifX0=
+kind: Secret
+metadata:
+  labels:
+    cluster.x-k8s.io/cluster-name: c1
+  name: worker-bootstrap
+  namespace: default
+type: Opaque`,
+		},
+		{
+			in: "# Generated by Butane; do not edit\nkind: MachineConfig",
+			wrapper: common.Wrapper{
+				Kind: "ConfigMap",
+				Name: "mc",
+				Key:  "mc.yaml",
+			},
+			out: `# Generated by Butane; do not edit
+apiVersion: v1
+data:
+  mc.yaml: |-
+    # Generated by Butane; do not edit
+    kind: MachineConfig
+kind: ConfigMap
+metadata:
+  name: mc`,
+		},
+		{
+			wrapper: common.Wrapper{
+				Kind: "Pod",
+				Name: "a",
+			},
+			err: common.ErrWrapperKind,
+		},
+		{
+			wrapper: common.Wrapper{
+				Kind: "Secret",
+			},
+			err: common.ErrWrapperName,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("wrap %d", i), func(t *testing.T) {
+			out, err := Wrap([]byte(test.in), test.wrapper)
+			assert.Equal(t, test.err, err, "bad error")
+			assert.Equal(t, test.out, string(out), "bad output")
+		})
+	}
+}
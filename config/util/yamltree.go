@@ -0,0 +1,278 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"github.com/coreos/vcontext/tree"
+	"gopkg.in/yaml.v3"
+)
+
+// unmarshalToContext is like vcontext's yaml.UnmarshalToContext, but also
+// records where each node ends, so source maps can report ranges.
+func unmarshalToContext(data []byte) (tree.Node, error) {
+	var ast yaml.Node
+	if err := yaml.Unmarshal(data, &ast); err != nil {
+		return nil, err
+	}
+	src := yamlSource{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		src.lines = append(src.lines, []rune(strings.TrimSuffix(string(line), "\r")))
+	}
+	return src.node(&ast, 0, false), nil
+}
+
+// yamlSource holds the lines of a YAML document, for finding the ends
+// of nodes.  yaml.v3 only reports where nodes start.  Lines and columns
+// are 1-based, and columns count characters.
+type yamlSource struct {
+	lines [][]rune
+}
+
+// node converts n to a context tree node.  indent is the column of the
+// collection containing n, and flow is true inside a flow collection.
+func (s yamlSource) node(n *yaml.Node, indent int, flow bool) tree.Node {
+	m := s.marker(n, indent, flow)
+	flow = flow || n.Style&yaml.FlowStyle != 0
+	switch n.Kind {
+	case 0:
+		// empty
+		return nil
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil
+		}
+		return s.node(n.Content[0], 0, false)
+	case yaml.MappingNode:
+		ret := tree.MapNode{
+			Marker:   m,
+			Children: make(map[string]tree.Node, len(n.Content)/2),
+			Keys:     make(map[string]tree.Leaf, len(n.Content)/2),
+		}
+		// MappingNodes list keys and values like [k, v, k, v...]
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			ret.Keys[key.Value] = tree.Leaf{
+				Marker: s.marker(key, n.Column, flow),
+			}
+			ret.Children[key.Value] = s.node(n.Content[i+1], n.Column, flow)
+		}
+		return ret
+	case yaml.SequenceNode:
+		ret := tree.SliceNode{
+			Marker:   m,
+			Children: make([]tree.Node, 0, len(n.Content)),
+		}
+		for _, child := range n.Content {
+			ret.Children = append(ret.Children, s.node(child, n.Column, flow))
+		}
+		return ret
+	default: // scalars and aliases
+		return tree.Leaf{
+			Marker: m,
+		}
+	}
+}
+
+// marker returns the range of n.  As with JSON context trees, the end
+// is the position of the last character.
+func (s yamlSource) marker(n *yaml.Node, indent int, flow bool) tree.Marker {
+	line, column := s.end(n, indent, flow)
+	if line > n.Line || column > n.Column {
+		column--
+	}
+	return tree.Marker{
+		StartP: &tree.Pos{
+			Line:   int64(n.Line),
+			Column: int64(n.Column),
+		},
+		EndP: &tree.Pos{
+			Line:   int64(line),
+			Column: int64(column),
+		},
+	}
+}
+
+// at returns the character at line and column, or 0 past the end of a
+// line and -1 past the end of the document.
+func (s yamlSource) at(line, column int) rune {
+	if line < 1 || line > len(s.lines) {
+		return -1
+	}
+	l := s.lines[line-1]
+	if column < 1 || column > len(l) {
+		return 0
+	}
+	return l[column-1]
+}
+
+// end returns the position just past the end of n.
+func (s yamlSource) end(n *yaml.Node, indent int, flow bool) (int, int) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) > 0 {
+			return s.end(n.Content[0], 0, false)
+		}
+	case yaml.MappingNode, yaml.SequenceNode:
+		if n.Style&yaml.FlowStyle != 0 {
+			return s.flowEnd(n.Line, n.Column)
+		}
+		line, column := n.Line, n.Column
+		for _, child := range n.Content {
+			if l, c := s.end(child, n.Column, false); l > line || (l == line && c > column) {
+				line, column = l, c
+			}
+		}
+		return line, column
+	case yaml.AliasNode:
+		return n.Line, n.Column + 1 + len([]rune(n.Value))
+	case yaml.ScalarNode:
+		switch {
+		case n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+			return s.blockEnd(n.Line, n.Column, indent)
+		case n.Style&yaml.DoubleQuotedStyle != 0:
+			return s.quotedEnd(n.Line, n.Column, '"')
+		case n.Style&yaml.SingleQuotedStyle != 0:
+			return s.quotedEnd(n.Line, n.Column, '\'')
+		default:
+			return s.plainEnd(n, indent, flow)
+		}
+	}
+	return n.Line, n.Column
+}
+
+// flowEnd finds the end of the flow collection starting at line and
+// column.
+func (s yamlSource) flowEnd(line, column int) (int, int) {
+	depth := 0
+	for {
+		switch c := s.at(line, column); c {
+		case -1:
+			return line, column
+		case 0:
+			line, column = line+1, 1
+			continue
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return line, column + 1
+			}
+		case '"', '\'':
+			line, column = s.quotedEnd(line, column, c)
+			continue
+		case '#':
+			if column == 1 || unicode.IsSpace(s.at(line, column-1)) {
+				line, column = line+1, 1
+				continue
+			}
+		}
+		column++
+	}
+}
+
+// quotedEnd finds the end of the quoted scalar starting at line and
+// column.
+func (s yamlSource) quotedEnd(line, column int, quote rune) (int, int) {
+	column++
+	for {
+		switch c := s.at(line, column); {
+		case c == -1:
+			return line, column
+		case c == 0:
+			line, column = line+1, 1
+			continue
+		case c == '\\' && quote == '"':
+			column++
+		case c == quote:
+			if quote == '\'' && s.at(line, column+1) == '\'' {
+				// escaped quote
+				column++
+			} else {
+				return line, column + 1
+			}
+		}
+		column++
+	}
+}
+
+// blockEnd finds the end of the literal or folded scalar whose indicator
+// is at line and column.  Its content is the following lines indented
+// further than indent.
+func (s yamlSource) blockEnd(line, column, indent int) (int, int) {
+	// the indicator itself, e.g. "|-"
+	endLine, endColumn := line, column
+	for c := s.at(line, column); c > 0 && !unicode.IsSpace(c); c = s.at(line, endColumn) {
+		endColumn++
+	}
+	for l := line + 1; l <= len(s.lines); l++ {
+		text := strings.TrimRightFunc(string(s.lines[l-1]), unicode.IsSpace)
+		if text == "" {
+			continue
+		}
+		if len(text)-len(strings.TrimLeft(text, " ")) < indent {
+			break
+		}
+		endLine, endColumn = l, len([]rune(text))+1
+	}
+	return endLine, endColumn
+}
+
+// plainEnd finds the end of the plain scalar n.  A plain scalar outside
+// a flow collection can continue onto following lines indented further
+// than indent, which fold into spaces.
+func (s yamlSource) plainEnd(n *yaml.Node, indent int, flow bool) (int, int) {
+	want := strings.Join(strings.Fields(n.Value), " ")
+	var got []string
+	endLine, endColumn := n.Line, n.Column
+	for line, column := n.Line, n.Column; line <= len(s.lines); line, column = line+1, 1 {
+		text := s.lines[line-1]
+		if line > n.Line {
+			trimmed := strings.TrimLeft(string(text), " ")
+			if trimmed == "" {
+				continue
+			}
+			if len(text)-len([]rune(trimmed)) < indent {
+				break
+			}
+		}
+		end := column
+		for ; end <= len(text); end++ {
+			c := text[end-1]
+			if c == '#' && end > 1 && unicode.IsSpace(text[end-2]) {
+				break
+			}
+			if flow && strings.ContainsRune(",[]{}", c) {
+				break
+			}
+			if c == ':' && (end == len(text) || unicode.IsSpace(text[end])) {
+				break
+			}
+		}
+		token := strings.TrimRightFunc(string(text[column-1:end-1]), unicode.IsSpace)
+		if strings.TrimSpace(token) != "" {
+			got = append(got, strings.TrimSpace(token))
+			endLine, endColumn = line, column+len([]rune(token))
+		}
+		if flow || end <= len(text) || strings.Join(strings.Fields(strings.Join(got, " ")), " ") == want {
+			break
+		}
+	}
+	return endLine, endColumn
+}
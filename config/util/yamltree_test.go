@@ -0,0 +1,83 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package util
+
+import (
+	"testing"
+
+	"github.com/coreos/vcontext/tree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalToContext(t *testing.T) {
+	in := `variant: fcos  # comment
+version: "1.5.0"
+storage:
+  files:
+    - path: /etc/motd
+      contents:
+        inline: |
+          hello
+
+          world
+      mode: 0644
+    - path: 'it''s'
+      flow: {a: [1, 2], "b": x}
+plain: this is
+  continued
+empty:
+last: ü
+`
+	n, err := unmarshalToContext([]byte(in))
+	if !assert.NoError(t, err) {
+		return
+	}
+	// [start line, start column, end line, end column]
+	tests := map[string][4]int64{
+		"$":                                 {1, 1, 17, 7},
+		"$.variant":                         {1, 10, 1, 13},
+		"$.version":                         {2, 10, 2, 16},
+		"$.storage":                         {4, 3, 13, 31},
+		"$.storage.files":                   {5, 5, 13, 31},
+		"$.storage.files.0":                 {5, 7, 11, 16},
+		"$.storage.files.0.path":            {5, 13, 5, 21},
+		"$.storage.files.0.contents":        {7, 9, 10, 15},
+		"$.storage.files.0.contents.inline": {7, 17, 10, 15},
+		"$.storage.files.0.mode":            {11, 13, 11, 16},
+		"$.storage.files.1.path":            {12, 13, 12, 19},
+		"$.storage.files.1.flow":            {13, 13, 13, 31},
+		"$.storage.files.1.flow.a":          {13, 17, 13, 22},
+		"$.storage.files.1.flow.a.1":        {13, 21, 13, 21},
+		"$.storage.files.1.flow.b":          {13, 30, 13, 30},
+		"$.plain":                           {14, 8, 15, 11},
+		"$.empty":                           {16, 7, 16, 7},
+		"$.last":                            {17, 7, 17, 7},
+	}
+	for p, expected := range tests {
+		node := sourceNode(n, p)
+		line, column := node.Start()
+		endLine, endColumn := node.End()
+		assert.Equal(t, expected, [4]int64{line, column, endLine, endColumn}, p)
+	}
+
+	// keys
+	keys := n.(tree.MapNode).Children["storage"].(tree.MapNode).Children["files"].(tree.SliceNode).Children[0].(tree.MapNode).Keys
+	line, column := keys["contents"].Start()
+	endLine, endColumn := keys["contents"].End()
+	assert.Equal(t, [4]int64{6, 7, 6, 14}, [4]int64{line, column, endLine, endColumn}, "bad key range")
+
+	_, err = unmarshalToContext([]byte("a: [\n"))
+	assert.Error(t, err, "invalid YAML accepted")
+}
@@ -0,0 +1,208 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/coreos/butane/config/common"
+	cutil "github.com/coreos/butane/config/util"
+	"github.com/coreos/butane/translate"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"gopkg.in/yaml.v3"
+)
+
+// VariantDef defines a variant and spec version in terms of a built-in
+// spec, with some Ignition fields or file paths forbidden.
+type VariantDef struct {
+	Variant string `yaml:"variant"`
+	Version string `yaml:"version"`
+	// Description names the variant in generated docs.
+	Description string      `yaml:"description"`
+	Base        VariantBase `yaml:"base"`
+	// Filters maps forbidden fields of the Ignition config, such as
+	// storage.luks, to error messages.
+	Filters        map[string]string `yaml:"filters"`
+	ForbiddenPaths []ForbiddenPath   `yaml:"forbidden_paths"`
+}
+
+// VariantBase is the built-in spec a VariantDef is derived from.
+type VariantBase struct {
+	Variant string `yaml:"variant"`
+	Version string `yaml:"version"`
+}
+
+// ForbiddenPath forbids files, directories, and links at or below Path.
+// Message defaults to common.ErrPathForbidden.
+type ForbiddenPath struct {
+	Path    string `yaml:"path"`
+	Message string `yaml:"message"`
+}
+
+// ParseVariantDef parses a YAML variant definition, rejecting unknown
+// fields.
+func ParseVariantDef(input []byte) (VariantDef, error) {
+	var def VariantDef
+	dec := yaml.NewDecoder(bytes.NewReader(input))
+	dec.KnownFields(true)
+	if err := dec.Decode(&def); err != nil {
+		return VariantDef{}, common.ErrUnmarshal{
+			Detail: err.Error(),
+		}
+	}
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"variant", def.Variant},
+		{"version", def.Version},
+		{"base.variant", def.Base.Variant},
+		{"base.version", def.Base.Version},
+	} {
+		if field.value == "" {
+			return VariantDef{}, common.ErrVariantDefField{
+				Field: field.name,
+			}
+		}
+	}
+	for i, forbidden := range def.ForbiddenPaths {
+		if !strings.HasPrefix(forbidden.Path, "/") {
+			return VariantDef{}, common.ErrVariantDefField{
+				Field: fmt.Sprintf("an absolute path for forbidden_paths.%d", i),
+			}
+		}
+	}
+	return def, nil
+}
+
+// BaseSpec returns a pointer to a new, empty config struct of def's base
+// spec.
+func (def VariantDef) BaseSpec() (cutil.Config, error) {
+	version, err := semver.NewVersion(def.Base.Version)
+	if err != nil {
+		return nil, common.ErrInvalidVersion
+	}
+	return NewSpecConfig(def.Base.Variant, *version)
+}
+
+// FieldFilters returns the FieldFilters of def's base spec, extended with
+// def's filters.
+func (def VariantDef) FieldFilters() (*cutil.FieldFilters, error) {
+	spec, err := def.BaseSpec()
+	if err != nil {
+		return nil, err
+	}
+	method, ok := findMethod(reflect.TypeOf(spec), ignitionMethodRe)
+	if !ok {
+		return nil, common.ErrExtensionOutput{
+			Variant: def.Base.Variant,
+			Version: def.Base.Version,
+		}
+	}
+	filters := cutil.FilterMap{}
+	for filter, message := range def.Filters {
+		filters[filter] = errors.New(message)
+	}
+	extended, err := spec.FieldFilters().Extend(reflect.Zero(method.Type.Out(0)).Interface(), filters)
+	if err != nil {
+		return nil, err
+	}
+	return &extended, nil
+}
+
+// RegisterVariantDef registers a translator for def's variant and version.
+// Configs are parsed and translated as for the base spec, with def's
+// filters and forbidden paths checked in the output.  The base spec must
+// produce Ignition configs.
+func RegisterVariantDef(def VariantDef) error {
+	version, err := semver.NewVersion(def.Version)
+	if err != nil {
+		return common.ErrInvalidVersion
+	}
+	spec, err := def.BaseSpec()
+	if err != nil {
+		return err
+	}
+	specType := reflect.TypeOf(spec)
+	method, ok := findMethod(specType, ignitionMethodRe)
+	if _, isMachineConfig := findMethod(specType, machineConfigMethodRe); !ok || isMachineConfig {
+		return common.ErrExtensionOutput{
+			Variant: def.Base.Variant,
+			Version: def.Base.Version,
+		}
+	}
+	filters, err := def.FieldFilters()
+	if err != nil {
+		return err
+	}
+	forbidden := append([]ForbiddenPath{}, def.ForbiddenPaths...)
+
+	return AddTranslator(def.Variant, version.String(), func(input []byte, options common.TranslateBytesOptions) ([]byte, report.Report, error) {
+		spec := reflect.New(specType.Elem()).Interface().(cutil.Config)
+		contextTree, r, err := cutil.Parse(input, spec)
+		if err != nil {
+			return nil, r, err
+		}
+		translateMethod := boundMethod(spec, method.Name)
+		translateFunc := func(options common.TranslateOptions) (interface{}, translate.TranslationSet, report.Report) {
+			final, ts, r := translateMethod(options)
+			r.Merge(checkForbiddenPaths(final, forbidden))
+			return final, ts, r
+		}
+		return translateParsed(cutil.WithFieldFilters(spec, filters), translateFunc, contextTree, r, options)
+	})
+}
+
+// checkForbiddenPaths reports files, directories, and links in the
+// Ignition config final that are at or below a forbidden path.
+func checkForbiddenPaths(final interface{}, forbidden []ForbiddenPath) (r report.Report) {
+	if len(forbidden) == 0 {
+		return
+	}
+	storage := reflect.ValueOf(final).FieldByName("Storage")
+	for _, kind := range []struct {
+		field string
+		tag   string
+	}{
+		{"Files", "files"},
+		{"Directories", "directories"},
+		{"Links", "links"},
+	} {
+		nodes := storage.FieldByName(kind.field)
+		for i := 0; i < nodes.Len(); i++ {
+			nodePath := nodes.Index(i).FieldByName("Path").String()
+			for _, f := range forbidden {
+				prefix := strings.TrimSuffix(f.Path, "/")
+				if nodePath != prefix && !strings.HasPrefix(nodePath, prefix+"/") {
+					continue
+				}
+				err := common.ErrPathForbidden
+				if f.Message != "" {
+					err = errors.New(f.Message)
+				}
+				r.AddOnError(path.New("json", "storage", kind.tag, i, "path"), err)
+				break
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVariantDef(t *testing.T) {
+	tests := []struct {
+		in  string
+		err error
+	}{
+		{
+			in: `variant: a
+version: 1.0.0
+base:
+  variant: fcos
+  version: 1.7.0
+`,
+		},
+		{
+			in: `variant: a
+version: 1.0.0
+base:
+  variant: fcos
+`,
+			err: common.ErrVariantDefField{Field: "base.version"},
+		},
+		{
+			in: `variant: a
+version: 1.0.0
+base:
+  variant: fcos
+  version: 1.7.0
+forbidden_paths:
+  - path: etc
+`,
+			err: common.ErrVariantDefField{Field: "an absolute path for forbidden_paths.0"},
+		},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("parse %d", i), func(t *testing.T) {
+			_, err := ParseVariantDef([]byte(test.in))
+			assert.Equal(t, test.err, err, "bad error")
+		})
+	}
+
+	_, err := ParseVariantDef([]byte("variant: a\nversion: 1.0.0\nfilter: {}\n"))
+	assert.IsType(t, common.ErrUnmarshal{}, err, "unknown field accepted")
+}
+
+func TestRegisterVariantDef(t *testing.T) {
+	def, err := ParseVariantDef([]byte(`variant: test-appliance
+version: 1.0.0
+base:
+  variant: fcos
+  version: 1.7.0
+filters:
+  storage.luks: no luks
+forbidden_paths:
+  - path: /etc/appliance
+    message: managed by the agent
+  - path: /var/lib/appliance/
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, RegisterVariantDef(def)) {
+		return
+	}
+
+	tests := []struct {
+		in     string
+		out    string
+		report string
+	}{
+		{
+			in: `variant: test-appliance
+version: 1.0.0
+storage:
+  files:
+    - path: /etc/appliance.conf
+`,
+			out: `{"ignition":{"version":"3.6.0"},"storage":{"files":[{"path":"/etc/appliance.conf"}]}}`,
+		},
+		{
+			in: `variant: test-appliance
+version: 1.0.0
+storage:
+  luks:
+    - name: a
+      device: /dev/vda
+`,
+			report: "error at $.storage.luks, line 5 col 5: no luks\n",
+		},
+		{
+			in: `variant: test-appliance
+version: 1.0.0
+storage:
+  directories:
+    - path: /etc/appliance
+  links:
+    - path: /var/lib/appliance/a
+      target: /b
+`,
+			report: "error at $.storage.directories.0.path, line 5 col 13: managed by the agent\n" +
+				"error at $.storage.links.0.path, line 7 col 13: " + common.ErrPathForbidden.Error() + "\n",
+		},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("translate %d", i), func(t *testing.T) {
+			out, r, err := TranslateBytes([]byte(test.in), common.TranslateBytesOptions{})
+			assert.Equal(t, test.report, r.String(), "bad report")
+			if r.IsFatal() {
+				assert.Equal(t, common.ErrInvalidSourceConfig, err, "bad error")
+				return
+			}
+			assert.NoError(t, err, "translation failed")
+			assert.Equal(t, test.out, string(out), "bad output")
+		})
+	}
+
+	// registration errors
+	assert.Equal(t, common.ErrVariantRegistered{Variant: "test-appliance", Version: "1.0.0"}, RegisterVariantDef(def), "duplicate registration")
+	def.Version = "2.0.0"
+	def.Filters = map[string]string{"storage.nonexistent": "x"}
+	assert.Equal(t, errors.New("invalid filter path: storage.nonexistent"), RegisterVariantDef(def), "invalid filter")
+	def.Filters = nil
+	def.Base = VariantBase{Variant: "openshift", Version: "4.22.0"}
+	assert.Equal(t, common.ErrExtensionOutput{Variant: "openshift", Version: "4.22.0"}, RegisterVariantDef(def), "MachineConfig base")
+}
@@ -0,0 +1,432 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+// Package archivefs provides an in-memory fs.FS backed by the contents of
+// a tar or zip archive, for use as a files directory.
+package archivefs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	slashpath "path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxLinkDepth bounds symlink resolution, mirroring the kernel's limit.
+const maxLinkDepth = 40
+
+var (
+	ErrUnknownFormat = errors.New("not a tar or zip archive")
+	ErrTooLarge      = errors.New("archive contents are too large")
+)
+
+type node struct {
+	name     string // base name
+	mode     fs.FileMode
+	modTime  time.Time
+	data     []byte
+	target   string // symlink target
+	children map[string]*node
+}
+
+func (n *node) Name() string               { return n.name }
+func (n *node) Size() int64                { return int64(len(n.data)) }
+func (n *node) Mode() fs.FileMode          { return n.mode }
+func (n *node) ModTime() time.Time         { return n.modTime }
+func (n *node) IsDir() bool                { return n.mode.IsDir() }
+func (n *node) Sys() any                   { return nil }
+func (n *node) Type() fs.FileMode          { return n.mode.Type() }
+func (n *node) Info() (fs.FileInfo, error) { return n, nil }
+
+// FS is a read-only filesystem holding the contents of an archive.  It
+// implements fs.FS, fs.StatFS, fs.ReadDirFS, and fs.ReadFileFS, plus
+// Lstat and ReadLink for access to symlinks.
+type FS struct {
+	root *node
+}
+
+// Open reads the archive at path.  Tar archives may be uncompressed or
+// gzip-compressed.
+func Open(path string) (*FS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return read(f, info.Size(), path, nil)
+}
+
+// FromBytes reads an archive held in memory, in any format supported by
+// Open.  If the archive's files total more than maxSize bytes once
+// decompressed, it returns ErrTooLarge.
+func FromBytes(data []byte, maxSize int64) (*FS, error) {
+	return read(bytes.NewReader(data), int64(len(data)), "archive", &limit{maxSize})
+}
+
+// limit bounds the total size of the file contents read from an archive.
+// A nil limit is unlimited.
+type limit struct {
+	remaining int64
+}
+
+// readAll reads r, charging its size against l.
+func (l *limit) readAll(r io.Reader) ([]byte, error) {
+	if l == nil {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, l.remaining+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > l.remaining {
+		return nil, ErrTooLarge
+	}
+	l.remaining -= int64(len(data))
+	return data, nil
+}
+
+// read detects the format of the archive in r and reads it.  name is used
+// in error messages.
+func read(r io.ReaderAt, size int64, name string, l *limit) (*FS, error) {
+	br := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	magic, _ := br.Peek(262)
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		return fromZip(zr, l)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		defer gr.Close()
+		return fromTar(tar.NewReader(gr), l)
+	case len(magic) >= 262 && string(magic[257:262]) == "ustar":
+		return fromTar(tar.NewReader(br), l)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// FromTar reads a tar stream into a new FS.
+func FromTar(tr *tar.Reader) (*FS, error) {
+	return fromTar(tr, nil)
+}
+
+func fromTar(tr *tar.Reader, l *limit) (*FS, error) {
+	fsys := newFS()
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		n := &node{
+			mode:    hdr.FileInfo().Mode(),
+			modTime: hdr.ModTime,
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+			if hdr.Typeflag == tar.TypeReg {
+				if n.data, err = l.readAll(tr); err != nil {
+					return nil, err
+				}
+			}
+		case tar.TypeDir:
+		case tar.TypeSymlink:
+			n.target = hdr.Linkname
+		case tar.TypeLink:
+			// hard link; copy the earlier entry
+			target, err := fsys.lookup(hdr.Linkname, false, 0)
+			if err != nil {
+				return nil, fmt.Errorf("hard link %q: %w", hdr.Name, err)
+			}
+			n.mode = target.mode
+			n.data = target.data
+		default:
+			// pax/GNU metadata entries are consumed by archive/tar
+			continue
+		}
+		if err := fsys.add(hdr.Name, n); err != nil {
+			return nil, err
+		}
+	}
+	return fsys, nil
+}
+
+// FromZip reads the contents of a zip archive into a new FS.
+func FromZip(zr *zip.Reader) (*FS, error) {
+	return fromZip(zr, nil)
+}
+
+func fromZip(zr *zip.Reader, l *limit) (*FS, error) {
+	fsys := newFS()
+	for _, f := range zr.File {
+		n := &node{
+			mode:    f.Mode(),
+			modTime: f.Modified,
+		}
+		if !n.mode.IsDir() {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			contents, err := l.readAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+			if n.mode&fs.ModeSymlink != 0 {
+				n.target = string(contents)
+			} else {
+				n.data = contents
+			}
+		}
+		if err := fsys.add(f.Name, n); err != nil {
+			return nil, err
+		}
+	}
+	return fsys, nil
+}
+
+func newFS() *FS {
+	return &FS{
+		root: &node{
+			name:     ".",
+			mode:     fs.ModeDir | 0755,
+			children: make(map[string]*node),
+		},
+	}
+}
+
+// add inserts n at name, creating parent directories as needed.
+func (fsys *FS) add(name string, n *node) error {
+	clean := slashpath.Clean(strings.TrimLeft(name, "/"))
+	if clean == "." {
+		if n.mode.IsDir() {
+			fsys.root.mode = n.mode
+			fsys.root.modTime = n.modTime
+		}
+		return nil
+	}
+	if !fs.ValidPath(clean) {
+		return fmt.Errorf("archive entry %q: %w", name, fs.ErrInvalid)
+	}
+	parts := strings.Split(clean, "/")
+	dir := fsys.root
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := dir.children[part]
+		if !ok {
+			child = &node{
+				name:     part,
+				mode:     fs.ModeDir | 0755,
+				children: make(map[string]*node),
+			}
+			dir.children[part] = child
+		} else if !child.IsDir() {
+			return fmt.Errorf("archive entry %q: parent is not a directory", name)
+		}
+		dir = child
+	}
+	n.name = parts[len(parts)-1]
+	if existing, ok := dir.children[n.name]; ok && existing.IsDir() && n.IsDir() {
+		// explicit entry for a directory we've already created
+		existing.mode = n.mode
+		existing.modTime = n.modTime
+		return nil
+	}
+	if n.IsDir() {
+		n.children = make(map[string]*node)
+	}
+	dir.children[n.name] = n
+	return nil
+}
+
+// lookup finds the node at name.  Symlinks in parent directories are
+// always followed; a symlink in the final component is followed only if
+// follow is true.  Symlinks can't resolve outside the archive.
+func (fsys *FS) lookup(name string, follow bool, depth int) (*node, error) {
+	if depth > maxLinkDepth {
+		return nil, errors.New("too many levels of symbolic links")
+	}
+	if !fs.ValidPath(name) {
+		return nil, fs.ErrInvalid
+	}
+	if name == "." {
+		return fsys.root, nil
+	}
+	parts := strings.Split(name, "/")
+	cur := fsys.root
+	for i, part := range parts {
+		if !cur.IsDir() {
+			return nil, fs.ErrNotExist
+		}
+		child, ok := cur.children[part]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		last := i == len(parts)-1
+		if child.mode&fs.ModeSymlink != 0 && (!last || follow) {
+			target := child.target
+			if !slashpath.IsAbs(target) {
+				target = slashpath.Join(strings.Join(parts[:i], "/"), target)
+			}
+			target = slashpath.Clean(strings.TrimLeft(target, "/"))
+			if target == ".." || strings.HasPrefix(target, "../") {
+				return nil, fs.ErrNotExist
+			}
+			if target == "" {
+				target = "."
+			}
+			if !last {
+				target = slashpath.Join(target, strings.Join(parts[i+1:], "/"))
+			}
+			return fsys.lookup(target, follow, depth+1)
+		}
+		cur = child
+	}
+	return cur, nil
+}
+
+func (fsys *FS) find(op, name string, follow bool) (*node, error) {
+	n, err := fsys.lookup(name, follow, 0)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return n, nil
+}
+
+// Open implements fs.FS.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	n, err := fsys.find("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return &file{node: n, path: name, Reader: bytes.NewReader(n.data)}, nil
+}
+
+// Stat implements fs.StatFS.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	return fsys.find("stat", name, true)
+}
+
+// Lstat returns information about name without following a final
+// symlink.
+func (fsys *FS) Lstat(name string) (fs.FileInfo, error) {
+	return fsys.find("lstat", name, false)
+}
+
+// ReadLink returns the target of the symlink at name.
+func (fsys *FS) ReadLink(name string) (string, error) {
+	n, err := fsys.find("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if n.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return n.target, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	n, err := fsys.find("read", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if n.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return bytes.Clone(n.data), nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := fsys.find("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !n.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return n.entries(), nil
+}
+
+func (n *node) entries() []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(n.children))
+	for _, child := range n.children {
+		entries = append(entries, child)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries
+}
+
+type file struct {
+	*bytes.Reader
+	node    *node
+	path    string
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.node, nil }
+func (f *file) Close() error               { return nil }
+
+func (f *file) Read(b []byte) (int, error) {
+	if f.node.IsDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: errors.New("is a directory")}
+	}
+	return f.Reader.Read(b)
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (f *file) ReadDir(count int) ([]fs.DirEntry, error) {
+	if !f.node.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.path, Err: errors.New("not a directory")}
+	}
+	if f.entries == nil {
+		f.entries = f.node.entries()
+	}
+	remaining := f.entries[f.offset:]
+	if count <= 0 {
+		f.offset = len(f.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	count = min(count, len(remaining))
+	f.offset += count
+	return remaining[:count], nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package archivefs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type entry struct {
+	name   string
+	data   string
+	target string
+	dir    bool
+}
+
+var entries = []entry{
+	{name: "dir/", dir: true},
+	{name: "dir/file", data: "file"},
+	{name: "dir/link", target: "file"},
+	{name: "dirlink", target: "dir"},
+	{name: "implicit/child", data: "child"},
+}
+
+func makeTar(t *testing.T, entries []entry) []byte {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := tar.Header{
+			Name: e.name,
+			Mode: 0644,
+			Size: int64(len(e.data)),
+		}
+		switch {
+		case e.dir:
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+		case e.target != "":
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = e.target
+		default:
+			hdr.Typeflag = tar.TypeReg
+		}
+		if err := w.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, entries []entry) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := zip.FileHeader{
+			Name: e.name,
+		}
+		contents := e.data
+		switch {
+		case e.dir:
+			hdr.SetMode(fs.ModeDir | 0755)
+		case e.target != "":
+			hdr.SetMode(fs.ModeSymlink | 0777)
+			contents = e.target
+		default:
+			hdr.SetMode(0644)
+		}
+		f, err := w.CreateHeader(&hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"test.tar", makeTar(t, entries)},
+		{"test.tar.gz", gzipped(t, makeTar(t, entries))},
+		{"test.zip", makeZip(t, entries)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), test.name)
+			if err := os.WriteFile(path, test.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			fsys, err := Open(path)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.NoError(t, fstest.TestFS(fsys, "dir/file", "dir/link", "implicit/child"))
+
+			contents, err := fs.ReadFile(fsys, "dirlink/link")
+			assert.NoError(t, err)
+			assert.Equal(t, "file", string(contents))
+
+			info, err := fsys.Lstat("dir/link")
+			assert.NoError(t, err)
+			assert.Equal(t, fs.ModeSymlink, info.Mode().Type())
+			target, err := fsys.ReadLink("dir/link")
+			assert.NoError(t, err)
+			assert.Equal(t, "file", target)
+
+			_, err = fsys.ReadLink("dir/file")
+			assert.ErrorIs(t, err, fs.ErrInvalid)
+		})
+	}
+}
+
+func TestSymlinkEscape(t *testing.T) {
+	fsys, err := FromTar(tar.NewReader(bytes.NewReader(makeTar(t, []entry{
+		{name: "escape", target: "../../etc/passwd"},
+		{name: "absolute", target: "/dir/file"},
+		{name: "dir/file", data: "file"},
+	}))))
+	if !assert.NoError(t, err) {
+		return
+	}
+	// symlinks can't point outside the archive
+	_, err = fs.ReadFile(fsys, "escape")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	// absolute symlinks are relative to the root of the archive
+	contents, err := fs.ReadFile(fsys, "absolute")
+	assert.NoError(t, err)
+	assert.Equal(t, "file", string(contents))
+}
+
+func TestOpenErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{
+			"not-archive",
+			[]byte("variant: fcos\nversion: 1.5.0\n"),
+			ErrUnknownFormat,
+		},
+		{
+			"traversal.tar",
+			makeTar(t, []entry{{name: "../evil", data: "x"}}),
+			fs.ErrInvalid,
+		},
+		{
+			"traversal.zip",
+			makeZip(t, []entry{{name: "a/../../evil", data: "x"}}),
+			fs.ErrInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), test.name)
+			if err := os.WriteFile(path, test.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Open(path)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestFromBytesLimit(t *testing.T) {
+	big := []entry{
+		{name: "a", data: strings.Repeat("a", 600)},
+		{name: "b", data: strings.Repeat("b", 400)},
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"tar", makeTar(t, big)},
+		{"tar.gz", gzipped(t, makeTar(t, big))},
+		{"zip", makeZip(t, big)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fsys, err := FromBytes(test.data, 1000)
+			if assert.NoError(t, err) {
+				contents, err := fs.ReadFile(fsys, "b")
+				assert.NoError(t, err)
+				assert.Equal(t, big[1].data, string(contents))
+			}
+			// the limit covers all files together
+			_, err = FromBytes(test.data, 999)
+			assert.ErrorIs(t, err, ErrTooLarge)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+	cutil "github.com/coreos/butane/config/util"
+)
+
+// maximum number of source lines to show for a field
+const blameMaxLines = 10
+
+// blameMain implements "butane blame", which reports where in the source
+// config a field of the output config came from.
+func blameMain(args []string) {
+	var (
+		helpFlag bool
+		cf       commonFlags
+	)
+	flags := pflag.NewFlagSet("blame", pflag.ExitOnError)
+	flags.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
+	flags.BoolVarP(&cf.options.Raw, "raw", "r", false, "never wrap in a MachineConfig; force Ignition output")
+	cf.register(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s blame [options] [input-file] FIELD\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Show the part of a config that produced a field of the output config.\n")
+		fmt.Fprintf(flags.Output(), "FIELD is a path such as '$.storage.files.0.path' or a JSON Pointer such\n")
+		fmt.Fprintf(flags.Output(), "as '/storage/files/0/path'.\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if helpFlag {
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		os.Exit(0)
+	}
+	var input, field string
+	switch flags.NArg() {
+	case 1:
+		field = flags.Arg(0)
+	case 2:
+		input = flags.Arg(0)
+		field = flags.Arg(1)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if field != "$" && !strings.HasPrefix(field, "$.") && field != "" && !strings.HasPrefix(field, "/") {
+		fail("field must be a path starting with \"$\" or a JSON Pointer: %s\n", field)
+	}
+
+	cf.finish()
+	cf.options.SourceMap = make(common.SourceMap)
+	dataIn, filename := readInput(input)
+	dataOut, r, err := config.TranslateBytes(dataIn, cf.options)
+	cf.printReport(r, err, filename, dataIn)
+
+	pointer, ok, err := fieldPointer(dataOut, field)
+	if err != nil {
+		fail("failed to read output config: %v\n", err)
+	}
+	if !ok {
+		fail("%s is not in the output config\n", field)
+	}
+	pointerMap, err := cutil.PointerSourceMap(dataOut, cf.options.SourceMap)
+	if err != nil {
+		fail("failed to read output config: %v\n", err)
+	}
+	loc, ok := pointerMap[pointer]
+	if !ok {
+		fail("%s is not in the output config\n", field)
+	}
+	if loc.File == "" {
+		loc.File = filename
+	}
+	fmt.Printf("%s: %s\n", formatRange(loc), loc.Path)
+	if loc.File == filename {
+		printSourceLines(dataIn, loc)
+	}
+}
+
+// fieldPointer converts a field of dataOut, given as a path such as
+// "$.a.0.b" or as a JSON Pointer, to a JSON Pointer.  Paths are resolved
+// against dataOut, since map keys such as MachineConfig labels can
+// contain dots.
+func fieldPointer(dataOut []byte, field string) (string, bool, error) {
+	if strings.HasPrefix(field, "$") {
+		return cutil.PathPointer(dataOut, field)
+	}
+	return field, true, nil
+}
+
+// formatRange returns a source location as FILE:LINE:COLUMN, followed by
+// -LINE:COLUMN if the end is known.
+func formatRange(loc common.SourceLocation) string {
+	if loc.Line == 0 {
+		return fmt.Sprintf("%s:%s", loc.File, loc.Path)
+	}
+	ret := fmt.Sprintf("%s:%d:%d", loc.File, loc.Line, loc.Column)
+	if loc.EndLine != 0 {
+		ret += fmt.Sprintf("-%d:%d", loc.EndLine, loc.EndColumn)
+	}
+	return ret
+}
+
+// printSourceLines prints the lines of source covered by loc.
+func printSourceLines(source []byte, loc common.SourceLocation) {
+	if loc.Line == 0 {
+		return
+	}
+	lines := bytes.Split(source, []byte("\n"))
+	end := max(loc.Line, loc.EndLine)
+	for line := loc.Line; line <= end && line <= int64(len(lines)); line++ {
+		if line-loc.Line == blameMaxLines {
+			fmt.Printf("%6s | ...\n", "")
+			break
+		}
+		fmt.Printf("%6d | %s\n", line, lines[line-1])
+	}
+}
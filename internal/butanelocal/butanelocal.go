@@ -0,0 +1,87 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+// Package butanelocal carries the state needed to translate butane_local
+// child configs.  It travels in TranslateOptions.Context, so callers of
+// the public API can't replace the translator or the list of parent
+// configs used for loop detection.
+package butanelocal
+
+import (
+	"context"
+	"slices"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/coreos/vcontext/report"
+)
+
+// Translator translates a Butane config of any variant and version to an
+// Ignition config.
+type Translator func(input []byte, options common.TranslateBytesOptions) ([]byte, report.Report, error)
+
+type stateKey struct{}
+
+type state struct {
+	translator Translator
+	// keys of the butane_local configs being translated
+	parents []string
+}
+
+func get(options common.TranslateOptions) state {
+	if options.Context == nil {
+		return state{}
+	}
+	s, _ := options.Context.Value(stateKey{}).(state)
+	return s
+}
+
+func set(options common.TranslateOptions, s state) common.TranslateOptions {
+	ctx := options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	options.Context = context.WithValue(ctx, stateKey{}, s)
+	return options
+}
+
+// WithTranslator returns options set to translate child configs with
+// translator, unless they already have a translator.
+func WithTranslator(options common.TranslateOptions, translator Translator) common.TranslateOptions {
+	s := get(options)
+	if s.translator != nil {
+		return options
+	}
+	s.translator = translator
+	return set(options, s)
+}
+
+// GetTranslator returns the translator for child configs, or nil.
+func GetTranslator(options common.TranslateOptions) Translator {
+	return get(options).translator
+}
+
+// WithParent returns options recording that the child config identified
+// by key is being translated.
+func WithParent(options common.TranslateOptions, key string) common.TranslateOptions {
+	s := get(options)
+	s.parents = append(slices.Clone(s.parents), key)
+	return set(options, s)
+}
+
+// Parents returns the keys of the child configs being translated,
+// outermost first.
+func Parents(options common.TranslateOptions) []string {
+	return get(options).parents
+}
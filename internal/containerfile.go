@@ -0,0 +1,95 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/spf13/pflag"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/internal/render"
+)
+
+// containerfileMain implements "butane containerfile", which writes a
+// Containerfile and build context that apply a config at image build
+// time.
+func containerfileMain(args []string) {
+	var (
+		output   string
+		from     string
+		helpFlag bool
+		cf       commonFlags
+	)
+	flags := pflag.NewFlagSet("containerfile", pflag.ExitOnError)
+	flags.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
+	flags.StringVarP(&output, "output", "o", "", "write Containerfile and build context to empty or nonexistent directory `DIR`")
+	flags.StringVar(&from, "from", "quay.io/fedora/fedora-coreos:stable", "base `IMAGE` for the Containerfile")
+	cf.register(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s containerfile -o DIR [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Write a Containerfile and build context for a derived container image\n")
+		fmt.Fprintf(flags.Output(), "that applies a config at build time.  Parts of the config that can't be\n")
+		fmt.Fprintf(flags.Output(), "applied at build time are reported as warnings.\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if helpFlag {
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		os.Exit(0)
+	}
+	var input string
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		input = flags.Arg(0)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if output == "" {
+		fail("-o/--output must be specified\n")
+	}
+
+	cf.finish()
+	dataIn, filename := readInput(input)
+	dataOut, sourceMap, r, err := config.Flatten(dataIn, cf.options)
+	cf.printReport(r, err, filename, dataIn)
+
+	var cfg types.Config
+	if err := json.Unmarshal(dataOut, &cfg); err != nil {
+		fail("failed to parse translated config: %v\n", err)
+	}
+	img, err := render.BuildImage(cfg, from)
+	if err != nil {
+		fail("Error rendering config: %v\n", err)
+	}
+	for _, w := range img.Warnings {
+		fmt.Fprintf(os.Stderr, "%s: warning: %s\n", formatLocation(sourceMap, w.Source, filename), w.Description)
+	}
+	if cf.strict && len(img.Warnings) > 0 {
+		fail("Config produced warnings and --strict was specified\n")
+	}
+	if err := img.Write(output); err != nil {
+		fail("failed to write %s: %v\n", output, err)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/internal/convert"
+	breport "github.com/coreos/butane/internal/report"
+)
+
+// convertMain implements "butane convert", which rewrites a config for
+// another variant or spec version after checking that the target
+// accepts it.
+func convertMain(args []string) {
+	var (
+		to       string
+		output   string
+		options  convert.Options
+		helpFlag bool
+		cf       commonFlags
+	)
+	flags := pflag.NewFlagSet("convert", pflag.ExitOnError)
+	flags.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
+	flags.StringVar(&to, "to", "", "target `VARIANT:VERSION`, such as openshift:4.22.0")
+	flags.StringVar(&options.Name, "name", "", "metadata.name for openshift configs that don't have one")
+	flags.StringVar(&options.Role, "role", "", "role label for openshift configs that don't have one")
+	flags.StringVarP(&output, "output", "o", "", "write to output file instead of stdout")
+	cf.register(flags)
+	// the output is YAML
+	flags.Lookup("pretty").Hidden = true
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s convert --to VARIANT:VERSION [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Rewrite a Butane config for another variant or spec version, reporting\n")
+		fmt.Fprintf(flags.Output(), "every field the target will reject.\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if helpFlag {
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		os.Exit(0)
+	}
+	var input string
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		input = flags.Arg(0)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	variant, version, ok := strings.Cut(to, ":")
+	if !ok || variant == "" || version == "" {
+		fail("--to must be of the form VARIANT:VERSION\n")
+	}
+
+	cf.finish()
+	dataIn, filename := readInput(input)
+	dataOut, r, err := convert.Convert(dataIn, variant, version, options)
+	cf.printReport(r, err, filename, dataIn)
+
+	// check the result against the target before writing anything
+	cf.options.ReportAll = true
+	_, r, err = config.TranslateBytes(dataOut, cf.options)
+	fmt.Fprintf(os.Stderr, "%s", breport.FormatError(r, "<converted>", dataOut, cf.colorize(), cf.rawErrors))
+	if err != nil {
+		if r.IsFatal() {
+			fail("Config is not compatible with %s %s\n", variant, version)
+		}
+		fail("Error translating config: %v\n", err)
+	}
+	if cf.strict && hasWarnings(r) {
+		fail("Config produced warnings and --strict was specified\n")
+	}
+	writeOutput(output, bytes.TrimSuffix(dataOut, []byte("\n")))
+}
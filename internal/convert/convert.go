@@ -0,0 +1,203 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+// Package convert rewrites Butane configs for a different variant or spec
+// version.
+package convert
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	vyaml "github.com/coreos/vcontext/yaml"
+	"gopkg.in/yaml.v3"
+)
+
+// openshiftRoleLabel is the MachineConfig label selecting the pool a
+// config applies to.
+const openshiftRoleLabel = "machineconfiguration.openshift.io/role"
+
+type Options struct {
+	Name string // metadata.name for openshift configs that don't have one
+	Role string // role label for openshift configs that don't have one
+}
+
+// ErrMoved describes a field that was moved to the target variant's
+// equivalent.
+type ErrMoved struct {
+	To string
+}
+
+func (e ErrMoved) Error() string {
+	return fmt.Sprintf("moved to %s", e.To)
+}
+
+// Convert rewrites the Butane config in input for the specified variant
+// and spec version.  Fields are moved into the target variant's own
+// sections where it has an equivalent, such as kernel_arguments.should_exist
+// and openshift.kernel_arguments.  Everything else, including comments,
+// is left alone; translate the result to find out which fields the
+// target doesn't accept.  The report has an Info entry for each moved
+// field, located in input.
+func Convert(input []byte, variant, version string, options Options) ([]byte, report.Report, error) {
+	var r report.Report
+	var doc yaml.Node
+	if err := yaml.Unmarshal(input, &doc); err != nil {
+		return nil, r, common.ErrUnmarshal{
+			Detail: err.Error(),
+		}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, r, common.ErrUnmarshal{
+			Detail: "config is not a mapping",
+		}
+	}
+	root := doc.Content[0]
+	source := ""
+	if node := lookup(root, "variant"); node != nil {
+		source = node.Value
+	}
+	if source == "" {
+		return nil, r, common.ErrNoVariant
+	}
+
+	set(root, "variant", variant)
+	set(root, "version", version)
+	switch {
+	case source != "openshift" && variant == "openshift":
+		move(&r, root, "kernel_arguments", "should_exist", "openshift", "kernel_arguments")
+	case source == "openshift" && variant != "openshift":
+		move(&r, root, "openshift", "kernel_arguments", "kernel_arguments", "should_exist")
+	}
+	if variant == "openshift" {
+		if options.Name != "" {
+			if metadata := mapping(root, "metadata"); metadata != nil && lookup(metadata, "name") == nil {
+				set(metadata, "name", options.Name)
+			}
+		}
+		if options.Role != "" {
+			if labels := mapping(mapping(root, "metadata"), "labels"); labels != nil && lookup(labels, openshiftRoleLabel) == nil {
+				set(labels, openshiftRoleLabel, options.Role)
+			}
+		}
+	}
+
+	if contextTree, err := vyaml.UnmarshalToContext(input); err == nil {
+		r.Correlate(contextTree)
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, r, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, r, err
+	}
+	return buf.Bytes(), r, nil
+}
+
+// move appends the items of the sequence fromSection.fromKey to the
+// sequence toSection.toKey, creating it if needed, and removes the source
+// field and its section if that's now empty.  Nothing is moved if either
+// field has an unexpected type.
+func move(r *report.Report, root *yaml.Node, fromSection, fromKey, toSection, toKey string) {
+	parent := lookup(root, fromSection)
+	src := lookup(parent, fromKey)
+	if src == nil || src.Kind != yaml.SequenceNode {
+		return
+	}
+	dest := mapping(root, toSection)
+	if dest == nil {
+		return
+	}
+	dst := lookup(dest, toKey)
+	if dst == nil {
+		dst = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		dest.Content = append(dest.Content, scalar(toKey), dst)
+	} else if dst.Kind != yaml.SequenceNode {
+		return
+	}
+	dst.Content = append(dst.Content, src.Content...)
+	r.AddOnInfo(path.New("yaml", fromSection, fromKey), ErrMoved{To: toSection + "." + toKey})
+
+	remove(parent, fromKey)
+	if len(parent.Content) == 0 {
+		remove(root, fromSection)
+	}
+}
+
+// lookup returns the value of key in the mapping m, or nil if m isn't
+// a mapping or doesn't have the key.
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mapping returns the mapping at key in m, adding an empty one if the key
+// is missing.  It returns nil if m or the existing value isn't a mapping.
+func mapping(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	if node := lookup(m, key); node != nil {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		return node
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	m.Content = append(m.Content, scalar(key), node)
+	return node
+}
+
+// set sets key in the mapping m to the string value, keeping the key's
+// position if it already exists.
+func set(m *yaml.Node, key, value string) {
+	if node := lookup(m, key); node != nil {
+		*node = yaml.Node{
+			Kind:        yaml.ScalarNode,
+			Tag:         "!!str",
+			Value:       value,
+			LineComment: node.LineComment,
+		}
+		return
+	}
+	m.Content = append(m.Content, scalar(key), scalar(value))
+}
+
+// remove deletes key from the mapping m.
+func remove(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
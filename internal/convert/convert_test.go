@@ -0,0 +1,196 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package convert
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		in      string
+		variant string
+		version string
+		options Options
+		out     string
+		report  string
+	}{
+		// fcos to openshift
+		{
+			in: `# worker config
+variant: fcos
+version: 1.6.0
+kernel_arguments:
+  should_exist:
+    - mitigations=off # fast
+storage:
+  files:
+    - path: /etc/a
+`,
+			variant: "openshift",
+			version: "4.22.0",
+			options: Options{Name: "99-worker", Role: "worker"},
+			out: `# worker config
+variant: openshift
+version: 4.22.0
+storage:
+  files:
+    - path: /etc/a
+openshift:
+  kernel_arguments:
+    - mitigations=off # fast
+metadata:
+  name: 99-worker
+  labels:
+    machineconfiguration.openshift.io/role: worker
+`,
+			report: "info at $.kernel_arguments.should_exist, line 6 col 5: " + ErrMoved{To: "openshift.kernel_arguments"}.Error() + "\n",
+		},
+		// openshift kernel arguments are appended to existing ones,
+		// and existing metadata is kept
+		{
+			in: `variant: openshift
+version: 4.21.0
+metadata:
+  name: master-a
+kernel_arguments:
+  should_exist: [a]
+  should_not_exist: [b]
+openshift:
+  kernel_arguments: [c]
+`,
+			variant: "openshift",
+			version: "4.22.0",
+			options: Options{Name: "99-worker", Role: "worker"},
+			out: `variant: openshift
+version: 4.22.0
+metadata:
+  name: master-a
+  labels:
+    machineconfiguration.openshift.io/role: worker
+kernel_arguments:
+  should_exist: [a]
+  should_not_exist: [b]
+openshift:
+  kernel_arguments: [c]
+`,
+		},
+		// openshift to fcos
+		{
+			in: `variant: openshift
+version: 4.22.0
+metadata:
+  name: master-a
+openshift:
+  kernel_arguments:
+    - a
+`,
+			variant: "fcos",
+			version: "1.6.0",
+			out: `variant: fcos
+version: 1.6.0
+metadata:
+  name: master-a
+kernel_arguments:
+  should_exist:
+    - a
+`,
+			report: "info at $.openshift.kernel_arguments, line 7 col 5: " + ErrMoved{To: "kernel_arguments.should_exist"}.Error() + "\n",
+		},
+		// r4e to fiot
+		{
+			in: `variant: r4e
+version: 1.1.0
+storage:
+  files:
+    - path: /etc/a
+`,
+			variant: "fiot",
+			version: "1.0.0",
+			out: `variant: fiot
+version: 1.0.0
+storage:
+  files:
+    - path: /etc/a
+`,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("convert %d", i), func(t *testing.T) {
+			out, r, err := Convert([]byte(test.in), test.variant, test.version, test.options)
+			assert.NoError(t, err)
+			assert.Equal(t, test.out, string(out), "bad output")
+			assert.Equal(t, test.report, r.String(), "bad report")
+		})
+	}
+
+	_, _, err := Convert([]byte("storage: {}\n"), "fcos", "1.6.0", Options{})
+	assert.Equal(t, common.ErrNoVariant, err, "bad error for missing variant")
+}
+
+// TestConvertReport checks that translating a converted config with
+// ReportAll lists every feature the target rejects, not just the first
+// class of problem, but never translates an invalid config.
+func TestConvertReport(t *testing.T) {
+	in := `variant: fcos
+version: 1.6.0
+passwd:
+  users:
+    - name: core
+    - name: admin
+storage:
+  links:
+    - path: /etc/a
+      target: /etc/b
+`
+	out, _, err := Convert([]byte(in), "openshift", "4.22.0", Options{Name: "a", Role: "worker"})
+	assert.NoError(t, err)
+	_, r, err := config.TranslateBytes(out, common.TranslateBytesOptions{
+		TranslateOptions: common.TranslateOptions{
+			ReportAll: true,
+		},
+	})
+	assert.Equal(t, common.ErrInvalidSourceConfig, err, "bad error")
+	assert.Equal(t, "error at $.passwd.users.1.name, line 6 col 13: "+common.ErrUserNameSupport.Error()+"\n"+
+		"error at $.storage.links, line 9 col 5: "+common.ErrLinkSupport.Error()+"\n", r.String(), "bad report")
+
+	// invalid configs aren't translated, even with ReportAll, since
+	// translators assume valid input
+	in = `variant: fcos
+version: 1.5.0
+boot_device:
+  layout: bogus
+  mirror:
+    devices:
+      - /dev/sda
+      - /dev/sdb
+`
+	out, _, err = Convert([]byte(in), "openshift", "4.22.0", Options{Name: "a", Role: "worker"})
+	assert.NoError(t, err)
+	_, r, err = config.TranslateBytes(out, common.TranslateBytesOptions{
+		TranslateOptions: common.TranslateOptions{
+			ReportAll: true,
+		},
+	})
+	assert.Equal(t, common.ErrInvalidSourceConfig, err, "bad error")
+	assert.Equal(t, "error at $.boot_device.layout, line 4 col 11: "+common.ErrUnknownBootDeviceLayout.Error()+"\n", r.String(), "bad report")
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package convert
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/coreos/vcontext/report"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrNoVersionField = errors.New("couldn't find the version field in the config")
+)
+
+// Rejection lists the report entries that keep a spec version from
+// accepting a config.
+type Rejection struct {
+	Version semver.Version
+	Entries []report.Entry
+}
+
+// MinVersionResult is the lowest spec version of Variant that accepts a
+// config, and the reasons each lower version doesn't.
+type MinVersionResult struct {
+	Variant  string
+	Version  semver.Version
+	Rejected []Rejection // in ascending version order
+}
+
+// MinVersion translates the config in input with each stable spec version
+// of its variant, up to its current version, and returns the lowest one
+// that accepts it.  A version accepts the config if translation succeeds
+// without any warnings the current version doesn't also produce, such as
+// unused keys for fields the version doesn't support.  The returned report
+// is from translating the config with its current version, which must
+// succeed.
+func MinVersion(input []byte, options common.TranslateBytesOptions) (MinVersionResult, report.Report, error) {
+	var result MinVersionResult
+	var fields struct {
+		Variant string `yaml:"variant"`
+		Version string `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(input, &fields); err != nil {
+		return result, report.Report{}, common.ErrUnmarshal{
+			Detail: err.Error(),
+		}
+	}
+	current, err := semver.NewVersion(fields.Version)
+	if err != nil {
+		return result, report.Report{}, common.ErrInvalidVersion
+	}
+	result.Variant = fields.Variant
+	result.Version = *current
+
+	options.ReportAll = true
+	_, baseline, err := config.TranslateBytes(input, options)
+	if err != nil {
+		return result, baseline, err
+	}
+	known := make(map[string]struct{})
+	for _, e := range baseline.Entries {
+		known[entryKey(e)] = struct{}{}
+	}
+
+	for _, version := range config.Versions(fields.Variant) {
+		if !version.LessThan(*current) {
+			break
+		}
+		if version.PreRelease != "" {
+			// experimental versions aren't a useful minimum
+			continue
+		}
+		candidate, err := SetVersion(input, version.String())
+		if err != nil {
+			return result, baseline, err
+		}
+		// candidates that fail validation aren't translated, and
+		// their validation errors are the reasons they're rejected
+		_, r, err := config.TranslateBytes(candidate, options)
+		var blockers []report.Entry
+		for _, e := range r.Entries {
+			if _, ok := known[entryKey(e)]; !ok && e.Kind != report.Info {
+				blockers = append(blockers, e)
+			}
+		}
+		if err != nil && len(blockers) == 0 {
+			// the version can't translate the config, for a reason
+			// the report doesn't show
+			blockers = append(blockers, report.Entry{
+				Kind:    report.Error,
+				Message: err.Error(),
+			})
+		}
+		if len(blockers) == 0 {
+			result.Version = version
+			break
+		}
+		result.Rejected = append(result.Rejected, Rejection{
+			Version: version,
+			Entries: blockers,
+		})
+	}
+	return result, baseline, nil
+}
+
+// entryKey identifies a report entry independently of its position in
+// the report.
+func entryKey(e report.Entry) string {
+	return e.Kind.String() + " " + e.Context.String() + " " + e.Message
+}
+
+// SetVersion returns input with the value of its top-level version field
+// replaced, leaving the rest of the config byte-for-byte unchanged.
+func SetVersion(input []byte, version string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(input, &doc); err != nil {
+		return nil, common.ErrUnmarshal{
+			Detail: err.Error(),
+		}
+	}
+	var node *yaml.Node
+	if len(doc.Content) > 0 {
+		node = lookup(doc.Content[0], "version")
+	}
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return nil, ErrNoVersionField
+	}
+	quote := ""
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		quote = `"`
+	case yaml.SingleQuotedStyle:
+		quote = "'"
+	}
+	old := []byte(quote + node.Value + quote)
+
+	// find the start of the value from its line and column
+	offset := 0
+	for line := 1; line < node.Line; line++ {
+		i := bytes.IndexByte(input[offset:], '\n')
+		if i < 0 {
+			return nil, ErrNoVersionField
+		}
+		offset += i + 1
+	}
+	offset += node.Column - 1
+	if offset > len(input) || !bytes.HasPrefix(input[offset:], old) {
+		return nil, ErrNoVersionField
+	}
+	var out bytes.Buffer
+	out.Write(input[:offset])
+	out.WriteString(quote + version + quote)
+	out.Write(input[offset+len(old):])
+	return out.Bytes(), nil
+}
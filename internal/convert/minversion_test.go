@@ -0,0 +1,103 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/coreos/butane/config/common"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinVersion(t *testing.T) {
+	in := `variant: fcos
+version: 1.6.0
+storage:
+  luks:
+    - name: data
+      device: /dev/vdb
+  files:
+    - path: /etc/a
+      mode: 400
+`
+	result, r, err := MinVersion([]byte(in), common.TranslateBytesOptions{})
+	assert.NoError(t, err)
+	// the mode warning is produced by every version
+	assert.Equal(t, "warning at $.storage.files.0.mode, line 9 col 13: "+common.ErrDecimalMode.Error()+"\n", r.String(), "bad report")
+	assert.Equal(t, "fcos", result.Variant)
+	assert.Equal(t, *semver.New("1.2.0"), result.Version, "bad minimum version")
+	if assert.Len(t, result.Rejected, 2) {
+		for i, version := range []string{"1.0.0", "1.1.0"} {
+			assert.Equal(t, *semver.New(version), result.Rejected[i].Version)
+			if assert.Len(t, result.Rejected[i].Entries, 1) {
+				assert.Equal(t, "warning at $.storage.luks, line 4 col 3: unused key luks", result.Rejected[i].Entries[0].String())
+			}
+		}
+	}
+
+	// the current version is the minimum if nothing lower works
+	result, _, err = MinVersion([]byte("variant: fcos\nversion: 1.7.0\nstorage:\n  files:\n    - path: /a\n      mode: 04755\n"), common.TranslateBytesOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, *semver.New("1.7.0"), result.Version, "bad minimum version")
+	assert.Len(t, result.Rejected, 7)
+
+	_, _, err = MinVersion([]byte("variant: fcos\nversion: 1.6.0\nstorage: 5\n"), common.TranslateBytesOptions{})
+	assert.Error(t, err, "invalid config accepted")
+
+	// versions that fail validation are rejected without being
+	// translated
+	in = `variant: fcos
+version: 1.6.0
+boot_device:
+  layout: s390x-virt
+  luks:
+    tpm2: true
+`
+	result, _, err = MinVersion([]byte(in), common.TranslateBytesOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, *semver.New("1.6.0"), result.Version, "bad minimum version")
+	if assert.Len(t, result.Rejected, 6) {
+		for _, rejection := range result.Rejected[3:] {
+			if assert.Len(t, rejection.Entries, 1, "version %s", rejection.Version) {
+				assert.Equal(t, "error at $.boot_device.layout, line 4 col 11: "+common.ErrUnknownBootDeviceLayoutLegacy.Error(), rejection.Entries[0].String())
+			}
+		}
+	}
+
+	// an invalid layout at the config's own version is an error
+	_, r, err = MinVersion([]byte("variant: fcos\nversion: 1.5.0\nboot_device:\n  layout: bogus\n  mirror:\n    devices: [/dev/sda, /dev/sdb]\n"), common.TranslateBytesOptions{})
+	assert.Equal(t, common.ErrInvalidSourceConfig, err, "bad error")
+	assert.Equal(t, "error at $.boot_device.layout, line 4 col 11: "+common.ErrUnknownBootDeviceLayoutLegacy.Error()+"\n", r.String(), "bad report")
+}
+
+func TestSetVersion(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"variant: fcos\nversion: 1.6.0\n", "variant: fcos\nversion: 1.2.0\n"},
+		{"variant: fcos\nversion:   '1.6.0'  # comment\n", "variant: fcos\nversion:   '1.2.0'  # comment\n"},
+		{"# header\nvariant: fcos\n\nversion: \"1.6.0\"\nstorage: {}\n", "# header\nvariant: fcos\n\nversion: \"1.2.0\"\nstorage: {}\n"},
+	}
+	for _, test := range tests {
+		out, err := SetVersion([]byte(test.in), "1.2.0")
+		assert.NoError(t, err)
+		assert.Equal(t, test.out, string(out), "bad output")
+	}
+	_, err := SetVersion([]byte("variant: fcos\n"), "1.2.0")
+	assert.Equal(t, ErrNoVersionField, err, "bad error for missing version")
+}
@@ -22,6 +22,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"text/template"
 
@@ -77,14 +78,36 @@ var (
 
 	//go:embed butane.yaml
 	butaneDocs []byte
+
+	// variant definitions being documented, by variant and version
+	variantDefs = map[string]config.VariantDef{}
+
+	// rewrites the variant and version fields of a variant definition's
+	// base spec
+	variantDefDocs = template.Must(template.New("variant-def").Parse(`root:
+  children:
+    - name: variant
+      transforms:
+        - regex: "Must be ` + "`[^`]*`" + `"
+          replacement: "Must be ` + "`{{.Variant}}`" + `"
+          if:
+            - variant: {{.Variant}}
+    - name: version
+      transforms:
+        - regex: "%{{.Base.Variant}}_version%"
+          replacement: "%{{.Variant}}_version%"
+          if:
+            - variant: {{.Variant}}
+`))
 )
 
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <directory>\n", os.Args[0])
+	if len(os.Args) < 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s <directory> [variant-def-file...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "With variant definition files, only document the defined variants.\n")
 		os.Exit(1)
 	}
-	if err := generate(os.Args[1]); err != nil {
+	if err := generate(os.Args[1], os.Args[2:]); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
@@ -101,7 +124,7 @@ type version struct {
 	config  buUtil.Config
 }
 
-func generate(dir string) error {
+func generate(dir string, defFiles []string) error {
 	configs := []variant{
 		// alphabetical order
 		{
@@ -174,6 +197,14 @@ func generate(dir string) error {
 		},
 	}
 
+	if len(defFiles) > 0 {
+		var err error
+		configs, err = loadVariantDefs(defFiles)
+		if err != nil {
+			return err
+		}
+	}
+
 	// parse and snakify Ignition components
 	comps, err := doc.IgnitionComponents()
 	if err != nil {
@@ -192,6 +223,19 @@ func generate(dir string) error {
 	if err := comps.Merge(butaneComps); err != nil {
 		return err
 	}
+	for _, def := range variantDefs {
+		var buf bytes.Buffer
+		if err := variantDefDocs.Execute(&buf, def); err != nil {
+			return err
+		}
+		defComps, err := doc.ParseComponents(&buf)
+		if err != nil {
+			return err
+		}
+		if err := comps.Merge(defComps); err != nil {
+			return err
+		}
+	}
 
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
@@ -263,8 +307,15 @@ func generateOne(dir string, comps doc.Components, variant variant, version vers
 		doc.IGNITION_VARIANT: ignVer,
 		variant.variant:      ver,
 	}
+	filters := version.config.FieldFilters()
+	if def, ok := variantDefs[variant.variant+"+"+version.version]; ok {
+		// document fields as for the base spec
+		vers[def.Base.Variant] = *semver.New(def.Base.Version)
+		if filters, err = def.FieldFilters(); err != nil {
+			return err
+		}
+	}
 	ignore := func(path []string) bool {
-		filters := version.config.FieldFilters()
 		if filters == nil {
 			return false
 		}
@@ -284,6 +335,42 @@ func generateOne(dir string, comps doc.Components, variant variant, version vers
 	return nil
 }
 
+// loadVariantDefs registers the variants defined in the specified files
+// and returns them for documenting.
+func loadVariantDefs(files []string) ([]variant, error) {
+	var ret []variant
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		def, err := config.ParseVariantDef(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", file, err)
+		}
+		if err := config.RegisterVariantDef(def); err != nil {
+			return nil, fmt.Errorf("registering %s: %w", file, err)
+		}
+		spec, err := def.BaseSpec()
+		if err != nil {
+			return nil, err
+		}
+		desc := def.Description
+		if desc == "" {
+			desc = def.Variant
+		}
+		variantDefs[def.Variant+"+"+def.Version] = def
+		ret = append(ret, variant{
+			desc,
+			def.Variant,
+			[]version{
+				{def.Version, reflect.ValueOf(spec).Elem().Interface().(buUtil.Config)},
+			},
+		})
+	}
+	return ret, nil
+}
+
 func getIgnitionVersion(variant string, version semver.Version) (semver.Version, error) {
 	// generate an empty Butane config with this variant/version
 	// use a random OpenShift spec as a representative structure
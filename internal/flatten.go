@@ -0,0 +1,89 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/coreos/butane/config"
+)
+
+// flattenMain implements "butane flatten", which outputs the effective
+// Ignition config after merging in child configs.
+func flattenMain(args []string) {
+	var (
+		output   string
+		mapFile  string
+		helpFlag bool
+		cf       commonFlags
+	)
+	flags := pflag.NewFlagSet("flatten", pflag.ExitOnError)
+	flags.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
+	flags.StringVarP(&output, "output", "o", "", "write to output file instead of stdout")
+	flags.StringVarP(&mapFile, "map", "m", "", "write the source location of each field in the output to `FILE` as JSON")
+	cf.register(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s flatten [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Translate a config and merge in its inline and local child configs.\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if helpFlag {
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		os.Exit(0)
+	}
+	var input string
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		input = flags.Arg(0)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cf.finish()
+	dataIn, filename := readInput(input)
+	dataOut, sourceMap, r, err := config.Flatten(dataIn, cf.options)
+	cf.printReport(r, err, filename, dataIn)
+
+	if mapFile != "" {
+		for key, loc := range sourceMap {
+			if loc.File == "" {
+				loc.File = filename
+				sourceMap[key] = loc
+			}
+		}
+		var mapOut []byte
+		if cf.options.Pretty {
+			mapOut, err = json.MarshalIndent(sourceMap, "", "  ")
+		} else {
+			mapOut, err = json.Marshal(sourceMap)
+		}
+		if err != nil {
+			fail("failed to marshal source map: %v\n", err)
+		}
+		writeOutput(mapFile, mapOut)
+	}
+	writeOutput(output, dataOut)
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/coreos/vcontext/report"
+	"github.com/spf13/pflag"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+	"github.com/coreos/butane/internal/importer"
+	breport "github.com/coreos/butane/internal/report"
+)
+
+type importFunc func(input []byte, variant, version string) ([]byte, report.Report, error)
+
+// importers maps --from formats to their importers and default variants.
+var importers = map[string]struct {
+	variant string
+	fn      importFunc
+}{
+	"clc":        {"flatcar", importer.FromCLC},
+	"cloud-init": {"fcos", importer.FromCloudInit},
+}
+
+// defaultImportVersion returns the spec version generated for a variant
+// when --version isn't specified: the latest stable one.
+func defaultImportVersion(variant string) (string, bool) {
+	var version string
+	for _, v := range config.RegisteredVersions() {
+		if v.Variant == variant && !v.Experimental {
+			version = v.Version.String()
+		}
+	}
+	return version, version != ""
+}
+
+// importMain implements "butane import", which converts configs for
+// other provisioning tools to Butane configs.
+func importMain(args []string) {
+	var (
+		from     string
+		variant  string
+		version  string
+		output   string
+		helpFlag bool
+		cf       commonFlags
+	)
+	var formats []string
+	for name := range importers {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	flags := pflag.NewFlagSet("import", pflag.ExitOnError)
+	flags.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
+	flags.StringVar(&from, "from", "", fmt.Sprintf("input `FORMAT`: %s", strings.Join(formats, ", ")))
+	flags.StringVar(&variant, "variant", "", "Butane variant to generate (default depends on --from)")
+	flags.StringVar(&version, "version", "", "Butane spec version to generate (default latest stable)")
+	flags.StringVarP(&output, "output", "o", "", "write to output file instead of stdout")
+	flags.BoolVarP(&cf.strict, "strict", "s", false, "fail on any warning")
+	flags.BoolVar(&cf.rawErrors, "raw-errors", false, "show raw errors, rather than pretty printing them")
+	flags.StringVar(&cf.colorFlag, "color", "auto", `control color output: "auto", "always", or "never"`)
+	flags.Lookup("color").NoOptDefVal = "always"
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import --from FORMAT [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Convert a config for another provisioning tool to a Butane config.\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if helpFlag {
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		os.Exit(0)
+	}
+	var input string
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		input = flags.Arg(0)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	imp, ok := importers[from]
+	if !ok {
+		fail("--from must be one of: %s\n", strings.Join(formats, ", "))
+	}
+	if variant == "" {
+		variant = imp.variant
+	}
+	if version == "" {
+		if version, ok = defaultImportVersion(variant); !ok {
+			fail("--version must be specified for variant %s\n", variant)
+		}
+	}
+
+	dataIn, filename := readInput(input)
+	dataOut, r, err := imp.fn(dataIn, variant, version)
+	cf.printReport(r, err, filename, dataIn)
+
+	// make sure the result is valid for the requested spec version
+	_, r, err = config.TranslateBytes(dataOut, common.TranslateBytesOptions{})
+	if err != nil || hasWarnings(r) {
+		fmt.Fprintf(os.Stderr, "%s", breport.FormatError(r, "<generated>", dataOut, cf.colorize(), cf.rawErrors))
+		fail("Generated config is invalid for %s %s\n", variant, version)
+	}
+	writeOutput(output, bytes.TrimSuffix(dataOut, []byte("\n")))
+}
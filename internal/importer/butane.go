@@ -0,0 +1,284 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+// Package importer converts configs for other provisioning tools to
+// Butane configs.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	vyaml "github.com/coreos/vcontext/yaml"
+	"gopkg.in/yaml.v3"
+)
+
+// The Butane config fields produced by importers.  Field order matches
+// the order of the generated YAML.
+
+type Config struct {
+	Variant  string   `yaml:"variant"`
+	Version  string   `yaml:"version"`
+	Ignition Ignition `yaml:"ignition,omitempty"`
+	Passwd   Passwd   `yaml:"passwd,omitempty"`
+	Storage  Storage  `yaml:"storage,omitempty"`
+	Systemd  Systemd  `yaml:"systemd,omitempty"`
+}
+
+type Ignition struct {
+	Config   IgnitionConfig `yaml:"config,omitempty"`
+	Timeouts Timeouts       `yaml:"timeouts,omitempty"`
+	Security Security       `yaml:"security,omitempty"`
+}
+
+type IgnitionConfig struct {
+	Merge   []Resource `yaml:"merge,omitempty"`
+	Replace *Resource  `yaml:"replace,omitempty"`
+}
+
+type Timeouts struct {
+	HTTPResponseHeaders *int `yaml:"http_response_headers,omitempty"`
+	HTTPTotal           *int `yaml:"http_total,omitempty"`
+}
+
+type Security struct {
+	TLS TLS `yaml:"tls,omitempty"`
+}
+
+type TLS struct {
+	CertificateAuthorities []Resource `yaml:"certificate_authorities,omitempty"`
+}
+
+type Passwd struct {
+	Users  []User  `yaml:"users,omitempty"`
+	Groups []Group `yaml:"groups,omitempty"`
+}
+
+type User struct {
+	Name              string   `yaml:"name"`
+	UID               *int     `yaml:"uid,omitempty"`
+	Gecos             string   `yaml:"gecos,omitempty"`
+	HomeDir           string   `yaml:"home_dir,omitempty"`
+	NoCreateHome      bool     `yaml:"no_create_home,omitempty"`
+	PrimaryGroup      string   `yaml:"primary_group,omitempty"`
+	Groups            []string `yaml:"groups,omitempty"`
+	NoUserGroup       bool     `yaml:"no_user_group,omitempty"`
+	Shell             string   `yaml:"shell,omitempty"`
+	System            bool     `yaml:"system,omitempty"`
+	PasswordHash      string   `yaml:"password_hash,omitempty"`
+	SSHAuthorizedKeys []string `yaml:"ssh_authorized_keys,omitempty"`
+}
+
+type Group struct {
+	Name         string `yaml:"name"`
+	Gid          *int   `yaml:"gid,omitempty"`
+	System       bool   `yaml:"system,omitempty"`
+	PasswordHash string `yaml:"password_hash,omitempty"`
+}
+
+type Storage struct {
+	Disks       []Disk       `yaml:"disks,omitempty"`
+	Raid        []Raid       `yaml:"raid,omitempty"`
+	Filesystems []Filesystem `yaml:"filesystems,omitempty"`
+	Directories []Directory  `yaml:"directories,omitempty"`
+	Files       []File       `yaml:"files,omitempty"`
+	Links       []Link       `yaml:"links,omitempty"`
+}
+
+type Disk struct {
+	Device     string      `yaml:"device"`
+	WipeTable  bool        `yaml:"wipe_table,omitempty"`
+	Partitions []Partition `yaml:"partitions,omitempty"`
+}
+
+type Partition struct {
+	Number   int    `yaml:"number,omitempty"`
+	Label    string `yaml:"label,omitempty"`
+	SizeMiB  *int   `yaml:"size_mib,omitempty"`
+	StartMiB *int   `yaml:"start_mib,omitempty"`
+	TypeGUID string `yaml:"type_guid,omitempty"`
+	GUID     string `yaml:"guid,omitempty"`
+}
+
+type Raid struct {
+	Name    string   `yaml:"name"`
+	Level   string   `yaml:"level"`
+	Devices []string `yaml:"devices"`
+	Spares  int      `yaml:"spares,omitempty"`
+	Options []string `yaml:"options,omitempty"`
+}
+
+type Filesystem struct {
+	Device         string   `yaml:"device"`
+	Path           string   `yaml:"path,omitempty"`
+	Format         string   `yaml:"format,omitempty"`
+	Label          string   `yaml:"label,omitempty"`
+	UUID           string   `yaml:"uuid,omitempty"`
+	WipeFilesystem bool     `yaml:"wipe_filesystem,omitempty"`
+	Options        []string `yaml:"options,omitempty"`
+	MountOptions   []string `yaml:"mount_options,omitempty"`
+	WithMountUnit  bool     `yaml:"with_mount_unit,omitempty"`
+}
+
+type Directory struct {
+	Path  string     `yaml:"path"`
+	Mode  *Mode      `yaml:"mode,omitempty"`
+	User  *NodeOwner `yaml:"user,omitempty"`
+	Group *NodeOwner `yaml:"group,omitempty"`
+}
+
+type File struct {
+	Path      string     `yaml:"path"`
+	Overwrite bool       `yaml:"overwrite,omitempty"`
+	Mode      *Mode      `yaml:"mode,omitempty"`
+	User      *NodeOwner `yaml:"user,omitempty"`
+	Group     *NodeOwner `yaml:"group,omitempty"`
+	Contents  *Resource  `yaml:"contents,omitempty"`
+	Append    []Resource `yaml:"append,omitempty"`
+}
+
+type Link struct {
+	Path      string     `yaml:"path"`
+	Target    string     `yaml:"target"`
+	Hard      bool       `yaml:"hard,omitempty"`
+	Overwrite bool       `yaml:"overwrite,omitempty"`
+	User      *NodeOwner `yaml:"user,omitempty"`
+	Group     *NodeOwner `yaml:"group,omitempty"`
+}
+
+type NodeOwner struct {
+	ID   *int   `yaml:"id,omitempty"`
+	Name string `yaml:"name,omitempty"`
+}
+
+type Resource struct {
+	Inline       *string      `yaml:"inline,omitempty"`
+	Source       string       `yaml:"source,omitempty"`
+	Local        string       `yaml:"local,omitempty"`
+	Compression  string       `yaml:"compression,omitempty"`
+	HTTPHeaders  []HTTPHeader `yaml:"http_headers,omitempty"`
+	Verification Verification `yaml:"verification,omitempty"`
+}
+
+type Verification struct {
+	Hash string `yaml:"hash,omitempty"`
+}
+
+type HTTPHeader struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type Systemd struct {
+	Units []Unit `yaml:"units,omitempty"`
+}
+
+type Unit struct {
+	Name     string   `yaml:"name"`
+	Enabled  *bool    `yaml:"enabled,omitempty"`
+	Mask     bool     `yaml:"mask,omitempty"`
+	Contents string   `yaml:"contents,omitempty"`
+	Dropins  []Dropin `yaml:"dropins,omitempty"`
+}
+
+type Dropin struct {
+	Name     string `yaml:"name"`
+	Contents string `yaml:"contents,omitempty"`
+}
+
+// Mode is a file mode, marshaled in octal.
+type Mode int
+
+func (m Mode) MarshalYAML() (interface{}, error) {
+	return &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!int",
+		Value: fmt.Sprintf("%#o", int(m)),
+	}, nil
+}
+
+// Marshal returns the YAML representation of cfg.
+func Marshal(cfg Config) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fcosFamily returns true if the variant shares the Fedora CoreOS
+// filesystem layout, where /var and /etc are the only writable mount
+// points.
+func fcosFamily(variant string) bool {
+	return variant != "flatcar"
+}
+
+// warnUnknownKeys reports a warning for each key in n, and in the nodes
+// it contains, that doesn't correspond to a field of v, which is usually
+// a struct.  p is the path of n.  Nodes decoded as yaml.Node aren't
+// checked.
+func warnUnknownKeys(r *report.Report, n *yaml.Node, v interface{}, p path.ContextPath, err func(string) error) {
+	checkKeys(r, n, reflect.TypeOf(v), p, err)
+}
+
+func checkKeys(r *report.Report, n *yaml.Node, typ reflect.Type, p path.ContextPath, err func(string) error) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ == reflect.TypeOf(yaml.Node{}):
+	case n.Kind == yaml.SequenceNode && typ.Kind() == reflect.Slice:
+		for i, item := range n.Content {
+			checkKeys(r, item, typ.Elem(), p.Append(i), err)
+		}
+	case n.Kind == yaml.MappingNode && typ.Kind() == reflect.Map:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			checkKeys(r, n.Content[i+1], typ.Elem(), p.Append(n.Content[i].Value), err)
+		}
+	case n.Kind == yaml.MappingNode && typ.Kind() == reflect.Struct:
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("yaml"), ",")
+			if name != "" && name != "-" {
+				fields[name] = typ.Field(i).Type
+			}
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if fieldType, ok := fields[key]; ok {
+				checkKeys(r, n.Content[i+1], fieldType, p.Append(key), err)
+			} else {
+				r.AddOnWarn(p.Append(key), err(key))
+			}
+		}
+	}
+}
+
+// finish correlates the report with the input and marshals cfg.
+func finish(input []byte, cfg Config, r report.Report) ([]byte, report.Report, error) {
+	if contextTree, err := vyaml.UnmarshalToContext(input); err == nil {
+		r.Correlate(contextTree)
+	}
+	out, err := Marshal(cfg)
+	return out, r, err
+}
@@ -0,0 +1,643 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	updateConfPath = "/etc/flatcar/update.conf"
+	networkdDir    = "/etc/systemd/network"
+)
+
+var (
+	ErrNonRootFilesystem = errors.New("nodes on filesystems other than root are not supported; specify the path under the filesystem's mount point")
+	ErrSizeNotMiB        = errors.New("size is not a multiple of 1 MiB; rounding down")
+	ErrInvalidSize       = errors.New("invalid size")
+	ErrDeprecatedCreate  = errors.New("passwd.users.create is not supported; specify the equivalent user fields")
+
+	// CLC dynamic data, substituted by ct from coreos-metadata
+	dynamicDataRegexp = regexp.MustCompile(`\{(PRIVATE_IPV4|PUBLIC_IPV4|PRIVATE_IPV6|PUBLIC_IPV6|HOSTNAME)\}`)
+)
+
+type ErrDynamicData struct {
+	Name string
+}
+
+func (e ErrDynamicData) Error() string {
+	return fmt.Sprintf("dynamic data {%s} is not supported; use coreos-metadata.service and an EnvironmentFile, or substitute the value", e.Name)
+}
+
+type clcConfig struct {
+	Ignition  clcIgnition       `yaml:"ignition"`
+	Storage   clcStorage        `yaml:"storage"`
+	Systemd   clcSystemd        `yaml:"systemd"`
+	Networkd  clcSystemd        `yaml:"networkd"`
+	Passwd    clcPasswd         `yaml:"passwd"`
+	Etcd      map[string]string `yaml:"etcd"`
+	Flannel   map[string]string `yaml:"flannel"`
+	Docker    clcDocker         `yaml:"docker"`
+	Update    clcUpdate         `yaml:"update"`
+	Locksmith clcLocksmith      `yaml:"locksmith"`
+}
+
+type clcIgnition struct {
+	Config struct {
+		Append  []clcRemote `yaml:"append"`
+		Replace *clcRemote  `yaml:"replace"`
+	} `yaml:"config"`
+	Timeouts struct {
+		HTTPResponseHeaders *int `yaml:"http_response_headers"`
+		HTTPTotal           *int `yaml:"http_total"`
+	} `yaml:"timeouts"`
+	Security struct {
+		TLS struct {
+			CertificateAuthorities []clcRemote `yaml:"certificate_authorities"`
+		} `yaml:"tls"`
+	} `yaml:"security"`
+}
+
+type clcRemote struct {
+	Source       string          `yaml:"source"`
+	URL          string          `yaml:"url"`
+	Compression  string          `yaml:"compression"`
+	Verification clcVerification `yaml:"verification"`
+}
+
+type clcVerification struct {
+	Hash struct {
+		Function string `yaml:"function"`
+		Sum      string `yaml:"sum"`
+	} `yaml:"hash"`
+}
+
+type clcStorage struct {
+	Disks       []clcDisk       `yaml:"disks"`
+	Raid        []clcRaid       `yaml:"raid"`
+	Filesystems []clcFilesystem `yaml:"filesystems"`
+	Files       []clcFile       `yaml:"files"`
+	Directories []clcNode       `yaml:"directories"`
+	Links       []clcNode       `yaml:"links"`
+}
+
+type clcDisk struct {
+	Device     string `yaml:"device"`
+	WipeTable  bool   `yaml:"wipe_table"`
+	Partitions []struct {
+		Label    string `yaml:"label"`
+		Number   int    `yaml:"number"`
+		Size     string `yaml:"size"`
+		Start    string `yaml:"start"`
+		GUID     string `yaml:"guid"`
+		TypeGUID string `yaml:"type_guid"`
+	} `yaml:"partitions"`
+}
+
+type clcRaid struct {
+	Name    string   `yaml:"name"`
+	Level   string   `yaml:"level"`
+	Devices []string `yaml:"devices"`
+	Spares  int      `yaml:"spares"`
+	Options []string `yaml:"options"`
+}
+
+type clcFilesystem struct {
+	Name  string `yaml:"name"`
+	Path  string `yaml:"path"`
+	Mount *struct {
+		Device         string   `yaml:"device"`
+		Format         string   `yaml:"format"`
+		WipeFilesystem bool     `yaml:"wipe_filesystem"`
+		Label          string   `yaml:"label"`
+		UUID           string   `yaml:"uuid"`
+		Options        []string `yaml:"options"`
+		Create         *struct {
+			Force   bool     `yaml:"force"`
+			Options []string `yaml:"options"`
+		} `yaml:"create"`
+	} `yaml:"mount"`
+}
+
+type clcOwner struct {
+	ID   *int   `yaml:"id"`
+	Name string `yaml:"name"`
+}
+
+// clcNode is a directory or link.
+type clcNode struct {
+	Filesystem string    `yaml:"filesystem"`
+	Path       string    `yaml:"path"`
+	Mode       *int      `yaml:"mode"`
+	User       *clcOwner `yaml:"user"`
+	Group      *clcOwner `yaml:"group"`
+	Overwrite  *bool     `yaml:"overwrite"`
+	Target     string    `yaml:"target"`
+	Hard       bool      `yaml:"hard"`
+}
+
+type clcFile struct {
+	Filesystem string    `yaml:"filesystem"`
+	Path       string    `yaml:"path"`
+	Mode       *int      `yaml:"mode"`
+	User       *clcOwner `yaml:"user"`
+	Group      *clcOwner `yaml:"group"`
+	Overwrite  *bool     `yaml:"overwrite"`
+	Append     bool      `yaml:"append"`
+	Contents   struct {
+		Inline *string    `yaml:"inline"`
+		Local  string     `yaml:"local"`
+		Remote *clcRemote `yaml:"remote"`
+	} `yaml:"contents"`
+}
+
+type clcSystemd struct {
+	Units []struct {
+		Name     string `yaml:"name"`
+		Enable   bool   `yaml:"enable"`
+		Enabled  *bool  `yaml:"enabled"`
+		Mask     bool   `yaml:"mask"`
+		Contents string `yaml:"contents"`
+		Dropins  []struct {
+			Name     string `yaml:"name"`
+			Contents string `yaml:"contents"`
+		} `yaml:"dropins"`
+	} `yaml:"units"`
+}
+
+type clcPasswd struct {
+	Users []struct {
+		Name              string    `yaml:"name"`
+		PasswordHash      string    `yaml:"password_hash"`
+		SSHAuthorizedKeys []string  `yaml:"ssh_authorized_keys"`
+		UID               *int      `yaml:"uid"`
+		Gecos             string    `yaml:"gecos"`
+		HomeDir           string    `yaml:"home_dir"`
+		NoCreateHome      bool      `yaml:"no_create_home"`
+		PrimaryGroup      string    `yaml:"primary_group"`
+		Groups            []string  `yaml:"groups"`
+		NoUserGroup       bool      `yaml:"no_user_group"`
+		NoLogInit         bool      `yaml:"no_log_init"`
+		Shell             string    `yaml:"shell"`
+		System            bool      `yaml:"system"`
+		Create            yaml.Node `yaml:"create"`
+	} `yaml:"users"`
+	Groups []struct {
+		Name         string `yaml:"name"`
+		Gid          *int   `yaml:"gid"`
+		PasswordHash string `yaml:"password_hash"`
+		System       bool   `yaml:"system"`
+	} `yaml:"groups"`
+}
+
+type clcDocker struct {
+	Flags []string `yaml:"flags"`
+}
+
+type clcUpdate struct {
+	Group  string `yaml:"group"`
+	Server string `yaml:"server"`
+}
+
+type clcLocksmith struct {
+	RebootStrategy string `yaml:"reboot_strategy"`
+	WindowStart    string `yaml:"window_start"`
+	WindowLength   string `yaml:"window_length"`
+	Group          string `yaml:"group"`
+	EtcdEndpoints  string `yaml:"etcd_endpoints"`
+	EtcdCAFile     string `yaml:"etcd_cafile"`
+	EtcdCertFile   string `yaml:"etcd_certfile"`
+	EtcdKeyFile    string `yaml:"etcd_keyfile"`
+}
+
+// clcImporter accumulates the Butane config for a Container Linux Config.
+type clcImporter struct {
+	cfg Config
+	r   report.Report
+}
+
+// FromCLC converts a Container Linux Config, as accepted by the ct
+// transpiler, to a Butane config of the specified variant and version,
+// which is normally flatcar.  The networkd, etcd, flannel, docker,
+// update, and locksmith sections become files and unit dropins.  Unknown
+// keys and dynamic data such as {PRIVATE_IPV4} are reported as warnings.
+func FromCLC(input []byte, variant, version string) ([]byte, report.Report, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(input, &doc); err != nil {
+		return nil, report.Report{}, err
+	}
+	var clc clcConfig
+	if err := doc.Decode(&clc); err != nil {
+		return nil, report.Report{}, err
+	}
+
+	imp := clcImporter{
+		cfg: Config{
+			Variant: variant,
+			Version: version,
+		},
+	}
+	if len(doc.Content) > 0 {
+		warnUnknownKeys(&imp.r, doc.Content[0], clc, path.New("yaml"), func(key string) error {
+			return ErrUnsupportedKey{Key: key}
+		})
+	}
+
+	imp.addIgnition(clc.Ignition)
+	imp.addStorage(clc.Storage)
+	imp.addUnits(clc.Systemd)
+	imp.addNetworkd(clc.Networkd)
+	imp.addPasswd(clc.Passwd)
+	imp.addServiceOptions("etcd", clc.Etcd, "etcd-member.service", "20-clct-etcd-member.conf", "ETCD_", "ETCD_IMAGE_TAG")
+	imp.addServiceOptions("flannel", clc.Flannel, "flanneld.service", "20-clct-flannel.conf", "FLANNELD_", "FLANNEL_IMAGE_TAG")
+	imp.addDocker(clc.Docker)
+	imp.addUpdateConf(clc.Update, clc.Locksmith)
+	return finish(input, imp.cfg, imp.r)
+}
+
+func (imp *clcImporter) addIgnition(ign clcIgnition) {
+	for _, ref := range ign.Config.Append {
+		imp.cfg.Ignition.Config.Merge = append(imp.cfg.Ignition.Config.Merge, remoteResource(ref))
+	}
+	if ign.Config.Replace != nil {
+		replace := remoteResource(*ign.Config.Replace)
+		imp.cfg.Ignition.Config.Replace = &replace
+	}
+	imp.cfg.Ignition.Timeouts.HTTPResponseHeaders = ign.Timeouts.HTTPResponseHeaders
+	imp.cfg.Ignition.Timeouts.HTTPTotal = ign.Timeouts.HTTPTotal
+	for _, ca := range ign.Security.TLS.CertificateAuthorities {
+		imp.cfg.Ignition.Security.TLS.CertificateAuthorities = append(imp.cfg.Ignition.Security.TLS.CertificateAuthorities, remoteResource(ca))
+	}
+}
+
+func (imp *clcImporter) addStorage(storage clcStorage) {
+	for i, d := range storage.Disks {
+		disk := Disk{
+			Device:    d.Device,
+			WipeTable: d.WipeTable,
+		}
+		for j, p := range d.Partitions {
+			partPath := path.New("yaml", "storage", "disks", i, "partitions", j)
+			disk.Partitions = append(disk.Partitions, Partition{
+				Number:   p.Number,
+				Label:    p.Label,
+				SizeMiB:  imp.parseSize(p.Size, partPath.Append("size")),
+				StartMiB: imp.parseSize(p.Start, partPath.Append("start")),
+				TypeGUID: p.TypeGUID,
+				GUID:     p.GUID,
+			})
+		}
+		imp.cfg.Storage.Disks = append(imp.cfg.Storage.Disks, disk)
+	}
+	for _, r := range storage.Raid {
+		imp.cfg.Storage.Raid = append(imp.cfg.Storage.Raid, Raid(r))
+	}
+	for _, fs := range storage.Filesystems {
+		if fs.Mount == nil {
+			// a filesystem mounted by path in the initramfs; only
+			// used as a reference from files
+			continue
+		}
+		filesystem := Filesystem{
+			Device:         fs.Mount.Device,
+			Format:         fs.Mount.Format,
+			WipeFilesystem: fs.Mount.WipeFilesystem,
+			Label:          fs.Mount.Label,
+			UUID:           fs.Mount.UUID,
+			Options:        fs.Mount.Options,
+		}
+		if fs.Mount.Create != nil {
+			filesystem.WipeFilesystem = fs.Mount.Create.Force
+			filesystem.Options = fs.Mount.Create.Options
+		}
+		imp.cfg.Storage.Filesystems = append(imp.cfg.Storage.Filesystems, filesystem)
+	}
+
+	for i, f := range storage.Files {
+		p := path.New("yaml", "storage", "files", i)
+		if !imp.rootFilesystem(f.Filesystem, p) {
+			continue
+		}
+		file := File{
+			Path:  f.Path,
+			Mode:  modeFromInt(f.Mode),
+			User:  owner(f.User),
+			Group: owner(f.Group),
+		}
+		var contents Resource
+		switch {
+		case f.Contents.Remote != nil:
+			contents = remoteResource(*f.Contents.Remote)
+		case f.Contents.Local != "":
+			contents.Local = f.Contents.Local
+		default:
+			s := ""
+			if f.Contents.Inline != nil {
+				s = *f.Contents.Inline
+			}
+			contents.Inline = &s
+		}
+		if f.Append {
+			file.Append = []Resource{contents}
+		} else {
+			// files are overwritten by default in the Ignition
+			// spec used by ct
+			file.Overwrite = f.Overwrite == nil || *f.Overwrite
+			file.Contents = &contents
+		}
+		imp.cfg.Storage.Files = append(imp.cfg.Storage.Files, file)
+	}
+	for i, d := range storage.Directories {
+		if !imp.rootFilesystem(d.Filesystem, path.New("yaml", "storage", "directories", i)) {
+			continue
+		}
+		imp.cfg.Storage.Directories = append(imp.cfg.Storage.Directories, Directory{
+			Path:  d.Path,
+			Mode:  modeFromInt(d.Mode),
+			User:  owner(d.User),
+			Group: owner(d.Group),
+		})
+	}
+	for i, l := range storage.Links {
+		if !imp.rootFilesystem(l.Filesystem, path.New("yaml", "storage", "links", i)) {
+			continue
+		}
+		imp.cfg.Storage.Links = append(imp.cfg.Storage.Links, Link{
+			Path:      l.Path,
+			Target:    l.Target,
+			Hard:      l.Hard,
+			Overwrite: l.Overwrite != nil && *l.Overwrite,
+			User:      owner(l.User),
+			Group:     owner(l.Group),
+		})
+	}
+}
+
+// rootFilesystem returns true if the node at p is on the root filesystem,
+// and warns otherwise.
+func (imp *clcImporter) rootFilesystem(filesystem string, p path.ContextPath) bool {
+	if filesystem == "" || filesystem == "root" {
+		return true
+	}
+	imp.r.AddOnWarn(p.Append("filesystem"), ErrNonRootFilesystem)
+	return false
+}
+
+func (imp *clcImporter) addUnits(systemd clcSystemd) {
+	for _, u := range systemd.Units {
+		unit := Unit{
+			Name:     u.Name,
+			Enabled:  u.Enabled,
+			Mask:     u.Mask,
+			Contents: u.Contents,
+		}
+		if u.Enable && unit.Enabled == nil {
+			unit.Enabled = boolPtr(true)
+		}
+		for _, d := range u.Dropins {
+			unit.Dropins = append(unit.Dropins, Dropin{Name: d.Name, Contents: d.Contents})
+		}
+		imp.cfg.Systemd.Units = append(imp.cfg.Systemd.Units, unit)
+	}
+}
+
+// addNetworkd writes networkd units and their dropins as files.
+func (imp *clcImporter) addNetworkd(networkd clcSystemd) {
+	for _, u := range networkd.Units {
+		if u.Contents != "" {
+			imp.addFile(networkdDir+"/"+u.Name, u.Contents)
+		}
+		for _, d := range u.Dropins {
+			imp.addFile(networkdDir+"/"+u.Name+".d/"+d.Name, d.Contents)
+		}
+	}
+}
+
+func (imp *clcImporter) addPasswd(passwd clcPasswd) {
+	for i, u := range passwd.Users {
+		if u.Create.Kind != 0 {
+			imp.r.AddOnWarn(path.New("yaml", "passwd", "users", i, "create"), ErrDeprecatedCreate)
+		}
+		imp.cfg.Passwd.Users = append(imp.cfg.Passwd.Users, User{
+			Name:              u.Name,
+			UID:               u.UID,
+			Gecos:             u.Gecos,
+			HomeDir:           u.HomeDir,
+			NoCreateHome:      u.NoCreateHome,
+			PrimaryGroup:      u.PrimaryGroup,
+			Groups:            u.Groups,
+			NoUserGroup:       u.NoUserGroup,
+			Shell:             u.Shell,
+			System:            u.System,
+			PasswordHash:      u.PasswordHash,
+			SSHAuthorizedKeys: u.SSHAuthorizedKeys,
+		})
+	}
+	for _, g := range passwd.Groups {
+		imp.cfg.Passwd.Groups = append(imp.cfg.Passwd.Groups, Group{
+			Name:         g.Name,
+			Gid:          g.Gid,
+			System:       g.System,
+			PasswordHash: g.PasswordHash,
+		})
+	}
+}
+
+// addServiceOptions converts the etcd or flannel section to environment
+// variables in a dropin for the service, and enables it.
+func (imp *clcImporter) addServiceOptions(section string, options map[string]string, unit, dropin, prefix, imageTagVar string) {
+	if len(options) == 0 {
+		return
+	}
+	var keys []string
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var contents strings.Builder
+	contents.WriteString("[Service]\n")
+	var execStartPre string
+	for _, key := range keys {
+		value := options[key]
+		for _, match := range dynamicDataRegexp.FindAllStringSubmatch(value, -1) {
+			imp.r.AddOnWarn(path.New("yaml", section, key), ErrDynamicData{Name: match[1]})
+		}
+		switch key {
+		case "version":
+			fmt.Fprintf(&contents, "Environment=%s\n", execQuote([]string{imageTagVar + "=v" + value}))
+		case "network_config":
+			// flannel reads its network config from etcd
+			execStartPre = execQuote([]string{"/usr/bin/etcdctl", "set", "/coreos.com/network/config", value})
+		default:
+			name := prefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+			fmt.Fprintf(&contents, "Environment=%s\n", execQuote([]string{name + "=" + value}))
+		}
+	}
+	if execStartPre != "" {
+		fmt.Fprintf(&contents, "ExecStartPre=%s\n", execStartPre)
+	}
+	imp.addDropin(unit, true, Dropin{Name: dropin, Contents: contents.String()})
+}
+
+func (imp *clcImporter) addDocker(docker clcDocker) {
+	if len(docker.Flags) == 0 {
+		return
+	}
+	imp.addDropin("docker.service", false, Dropin{
+		Name:     "20-clct-docker.conf",
+		Contents: fmt.Sprintf("[Service]\nEnvironment=%s\n", execQuote([]string{"DOCKER_OPTS=" + strings.Join(docker.Flags, " ")})),
+	})
+}
+
+// addDropin adds dropin to the named unit, optionally enabling it.  If
+// systemd.units already has the unit, as when a config both configures
+// etcd and enables etcd-member.service, the dropin is added to it, and
+// an explicit enabled setting is kept.
+func (imp *clcImporter) addDropin(name string, enable bool, dropin Dropin) {
+	for i := range imp.cfg.Systemd.Units {
+		unit := &imp.cfg.Systemd.Units[i]
+		if unit.Name == name {
+			unit.Dropins = append(unit.Dropins, dropin)
+			if enable && unit.Enabled == nil {
+				unit.Enabled = boolPtr(true)
+			}
+			return
+		}
+	}
+	unit := Unit{
+		Name:    name,
+		Dropins: []Dropin{dropin},
+	}
+	if enable {
+		unit.Enabled = boolPtr(true)
+	}
+	imp.cfg.Systemd.Units = append(imp.cfg.Systemd.Units, unit)
+}
+
+// addUpdateConf writes the update and locksmith sections to the update
+// config, which is read by update_engine and locksmithd.
+func (imp *clcImporter) addUpdateConf(update clcUpdate, locksmith clcLocksmith) {
+	var contents bytes.Buffer
+	for _, setting := range []struct {
+		name, value string
+	}{
+		{"GROUP", update.Group},
+		{"SERVER", update.Server},
+		{"REBOOT_STRATEGY", locksmith.RebootStrategy},
+		{"LOCKSMITHD_REBOOT_WINDOW_START", locksmith.WindowStart},
+		{"LOCKSMITHD_REBOOT_WINDOW_LENGTH", locksmith.WindowLength},
+		{"LOCKSMITHD_GROUP", locksmith.Group},
+		{"LOCKSMITHD_ENDPOINT", locksmith.EtcdEndpoints},
+		{"LOCKSMITHD_ETCD_CAFILE", locksmith.EtcdCAFile},
+		{"LOCKSMITHD_ETCD_CERTFILE", locksmith.EtcdCertFile},
+		{"LOCKSMITHD_ETCD_KEYFILE", locksmith.EtcdKeyFile},
+	} {
+		if setting.value != "" {
+			fmt.Fprintf(&contents, "%s=%s\n", setting.name, setting.value)
+		}
+	}
+	if contents.Len() == 0 {
+		return
+	}
+	imp.cfg.Storage.Files = append(imp.cfg.Storage.Files, File{
+		Path:      updateConfPath,
+		Overwrite: true,
+		Mode:      modePtr(0644),
+		Contents:  inline(contents.String()),
+	})
+}
+
+func (imp *clcImporter) addFile(p, contents string) {
+	imp.cfg.Storage.Files = append(imp.cfg.Storage.Files, File{
+		Path:     p,
+		Mode:     modePtr(0644),
+		Contents: inline(contents),
+	})
+}
+
+// parseSize converts a partition size or start, such as "10GiB", to
+// MiB.  Sizes without a unit are in bytes.
+func (imp *clcImporter) parseSize(size string, p path.ContextPath) *int {
+	if size == "" {
+		return nil
+	}
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	number, multiplier := size, int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(size, unit.suffix) {
+			number, multiplier = strings.TrimSuffix(size, unit.suffix), unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil || n < 0 {
+		imp.r.AddOnWarn(p, ErrInvalidSize)
+		return nil
+	}
+	bytes := n * multiplier
+	if bytes%(1<<20) != 0 {
+		imp.r.AddOnWarn(p, ErrSizeNotMiB)
+	}
+	mib := int(bytes >> 20)
+	return &mib
+}
+
+func remoteResource(remote clcRemote) Resource {
+	source := remote.Source
+	if source == "" {
+		source = remote.URL
+	}
+	ret := Resource{
+		Source:      source,
+		Compression: remote.Compression,
+	}
+	if remote.Verification.Hash.Sum != "" {
+		ret.Verification.Hash = remote.Verification.Hash.Function + "-" + remote.Verification.Hash.Sum
+	}
+	return ret
+}
+
+func owner(o *clcOwner) *NodeOwner {
+	if o == nil || (o.ID == nil && o.Name == "") {
+		return nil
+	}
+	return &NodeOwner{ID: o.ID, Name: o.Name}
+}
+
+func modeFromInt(mode *int) *Mode {
+	if mode == nil {
+		return nil
+	}
+	return modePtr(*mode)
+}
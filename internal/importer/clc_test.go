@@ -0,0 +1,274 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package importer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromCLC(t *testing.T) {
+	in := `ignition:
+  config:
+    append:
+      - source: https://example.com/extra.ign
+        verification:
+          hash:
+            function: sha256
+            sum: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+storage:
+  disks:
+    - device: /dev/vdb
+      wipe_table: true
+      partitions:
+        - label: data
+          number: 1
+          size: 10GiB
+  filesystems:
+    - name: data
+      mount:
+        device: /dev/disk/by-partlabel/data
+        format: ext4
+        create:
+          force: true
+  files:
+    - path: /etc/motd
+      filesystem: root
+      mode: 0644
+      contents:
+        inline: hi
+    - path: /data/file
+      filesystem: data
+  links:
+    - path: /etc/localtime
+      target: /usr/share/zoneinfo/UTC
+systemd:
+  units:
+    - name: app.service
+      enable: true
+      contents: "[Service]\nExecStart=/bin/true\n[Install]\nWantedBy=multi-user.target\n"
+networkd:
+  units:
+    - name: 00-eth0.network
+      contents: "[Match]\nName=eth0\n"
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys: [ssh-ed25519 AAAA]
+etcd:
+  version: 3.5.0
+  name: "{HOSTNAME}"
+  advertise_client_urls: http://{PRIVATE_IPV4}:2379
+flannel:
+  network_config: '{"Network": "10.1.0.0/16"}'
+update:
+  group: beta
+locksmith:
+  reboot_strategy: etcd-lock
+  window_start: Sun 1:00
+  window_length: 2h
+coreos: {}
+`
+	expected := `variant: flatcar
+version: 1.1.0
+ignition:
+  config:
+    merge:
+      - source: https://example.com/extra.ign
+        verification:
+          hash: sha256-aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - ssh-ed25519 AAAA
+storage:
+  disks:
+    - device: /dev/vdb
+      wipe_table: true
+      partitions:
+        - number: 1
+          label: data
+          size_mib: 10240
+  filesystems:
+    - device: /dev/disk/by-partlabel/data
+      format: ext4
+      wipe_filesystem: true
+  files:
+    - path: /etc/motd
+      overwrite: true
+      mode: 0644
+      contents:
+        inline: hi
+    - path: /etc/systemd/network/00-eth0.network
+      mode: 0644
+      contents:
+        inline: |
+          [Match]
+          Name=eth0
+    - path: /etc/flatcar/update.conf
+      overwrite: true
+      mode: 0644
+      contents:
+        inline: |
+          GROUP=beta
+          REBOOT_STRATEGY=etcd-lock
+          LOCKSMITHD_REBOOT_WINDOW_START=Sun 1:00
+          LOCKSMITHD_REBOOT_WINDOW_LENGTH=2h
+  links:
+    - path: /etc/localtime
+      target: /usr/share/zoneinfo/UTC
+systemd:
+  units:
+    - name: app.service
+      enabled: true
+      contents: |
+        [Service]
+        ExecStart=/bin/true
+        [Install]
+        WantedBy=multi-user.target
+    - name: etcd-member.service
+      enabled: true
+      dropins:
+        - name: 20-clct-etcd-member.conf
+          contents: |
+            [Service]
+            Environment=ETCD_ADVERTISE_CLIENT_URLS=http://{PRIVATE_IPV4}:2379
+            Environment=ETCD_NAME={HOSTNAME}
+            Environment=ETCD_IMAGE_TAG=v3.5.0
+    - name: flanneld.service
+      enabled: true
+      dropins:
+        - name: 20-clct-flannel.conf
+          contents: |
+            [Service]
+            ExecStartPre=/usr/bin/etcdctl set /coreos.com/network/config "{\"Network\": \"10.1.0.0/16\"}"
+`
+
+	out, r, err := FromCLC([]byte(in), "flatcar", "1.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(out), "bad output")
+	assert.Equal(t, "warning at $.coreos, line 60 col 9: "+ErrUnsupportedKey{Key: "coreos"}.Error()+"\n"+
+		"warning at $.storage.files.1.filesystem, line 31 col 19: "+ErrNonRootFilesystem.Error()+"\n"+
+		"warning at $.etcd.advertise_client_urls, line 51 col 26: "+ErrDynamicData{Name: "PRIVATE_IPV4"}.Error()+"\n"+
+		"warning at $.etcd.name, line 50 col 9: "+ErrDynamicData{Name: "HOSTNAME"}.Error()+"\n",
+		r.String(), "bad report")
+
+	_, r, err = config.TranslateBytes(out, common.TranslateBytesOptions{})
+	assert.NoError(t, err, "output doesn't translate")
+	assert.Empty(t, r.Entries, "output has report entries")
+}
+
+// TestFromCLCExistingUnits checks that service sections add their dropins
+// to units the config already lists, rather than duplicating the units.
+func TestFromCLCExistingUnits(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		// etcd with the usual enabled etcd-member.service
+		{
+			in: `etcd:
+  name: node1
+systemd:
+  units:
+    - name: etcd-member.service
+      enable: true
+`,
+			out: `variant: flatcar
+version: 1.1.0
+systemd:
+  units:
+    - name: etcd-member.service
+      enabled: true
+      dropins:
+        - name: 20-clct-etcd-member.conf
+          contents: |
+            [Service]
+            Environment=ETCD_NAME=node1
+`,
+		},
+		// flannel with an existing dropin
+		{
+			in: `flannel:
+  etcd_prefix: /coreos.com/network2
+systemd:
+  units:
+    - name: flanneld.service
+      dropins:
+        - name: 10-custom.conf
+          contents: |
+            [Service]
+            Restart=always
+`,
+			out: `variant: flatcar
+version: 1.1.0
+systemd:
+  units:
+    - name: flanneld.service
+      enabled: true
+      dropins:
+        - name: 10-custom.conf
+          contents: |
+            [Service]
+            Restart=always
+        - name: 20-clct-flannel.conf
+          contents: |
+            [Service]
+            Environment=FLANNELD_ETCD_PREFIX=/coreos.com/network2
+`,
+		},
+		// docker with an explicitly disabled docker.service
+		{
+			in: `docker:
+  flags:
+    - --debug
+systemd:
+  units:
+    - name: docker.service
+      enabled: false
+`,
+			out: `variant: flatcar
+version: 1.1.0
+systemd:
+  units:
+    - name: docker.service
+      enabled: false
+      dropins:
+        - name: 20-clct-docker.conf
+          contents: |
+            [Service]
+            Environment=DOCKER_OPTS=--debug
+`,
+		},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("existing unit %d", i), func(t *testing.T) {
+			out, r, err := FromCLC([]byte(test.in), "flatcar", "1.1.0")
+			assert.NoError(t, err)
+			assert.Empty(t, r.Entries, "non-empty report")
+			assert.Equal(t, test.out, string(out), "bad output")
+
+			_, r, err = config.TranslateBytes(out, common.TranslateBytesOptions{})
+			assert.NoError(t, err, "output doesn't translate")
+			assert.Empty(t, r.Entries, "output has report entries")
+		})
+	}
+}
@@ -0,0 +1,654 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"github.com/vincent-petithory/dataurl"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// user that receives the top-level SSH keys
+	defaultUser = "core"
+
+	runcmdUnit = `[Unit]
+Description=Run cloud-init runcmd commands
+Wants=network-online.target
+After=network-online.target
+ConditionFirstBoot=yes
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+%s
+[Install]
+WantedBy=multi-user.target
+`
+
+	bootcmdUnit = `[Unit]
+Description=Run cloud-init bootcmd commands
+DefaultDependencies=no
+After=local-fs.target
+Before=sysinit.target
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+%s
+[Install]
+WantedBy=sysinit.target
+`
+
+	chronyConf = `# Generated from cloud-init ntp module
+%sdriftfile /var/lib/chrony/drift
+makestep 1.0 3
+rtcsync
+`
+)
+
+var (
+	ErrNotCloudConfig    = errors.New("input must be a cloud-config starting with #cloud-config")
+	ErrPlainTextPassword = errors.New("plain-text passwords are not supported; specify a password hash with passwd")
+	ErrSSHImportID       = errors.New("importing SSH keys is not supported; list them in ssh_authorized_keys")
+	ErrAutoFilesystem    = errors.New("filesystem type must be specified to create a mount unit")
+	ErrEphemeralMount    = errors.New("cloud-specific ephemeral devices are not supported")
+	ErrMountPoint        = errors.New("mount point must be under /etc or /var")
+	ErrInvalidEntry      = errors.New("invalid entry")
+	ErrUnknownEncoding   = errors.New("unsupported encoding")
+	ErrNTPDisabled       = errors.New("disabling NTP is not supported")
+	ErrDeferredWrite     = errors.New("deferred writes are not supported; the file will be written before users are created and services start")
+)
+
+type ErrUnsupportedModule struct {
+	Module string
+}
+
+func (e ErrUnsupportedModule) Error() string {
+	return fmt.Sprintf("cloud-init module %q has no Butane equivalent", e.Module)
+}
+
+type ErrUnsupportedKey struct {
+	Key string
+}
+
+func (e ErrUnsupportedKey) Error() string {
+	return fmt.Sprintf("%q has no Butane equivalent", e.Key)
+}
+
+type cloudConfig struct {
+	Hostname          string        `yaml:"hostname"`
+	FQDN              string        `yaml:"fqdn"`
+	PreferFQDN        bool          `yaml:"prefer_fqdn_over_hostname"`
+	Users             []yaml.Node   `yaml:"users"`
+	Groups            []yaml.Node   `yaml:"groups"`
+	SSHAuthorizedKeys []string      `yaml:"ssh_authorized_keys"`
+	WriteFiles        []yaml.Node   `yaml:"write_files"`
+	Mounts            [][]yaml.Node `yaml:"mounts"`
+	Bootcmd           []yaml.Node   `yaml:"bootcmd"`
+	Runcmd            []yaml.Node   `yaml:"runcmd"`
+	NTP               yaml.Node     `yaml:"ntp"`
+}
+
+type cloudUser struct {
+	Name              string    `yaml:"name"`
+	Gecos             string    `yaml:"gecos"`
+	Homedir           string    `yaml:"homedir"`
+	PrimaryGroup      string    `yaml:"primary_group"`
+	Groups            yaml.Node `yaml:"groups"`
+	Shell             string    `yaml:"shell"`
+	System            bool      `yaml:"system"`
+	UID               *int      `yaml:"uid"`
+	NoCreateHome      bool      `yaml:"no_create_home"`
+	NoUserGroup       bool      `yaml:"no_user_group"`
+	Passwd            string    `yaml:"passwd"`
+	HashedPasswd      string    `yaml:"hashed_passwd"`
+	PlainTextPasswd   string    `yaml:"plain_text_passwd"`
+	LockPasswd        *bool     `yaml:"lock_passwd"`
+	Sudo              yaml.Node `yaml:"sudo"`
+	SSHAuthorizedKeys []string  `yaml:"ssh_authorized_keys"`
+	SSHImportID       []string  `yaml:"ssh_import_id"`
+}
+
+type cloudWriteFile struct {
+	Path    string `yaml:"path"`
+	Content string `yaml:"content"`
+	Source  *struct {
+		URI     string            `yaml:"uri"`
+		Headers map[string]string `yaml:"headers"`
+	} `yaml:"source"`
+	Encoding    string    `yaml:"encoding"`
+	Owner       string    `yaml:"owner"`
+	Permissions yaml.Node `yaml:"permissions"`
+	Append      bool      `yaml:"append"`
+	Defer       bool      `yaml:"defer"`
+}
+
+type cloudNTP struct {
+	Enabled   *bool    `yaml:"enabled"`
+	NTPClient string   `yaml:"ntp_client"`
+	Servers   []string `yaml:"servers"`
+	Pools     []string `yaml:"pools"`
+}
+
+// cloudInitImporter accumulates the Butane config for a cloud-config.
+type cloudInitImporter struct {
+	cfg   Config
+	r     report.Report
+	users map[string]int
+}
+
+// FromCloudInit converts a cloud-init cloud-config to a Butane config
+// of the specified variant and version.  Modules and keys that have no
+// Butane equivalent are reported as warnings.
+func FromCloudInit(input []byte, variant, version string) ([]byte, report.Report, error) {
+	if !bytes.HasPrefix(input, []byte("#cloud-config")) {
+		return nil, report.Report{}, ErrNotCloudConfig
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(input, &doc); err != nil {
+		return nil, report.Report{}, err
+	}
+	var cc cloudConfig
+	if err := doc.Decode(&cc); err != nil {
+		return nil, report.Report{}, err
+	}
+
+	imp := cloudInitImporter{
+		cfg: Config{
+			Variant: variant,
+			Version: version,
+		},
+		users: make(map[string]int),
+	}
+	if len(doc.Content) > 0 {
+		warnUnknownKeys(&imp.r, doc.Content[0], cc, path.New("yaml"), func(key string) error {
+			return ErrUnsupportedModule{Module: key}
+		})
+	}
+
+	imp.addUsers(cc)
+	imp.addGroups(cc.Groups)
+	imp.addHostname(cc)
+	imp.addWriteFiles(cc.WriteFiles)
+	imp.addMounts(cc.Mounts)
+	imp.addNTP(cc.NTP)
+	imp.addCommands("bootcmd", cc.Bootcmd, bootcmdUnit)
+	imp.addCommands("runcmd", cc.Runcmd, runcmdUnit)
+	return finish(input, imp.cfg, imp.r)
+}
+
+// user returns the user with the specified name, adding it if needed.
+func (imp *cloudInitImporter) user(name string) *User {
+	i, ok := imp.users[name]
+	if !ok {
+		i = len(imp.cfg.Passwd.Users)
+		imp.users[name] = i
+		imp.cfg.Passwd.Users = append(imp.cfg.Passwd.Users, User{Name: name})
+	}
+	return &imp.cfg.Passwd.Users[i]
+}
+
+func (imp *cloudInitImporter) addUsers(cc cloudConfig) {
+	if len(cc.SSHAuthorizedKeys) > 0 {
+		u := imp.user(defaultUser)
+		u.SSHAuthorizedKeys = append(u.SSHAuthorizedKeys, cc.SSHAuthorizedKeys...)
+	}
+
+	for i, n := range cc.Users {
+		p := path.New("yaml", "users", i)
+		// the distro default user already exists
+		if n.Kind == yaml.ScalarNode && n.Value == "default" {
+			continue
+		}
+		var cu cloudUser
+		if err := n.Decode(&cu); err != nil || cu.Name == "" {
+			imp.r.AddOnWarn(p, ErrInvalidEntry)
+			continue
+		}
+		warnUnknownKeys(&imp.r, &n, cu, p, func(key string) error {
+			return ErrUnsupportedKey{Key: key}
+		})
+
+		u := imp.user(cu.Name)
+		u.UID = cu.UID
+		u.Gecos = cu.Gecos
+		u.HomeDir = cu.Homedir
+		u.NoCreateHome = cu.NoCreateHome
+		u.PrimaryGroup = cu.PrimaryGroup
+		u.Groups = append(u.Groups, stringList(cu.Groups)...)
+		u.NoUserGroup = cu.NoUserGroup
+		u.Shell = cu.Shell
+		u.System = cu.System
+		u.SSHAuthorizedKeys = append(u.SSHAuthorizedKeys, cu.SSHAuthorizedKeys...)
+		if cu.HashedPasswd != "" {
+			u.PasswordHash = cu.HashedPasswd
+		} else if cu.Passwd != "" {
+			u.PasswordHash = cu.Passwd
+		}
+		if cu.PlainTextPasswd != "" {
+			imp.r.AddOnWarn(p.Append("plain_text_passwd"), ErrPlainTextPassword)
+		}
+		if len(cu.SSHImportID) > 0 {
+			imp.r.AddOnWarn(p.Append("ssh_import_id"), ErrSSHImportID)
+		}
+
+		var sudo []string
+		switch cu.Sudo.Kind {
+		case yaml.ScalarNode:
+			// sudo: false disables sudo
+			if cu.Sudo.Tag != "!!bool" && cu.Sudo.Tag != "!!null" {
+				sudo = []string{cu.Sudo.Value}
+			}
+		case yaml.SequenceNode:
+			sudo = stringList(cu.Sudo)
+		}
+		if len(sudo) > 0 {
+			var contents strings.Builder
+			for _, rule := range sudo {
+				fmt.Fprintf(&contents, "%s %s\n", cu.Name, rule)
+			}
+			imp.addFile(File{
+				Path:     "/etc/sudoers.d/" + cu.Name,
+				Mode:     modePtr(0440),
+				Contents: inline(contents.String()),
+			})
+		}
+	}
+}
+
+func (imp *cloudInitImporter) addGroups(groups []yaml.Node) {
+	for i, n := range groups {
+		switch n.Kind {
+		case yaml.ScalarNode:
+			imp.cfg.Passwd.Groups = append(imp.cfg.Passwd.Groups, Group{Name: n.Value})
+		case yaml.MappingNode:
+			// name: [members]
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				name := n.Content[j].Value
+				imp.cfg.Passwd.Groups = append(imp.cfg.Passwd.Groups, Group{Name: name})
+				for _, member := range stringList(*n.Content[j+1]) {
+					u := imp.user(member)
+					u.Groups = append(u.Groups, name)
+				}
+			}
+		default:
+			imp.r.AddOnWarn(path.New("yaml", "groups", i), ErrInvalidEntry)
+		}
+	}
+}
+
+func (imp *cloudInitImporter) addHostname(cc cloudConfig) {
+	hostname := cc.Hostname
+	if hostname == "" || (cc.PreferFQDN && cc.FQDN != "") {
+		hostname = cc.FQDN
+	}
+	if hostname == "" {
+		return
+	}
+	imp.addFile(File{
+		Path:     "/etc/hostname",
+		Mode:     modePtr(0644),
+		Contents: inline(hostname + "\n"),
+	})
+}
+
+func (imp *cloudInitImporter) addWriteFiles(writeFiles []yaml.Node) {
+	for i, n := range writeFiles {
+		p := path.New("yaml", "write_files", i)
+		var wf cloudWriteFile
+		if err := n.Decode(&wf); err != nil || wf.Path == "" {
+			imp.r.AddOnWarn(p, ErrInvalidEntry)
+			continue
+		}
+		warnUnknownKeys(&imp.r, &n, wf, p, func(key string) error {
+			return ErrUnsupportedKey{Key: key}
+		})
+
+		var resource Resource
+		if wf.Source != nil && wf.Source.URI != "" {
+			resource.Source = wf.Source.URI
+			var names []string
+			for name := range wf.Source.Headers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				resource.HTTPHeaders = append(resource.HTTPHeaders, HTTPHeader{Name: name, Value: wf.Source.Headers[name]})
+			}
+		} else {
+			contents, err := decodeContent(wf.Content, wf.Encoding)
+			if err != nil {
+				imp.r.AddOnWarn(p.Append("encoding"), err)
+				continue
+			}
+			resource = dataResource(contents)
+		}
+
+		// cloud-init defaults to 0644, and replaces existing files
+		f := File{
+			Path: wf.Path,
+			Mode: modePtr(0644),
+		}
+		if mode, err := parseMode(wf.Permissions); err != nil {
+			imp.r.AddOnWarn(p.Append("permissions"), err)
+		} else if mode != nil {
+			f.Mode = mode
+		}
+		if user, group, _ := strings.Cut(wf.Owner, ":"); wf.Owner != "" && wf.Owner != "root:root" {
+			if user != "" && user != "root" {
+				f.User = &NodeOwner{Name: user}
+			}
+			if group != "" && group != "root" {
+				f.Group = &NodeOwner{Name: group}
+			}
+		}
+		if wf.Defer {
+			imp.r.AddOnWarn(p.Append("defer"), ErrDeferredWrite)
+		}
+		if wf.Append {
+			f.Append = []Resource{resource}
+		} else {
+			f.Overwrite = true
+			f.Contents = &resource
+		}
+		imp.addFile(f)
+	}
+}
+
+func (imp *cloudInitImporter) addMounts(mounts [][]yaml.Node) {
+	for i, fields := range mounts {
+		p := path.New("yaml", "mounts", i)
+		// fs_spec, fs_file, fs_vfstype, fs_mntops, fs_freq, fs_passno
+		var spec, file, vfstype, mntops string
+		for j, field := range fields {
+			value := field.Value
+			if field.Tag == "!!null" {
+				value = ""
+			}
+			switch j {
+			case 0:
+				spec = value
+			case 1:
+				file = value
+			case 2:
+				vfstype = value
+			case 3:
+				mntops = value
+			}
+		}
+		if spec == "" || file == "" {
+			// an entry without a mount point removes a default mount
+			imp.r.AddOnWarn(p, ErrInvalidEntry)
+			continue
+		}
+
+		device := spec
+		if prefix, value, ok := strings.Cut(spec, "="); ok {
+			switch prefix {
+			case "LABEL":
+				device = "/dev/disk/by-label/" + value
+			case "UUID":
+				device = "/dev/disk/by-uuid/" + value
+			case "PARTLABEL":
+				device = "/dev/disk/by-partlabel/" + value
+			case "PARTUUID":
+				device = "/dev/disk/by-partuuid/" + value
+			}
+		} else if strings.HasPrefix(spec, "ephemeral") {
+			imp.r.AddOnWarn(p.Append(0), ErrEphemeralMount)
+			continue
+		} else if !strings.HasPrefix(spec, "/") {
+			device = "/dev/" + spec
+		}
+
+		fs := Filesystem{
+			Device:        device,
+			Format:        vfstype,
+			WithMountUnit: true,
+		}
+		if file == "none" || file == "swap" || vfstype == "swap" {
+			fs.Format = "swap"
+		} else {
+			fs.Path = file
+		}
+		if fs.Format == "" || fs.Format == "auto" {
+			imp.r.AddOnWarn(p.Append(2), ErrAutoFilesystem)
+			continue
+		}
+		if fs.Path != "" && fcosFamily(imp.cfg.Variant) {
+			fs.Path = varPath(fs.Path)
+			if !strings.HasPrefix(fs.Path, "/var/") && !strings.HasPrefix(fs.Path, "/etc/") {
+				imp.r.AddOnWarn(p.Append(1), ErrMountPoint)
+				continue
+			}
+		}
+		for _, opt := range strings.Split(mntops, ",") {
+			if opt != "" && opt != "defaults" {
+				fs.MountOptions = append(fs.MountOptions, opt)
+			}
+		}
+		imp.cfg.Storage.Filesystems = append(imp.cfg.Storage.Filesystems, fs)
+	}
+}
+
+func (imp *cloudInitImporter) addNTP(n yaml.Node) {
+	if n.Kind == 0 {
+		return
+	}
+	p := path.New("yaml", "ntp")
+	var ntp cloudNTP
+	if err := n.Decode(&ntp); err != nil {
+		imp.r.AddOnWarn(p, ErrInvalidEntry)
+		return
+	}
+	warnUnknownKeys(&imp.r, &n, ntp, p, func(key string) error {
+		return ErrUnsupportedKey{Key: key}
+	})
+	if ntp.Enabled != nil && !*ntp.Enabled {
+		imp.r.AddOnWarn(p.Append("enabled"), ErrNTPDisabled)
+		return
+	}
+	if len(ntp.Servers) == 0 && len(ntp.Pools) == 0 {
+		return
+	}
+
+	if !fcosFamily(imp.cfg.Variant) {
+		// Flatcar uses systemd-timesyncd
+		imp.addFile(File{
+			Path:     "/etc/systemd/timesyncd.conf.d/10-cloud-init.conf",
+			Mode:     modePtr(0644),
+			Contents: inline(fmt.Sprintf("[Time]\nNTP=%s\n", strings.Join(append(ntp.Servers, ntp.Pools...), " "))),
+		})
+		return
+	}
+	var sources strings.Builder
+	for _, server := range ntp.Servers {
+		fmt.Fprintf(&sources, "server %s iburst\n", server)
+	}
+	for _, pool := range ntp.Pools {
+		fmt.Fprintf(&sources, "pool %s iburst\n", pool)
+	}
+	imp.addFile(File{
+		Path:      "/etc/chrony.conf",
+		Overwrite: true,
+		Mode:      modePtr(0644),
+		Contents:  inline(fmt.Sprintf(chronyConf, sources.String())),
+	})
+}
+
+// addCommands adds a oneshot unit that runs bootcmd or runcmd commands.
+func (imp *cloudInitImporter) addCommands(module string, commands []yaml.Node, template string) {
+	var execs strings.Builder
+	for i, n := range commands {
+		var argv []string
+		switch n.Kind {
+		case yaml.ScalarNode:
+			argv = []string{"/bin/sh", "-c", n.Value}
+		case yaml.SequenceNode:
+			argv = stringList(n)
+		}
+		if len(argv) == 0 {
+			imp.r.AddOnWarn(path.New("yaml", module, i), ErrInvalidEntry)
+			continue
+		}
+		fmt.Fprintf(&execs, "ExecStart=%s\n", execQuote(argv))
+	}
+	if execs.Len() == 0 {
+		return
+	}
+	imp.cfg.Systemd.Units = append(imp.cfg.Systemd.Units, Unit{
+		Name:     fmt.Sprintf("cloud-init-%s.service", module),
+		Enabled:  boolPtr(true),
+		Contents: fmt.Sprintf(template, execs.String()),
+	})
+}
+
+func (imp *cloudInitImporter) addFile(f File) {
+	imp.cfg.Storage.Files = append(imp.cfg.Storage.Files, f)
+}
+
+// decodeContent decodes write_files content.
+func decodeContent(content, encoding string) ([]byte, error) {
+	encoding = strings.ToLower(encoding)
+	switch encoding {
+	case "", "text/plain":
+		return []byte(content), nil
+	case "b64", "base64", "gz", "gzip", "gz+b64", "gz+base64", "gzip+b64", "gzip+base64":
+	default:
+		return nil, ErrUnknownEncoding
+	}
+	// binary gzip content is decoded by the YAML parser
+	data := []byte(content)
+	if strings.HasSuffix(encoding, "b64") || strings.HasSuffix(encoding, "base64") {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(content), "")); err != nil {
+			return nil, err
+		}
+	}
+	if strings.HasPrefix(encoding, "gz") {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+	return data, nil
+}
+
+// dataResource returns a resource with the specified contents, inline if
+// they're text.  Butane compresses them as needed.
+func dataResource(contents []byte) Resource {
+	if utf8.Valid(contents) {
+		return *inline(string(contents))
+	}
+	return Resource{
+		Source: dataurl.New(contents, "application/octet-stream").String(),
+	}
+}
+
+// parseMode parses write_files permissions, which are usually an octal
+// string.
+func parseMode(n yaml.Node) (*Mode, error) {
+	if n.Kind == 0 || n.Tag == "!!null" {
+		return nil, nil
+	}
+	value := strings.TrimPrefix(strings.TrimPrefix(n.Value, "0o"), "0")
+	base := 8
+	// YAML 1.2 decimal, as written by tools
+	if n.Tag == "!!int" && !strings.HasPrefix(n.Value, "0") {
+		base = 10
+	}
+	if value == "" {
+		value = "0"
+	}
+	mode, err := strconv.ParseInt(value, base, 32)
+	if err != nil {
+		return nil, err
+	}
+	return modePtr(int(mode)), nil
+}
+
+// varPath maps a path under a directory that Fedora CoreOS symlinks into
+// /var to the symlink target.
+func varPath(p string) string {
+	for _, dir := range []string{"/mnt", "/home", "/srv", "/opt"} {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return "/var" + p
+		}
+	}
+	return p
+}
+
+// execQuote quotes a command line for a systemd Exec setting.
+func execQuote(argv []string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "%", "%%", "$", "$$")
+	var quoted []string
+	for _, arg := range argv {
+		escaped := replacer.Replace(arg)
+		if escaped == "" || escaped != arg || strings.ContainsAny(arg, " \t'") {
+			escaped = `"` + escaped + `"`
+		}
+		quoted = append(quoted, escaped)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// stringList returns the strings in a sequence node, or in a
+// comma-separated scalar.
+func stringList(n yaml.Node) []string {
+	var ret []string
+	switch n.Kind {
+	case yaml.ScalarNode:
+		for _, s := range strings.Split(n.Value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				ret = append(ret, s)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if item.Kind == yaml.ScalarNode {
+				ret = append(ret, item.Value)
+			}
+		}
+	}
+	return ret
+}
+
+func inline(s string) *Resource {
+	return &Resource{Inline: &s}
+}
+
+func modePtr(m int) *Mode {
+	mode := Mode(m)
+	return &mode
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
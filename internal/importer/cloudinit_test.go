@@ -0,0 +1,177 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package importer
+
+import (
+	"testing"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromCloudInit(t *testing.T) {
+	in := `#cloud-config
+hostname: web1
+users:
+  - default
+  - name: app
+    gecos: App User
+    groups: wheel, docker
+    uid: 1500
+    sudo: ALL=(ALL) NOPASSWD:ALL
+    hashed_passwd: $6$abc
+    plain_text_passwd: hunter2
+    ssh_authorized_keys: [ssh-ed25519 BBBB]
+ssh_authorized_keys:
+  - ssh-ed25519 AAAA
+write_files:
+  - path: /etc/app.conf
+    content: |
+      key=value
+    owner: app:app
+    permissions: '0600'
+  - path: /etc/motd
+    encoding: gz+b64
+    content: H4sIAAAAAAAAA8tIzcnJBwCGphA2BQAAAA==
+    append: true
+    defer: true
+mounts:
+  - [LABEL=data, /mnt/data, xfs, "defaults,nofail"]
+  - [ephemeral0, /mnt/scratch]
+  - [/dev/vdc, /data, ext4]
+ntp:
+  servers: [ntp.example.com]
+bootcmd:
+  - echo early > /run/early
+runcmd:
+  - [systemctl, restart, "my service"]
+  - echo "100%" done
+packages: [nginx]
+`
+	expected := `variant: fcos
+version: 1.6.0
+passwd:
+  users:
+    - name: core
+      ssh_authorized_keys:
+        - ssh-ed25519 AAAA
+    - name: app
+      uid: 1500
+      gecos: App User
+      groups:
+        - wheel
+        - docker
+      password_hash: $6$abc
+      ssh_authorized_keys:
+        - ssh-ed25519 BBBB
+storage:
+  filesystems:
+    - device: /dev/disk/by-label/data
+      path: /var/mnt/data
+      format: xfs
+      mount_options:
+        - nofail
+      with_mount_unit: true
+  files:
+    - path: /etc/sudoers.d/app
+      mode: 0440
+      contents:
+        inline: |
+          app ALL=(ALL) NOPASSWD:ALL
+    - path: /etc/hostname
+      mode: 0644
+      contents:
+        inline: |
+          web1
+    - path: /etc/app.conf
+      overwrite: true
+      mode: 0600
+      user:
+        name: app
+      group:
+        name: app
+      contents:
+        inline: |
+          key=value
+    - path: /etc/motd
+      mode: 0644
+      append:
+        - inline: hello
+    - path: /etc/chrony.conf
+      overwrite: true
+      mode: 0644
+      contents:
+        inline: |
+          # Generated from cloud-init ntp module
+          server ntp.example.com iburst
+          driftfile /var/lib/chrony/drift
+          makestep 1.0 3
+          rtcsync
+systemd:
+  units:
+    - name: cloud-init-bootcmd.service
+      enabled: true
+      contents: |
+        [Unit]
+        Description=Run cloud-init bootcmd commands
+        DefaultDependencies=no
+        After=local-fs.target
+        Before=sysinit.target
+
+        [Service]
+        Type=oneshot
+        RemainAfterExit=yes
+        ExecStart=/bin/sh -c "echo early > /run/early"
+
+        [Install]
+        WantedBy=sysinit.target
+    - name: cloud-init-runcmd.service
+      enabled: true
+      contents: |
+        [Unit]
+        Description=Run cloud-init runcmd commands
+        Wants=network-online.target
+        After=network-online.target
+        ConditionFirstBoot=yes
+
+        [Service]
+        Type=oneshot
+        RemainAfterExit=yes
+        ExecStart=systemctl restart "my service"
+        ExecStart=/bin/sh -c "echo \"100%%\" done"
+
+        [Install]
+        WantedBy=multi-user.target
+`
+
+	out, r, err := FromCloudInit([]byte(in), "fcos", "1.6.0")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(out), "bad output")
+	assert.Equal(t, "warning at $.packages, line 37 col 11: "+ErrUnsupportedModule{Module: "packages"}.Error()+"\n"+
+		"warning at $.users.1.plain_text_passwd, line 11 col 24: "+ErrPlainTextPassword.Error()+"\n"+
+		"warning at $.write_files.1.defer, line 25 col 12: "+ErrDeferredWrite.Error()+"\n"+
+		"warning at $.mounts.1.0, line 28 col 6: "+ErrEphemeralMount.Error()+"\n"+
+		"warning at $.mounts.2.1, line 29 col 16: "+ErrMountPoint.Error()+"\n",
+		r.String(), "bad report")
+
+	_, r, err = config.TranslateBytes(out, common.TranslateBytesOptions{})
+	assert.NoError(t, err, "output doesn't translate")
+	assert.Empty(t, r.Entries, "output has report entries")
+
+	_, _, err = FromCloudInit([]byte("#!/bin/sh\necho hi\n"), "fcos", "1.6.0")
+	assert.Equal(t, ErrNotCloudConfig, err, "bad error for script")
+}
@@ -15,14 +15,20 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
+	"github.com/coreos/vcontext/report"
 	"github.com/spf13/pflag"
 
 	"github.com/coreos/butane/config"
 	"github.com/coreos/butane/config/common"
+	cutil "github.com/coreos/butane/config/util"
+	"github.com/coreos/butane/internal/archivefs"
+	"github.com/coreos/butane/internal/render"
 	breport "github.com/coreos/butane/internal/report"
 	"github.com/coreos/butane/internal/version"
 )
@@ -40,57 +46,275 @@ func isCharDevice(f *os.File) bool {
 	return stat.Mode()&os.ModeCharDevice != 0
 }
 
+// parseFilesDir parses a --files-dir argument of the form [NAME=]DIR.
+// An unnamed DIR containing "=" can be written as ./DIR, since names
+// can't contain path separators.
+func parseFilesDir(arg string) common.FilesDirEntry {
+	if name, dir, ok := strings.Cut(arg, "="); ok && name != "" && !strings.ContainsAny(name, `/\`) {
+		return common.FilesDirEntry{Name: name, Path: dir}
+	}
+	return common.FilesDirEntry{Path: arg}
+}
+
+// hasWarnings returns true if the report contains any entries more severe
+// than informational messages.
+func hasWarnings(r report.Report) bool {
+	for _, e := range r.Entries {
+		if e.Kind != report.Info {
+			return true
+		}
+	}
+	return false
+}
+
+// commonFlags are the command-line options shared by commands that
+// translate configs.
+type commonFlags struct {
+	options     common.TranslateBytesOptions
+	colorFlag   string
+	strict      bool
+	rawErrors   bool
+	filesDirs   []string
+	urlMaps     []string
+	variantDefs []string
+	cacheDir    string
+}
+
+func (cf *commonFlags) register(flags *pflag.FlagSet) {
+	flags.BoolVarP(&cf.options.DebugPrintTranslations, "debug", "D", false, "log translations")
+	flags.Lookup("debug").Hidden = true
+	flags.BoolVarP(&cf.strict, "strict", "s", false, "fail on any warning")
+	flags.BoolVarP(&cf.options.Pretty, "pretty", "p", false, "output formatted json")
+	flags.BoolVar(&cf.rawErrors, "raw-errors", false, "show raw errors, rather than pretty printing them")
+	flags.StringVar(&cf.colorFlag, "color", "auto", `control color output: "auto", "always", or "never"`)
+	flags.Lookup("color").NoOptDefVal = "always"
+	flags.StringVar(&cf.colorFlag, "colour", "auto", `control color output: "auto", "always", or "never"`)
+	flags.Lookup("colour").NoOptDefVal = "always"
+	flags.Lookup("colour").Hidden = true
+	flags.StringArrayVarP(&cf.filesDirs, "files-dir", "d", nil, "allow embedding local files from `[NAME=]DIR` or tar/zip archive; repeat to search multiple directories in order; specify a DIR containing \"=\" as ./DIR")
+	flags.BoolVar(&cf.options.FilesDirStrict, "files-dir-strict", false, "fail if a local path exists in more than one files directory")
+	flags.BoolVar(&cf.options.InlineRemote, "inline-remote", false, "fetch remote resources and embed them in the config")
+	flags.StringArrayVar(&cf.urlMaps, "url-map", nil, "with --inline-remote, read URLs under a `PREFIX=DIR` mapping from the local DIR; repeatable")
+	flags.StringVar(&cf.cacheDir, "cache-dir", "", "cache encoded inline and local file contents in `DIR`, which can be shared")
+	flags.StringArrayVar(&cf.variantDefs, "variant-def", nil, "register a custom variant from a definition `FILE`; repeatable")
+}
+
+// finish validates the parsed options and fills in the ones that can't
+// be set directly by flags.
+func (cf *commonFlags) finish() {
+	for _, name := range cf.variantDefs {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			fail("failed to read variant definition: %v\n", err)
+		}
+		def, err := config.ParseVariantDef(data)
+		if err == nil {
+			err = config.RegisterVariantDef(def)
+		}
+		if err != nil {
+			fail("failed to register variant definition %s: %v\n", name, err)
+		}
+	}
+
+	for _, arg := range cf.filesDirs {
+		dir := parseFilesDir(arg)
+		// a regular file is treated as a tar or zip archive
+		if info, err := os.Stat(dir.Path); err == nil && info.Mode().IsRegular() {
+			dir.FS, err = archivefs.Open(dir.Path)
+			if err != nil {
+				fail("failed to open files-dir archive %s: %v\n", dir.Path, err)
+			}
+		}
+		cf.options.FilesDirs = append(cf.options.FilesDirs, dir)
+	}
+
+	if cf.cacheDir != "" {
+		cf.options.DataURLCache = cutil.DirCache{
+			Dir: cf.cacheDir,
+		}
+	}
+
+	if len(cf.urlMaps) > 0 {
+		if !cf.options.InlineRemote {
+			fail("--url-map requires --inline-remote\n")
+		}
+		fetcher := cutil.URLMapFetcher{}
+		for _, arg := range cf.urlMaps {
+			i := strings.LastIndex(arg, "=")
+			if i <= 0 {
+				fail("--url-map argument must be of the form PREFIX=DIR: %s\n", arg)
+			}
+			fetcher.Mappings = append(fetcher.Mappings, cutil.URLMapping{
+				Prefix: arg[:i],
+				Dir:    arg[i+1:],
+			})
+		}
+		cf.options.Fetcher = fetcher
+	}
+}
+
+func (cf *commonFlags) colorize() bool {
+	switch cf.colorFlag {
+	case "always", "yes":
+		return true
+	case "auto":
+		_, noColorSet := os.LookupEnv("NO_COLOR")
+		return !noColorSet && isCharDevice(os.Stderr)
+	default:
+		return false
+	}
+}
+
+// printReport prints r and fails if err is set, or if r has warnings and
+// --strict was specified.
+func (cf *commonFlags) printReport(r report.Report, err error, filename string, dataIn []byte) {
+	errorString := breport.FormatError(r, filename, dataIn, cf.colorize(), cf.rawErrors)
+	fmt.Fprintf(os.Stderr, "%s", errorString)
+
+	if err != nil {
+		fail("Error translating config: %v\n", err)
+	}
+	if cf.strict && hasWarnings(r) {
+		fail("Config produced warnings and --strict was specified\n")
+	}
+}
+
+// readInput reads the named file, or stdin if input is empty, and returns
+// its contents and a name for error messages.
+func readInput(input string) ([]byte, string) {
+	infile := os.Stdin
+	filename := "<stdin>"
+	if input != "" {
+		var err error
+		infile, err = os.Open(input)
+		if err != nil {
+			fail("failed to open %s: %v\n", input, err)
+		}
+		defer infile.Close()
+		filename = input
+	}
+
+	dataIn, err := io.ReadAll(infile)
+	if err != nil {
+		fail("failed to read %s: %v\n", infile.Name(), err)
+	}
+	return dataIn, filename
+}
+
+// writeOutput writes data and a trailing newline to the named file, or
+// stdout if output is empty.
+func writeOutput(output string, data []byte) {
+	outfile := os.Stdout
+	if output != "" {
+		var err error
+		outfile, err = os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			fail("failed to open %s: %v\n", output, err)
+		}
+		defer outfile.Close()
+	}
+
+	if _, err := outfile.Write(append(data, '\n')); err != nil {
+		fail("Failed to write config to %s: %v\n", outfile.Name(), err)
+	}
+}
+
+// formatLocation returns the source location of the config field at p,
+// or of its closest ancestor that has one, as FILE:LINE:COLUMN.
+func formatLocation(sourceMap common.SourceMap, p, filename string) string {
+	for p != "" {
+		if loc, ok := sourceMap[p]; ok {
+			if loc.File == "" {
+				loc.File = filename
+			}
+			return fmt.Sprintf("%s:%d:%d", loc.File, loc.Line, loc.Column)
+		}
+		i := strings.LastIndex(p, ".")
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	return "<generated>"
+}
+
+// printSkipped lists the parts of a config that weren't rendered.
+func printSkipped(skipped []render.Skip, sourceMap common.SourceMap, filename string) {
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "%s: skipped %s\n", formatLocation(sourceMap, s.Source, filename), s.Description)
+	}
+}
+
+// subcommands maps subcommand names to their implementations, which
+// receive the arguments following the subcommand name.  An input file
+// with the same name as a subcommand can be specified as ./NAME.
+var subcommands = map[string]func(args []string){
+	"blame":         blameMain,
+	"containerfile": containerfileMain,
+	"convert":       convertMain,
+	"flatten":       flattenMain,
+	"import":        importMain,
+	"min-version":   minVersionMain,
+	"render":        renderMain,
+	"serve":         serveMain,
+	"verify":        verifyMain,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
+	}
+
 	var (
 		input       string
 		output      string
-		colorFlag   string
+		sourceMap   string
 		check       bool
-		strict      bool
 		helpFlag    bool
 		versionFlag bool
-		rawErrors   bool
-		colorize    bool
+		listFlag    bool
+		wrap        string
+		wrapper     common.Wrapper
+		wrapLabels  []string
+		cf          commonFlags
 	)
-	options := common.TranslateBytesOptions{}
 	pflag.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
 	pflag.BoolVarP(&versionFlag, "version", "V", false, "print the version and exit")
-	pflag.BoolVarP(&options.DebugPrintTranslations, "debug", "D", false, "log translations")
-	pflag.Lookup("debug").Hidden = true
+	pflag.BoolVar(&listFlag, "list-versions", false, "list supported variants and spec versions and exit")
 	pflag.BoolVarP(&check, "check", "c", false, "check config without producing output")
-	pflag.BoolVarP(&strict, "strict", "s", false, "fail on any warning")
-	pflag.BoolVarP(&options.Pretty, "pretty", "p", false, "output formatted json")
-	pflag.BoolVarP(&options.Raw, "raw", "r", false, "never wrap in a MachineConfig; force Ignition output")
-	pflag.BoolVar(&rawErrors, "raw-errors", false, "show raw errors, rather than pretty printing them")
-	pflag.StringVar(&colorFlag, "color", "auto", `control color output: "auto", "always", or "never"`)
-	pflag.Lookup("color").NoOptDefVal = "always"
-	pflag.StringVar(&colorFlag, "colour", "auto", `control color output: "auto", "always", or "never"`)
-	pflag.Lookup("colour").NoOptDefVal = "always"
-	pflag.Lookup("colour").Hidden = true
+	pflag.BoolVarP(&cf.options.Raw, "raw", "r", false, "never wrap in a MachineConfig; force Ignition output")
+	pflag.StringVar(&cf.options.IgnitionVersion, "ignition-version", "", "output an older Ignition spec `VERSION` if the config allows it")
 	pflag.StringVar(&input, "input", "", "read from input file instead of stdin")
 	pflag.Lookup("input").Deprecated = "specify filename directly on command line"
 	pflag.Lookup("input").Hidden = true
 	pflag.StringVarP(&output, "output", "o", "", "write to output file instead of stdout")
-	pflag.StringVarP(&options.FilesDir, "files-dir", "d", "", "allow embedding local files from this directory")
+	pflag.StringVar(&sourceMap, "source-map", "", "write the source location of each field in the output to `FILE` as JSON, keyed by JSON Pointer")
+	pflag.StringVar(&wrap, "wrap", "", "embed the output in a Kubernetes `KIND`: \"secret\" or \"configmap\"")
+	pflag.StringVar(&wrapper.Name, "wrap-name", "", "name of the --wrap object")
+	pflag.StringVar(&wrapper.Namespace, "wrap-namespace", "", "namespace of the --wrap object")
+	pflag.StringArrayVar(&wrapLabels, "wrap-label", nil, "add a `KEY=VALUE` label to the --wrap object; repeatable")
+	pflag.StringVar(&wrapper.Key, "wrap-key", "value", "data key for the config in the --wrap object")
+	cf.register(pflag.CommandLine)
 
 	pflag.Usage = func() {
 		fmt.Fprintf(pflag.CommandLine.Output(), "Usage: %s [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s blame [options] [input-file] FIELD\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s containerfile -o DIR [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s convert --to VARIANT:VERSION [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s flatten [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s import --from FORMAT [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s min-version [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s render --root DIR|--tar FILE [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s serve [options]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s verify --root DIR [options] [input-file]\n", os.Args[0])
 		fmt.Fprintf(pflag.CommandLine.Output(), "Options:\n")
 		pflag.PrintDefaults()
 	}
 	pflag.Parse()
 
-	switch colorFlag {
-	case "always", "yes":
-		colorize = true
-	case "never", "no":
-		colorize = false
-	case "auto":
-		_, noColorSet := os.LookupEnv("NO_COLOR")
-		isTTY := isCharDevice(os.Stderr)
-		colorize = !noColorSet && isTTY
-	}
-
 	args := pflag.Args()
 	if len(args) == 1 && input == "" {
 		input = args[0]
@@ -110,48 +334,81 @@ func main() {
 		os.Exit(0)
 	}
 
-	infile := os.Stdin
-	filename := "<stdin>"
-	if input != "" {
-		var err error
-		infile, err = os.Open(input)
-		if err != nil {
-			fail("failed to open %s: %v\n", input, err)
+	cf.finish()
+	if listFlag {
+		for _, v := range config.RegisteredVersions() {
+			if v.Experimental {
+				fmt.Printf("%s %s (experimental)\n", v.Variant, v.Version)
+			} else {
+				fmt.Printf("%s %s\n", v.Variant, v.Version)
+			}
 		}
-		defer infile.Close()
-		filename = input
-	}
-
-	dataIn, err := io.ReadAll(infile)
-	if err != nil {
-		fail("failed to read %s: %v\n", infile.Name(), err)
+		os.Exit(0)
 	}
 
-	dataOut, r, err := config.TranslateBytes(dataIn, options)
-
-	errorString := breport.FormatError(r, filename, dataIn, colorize, rawErrors)
-	fmt.Fprintf(os.Stderr, "%s", errorString)
-
-	if err != nil {
-		fail("Error translating config: %v\n", err)
+	switch strings.ToLower(wrap) {
+	case "":
+		if wrapper.Name != "" || wrapper.Namespace != "" || len(wrapLabels) > 0 {
+			fail("--wrap-name, --wrap-namespace, and --wrap-label require --wrap\n")
+		}
+	case "secret", "configmap":
+		if wrapper.Name == "" {
+			fail("--wrap requires --wrap-name\n")
+		}
+		wrapper.Kind = map[string]string{"secret": "Secret", "configmap": "ConfigMap"}[strings.ToLower(wrap)]
+		for _, arg := range wrapLabels {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok || key == "" {
+				fail("--wrap-label argument must be of the form KEY=VALUE: %s\n", arg)
+			}
+			if wrapper.Labels == nil {
+				wrapper.Labels = make(map[string]string)
+			}
+			wrapper.Labels[key] = value
+		}
+		cf.options.Wrapper = &wrapper
+	default:
+		fail("--wrap must be \"secret\" or \"configmap\"\n")
 	}
-	if strict && len(r.Entries) > 0 {
-		fail("Config produced warnings and --strict was specified\n")
+	if sourceMap != "" {
+		if cf.options.Wrapper != nil {
+			fail("--source-map can't be used with --wrap\n")
+		}
+		cf.options.SourceMap = make(common.SourceMap)
 	}
+	dataIn, filename := readInput(input)
+	dataOut, r, err := config.TranslateBytes(dataIn, cf.options)
+	cf.printReport(r, err, filename, dataIn)
 
+	if sourceMap != "" {
+		writeSourceMap(sourceMap, dataOut, cf.options.SourceMap, filename, cf.options.Pretty)
+	}
 	if !check {
-		outfile := os.Stdout
-		if output != "" {
-			var err error
-			outfile, err = os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-			if err != nil {
-				fail("failed to open %s: %v\n", output, err)
-			}
-			defer outfile.Close()
-		}
+		writeOutput(output, dataOut)
+	}
+}
 
-		if _, err := outfile.Write(append(dataOut, '\n')); err != nil {
-			fail("Failed to write config to %s: %v\n", outfile.Name(), err)
+// writeSourceMap writes the source location of each field in dataOut,
+// keyed by JSON Pointer, to the named file.
+func writeSourceMap(name string, dataOut []byte, sourceMap common.SourceMap, filename string, pretty bool) {
+	pointerMap, err := cutil.PointerSourceMap(dataOut, sourceMap)
+	if err != nil {
+		fail("failed to read output config: %v\n", err)
+	}
+	for key, loc := range pointerMap {
+		if loc.File == "" {
+			loc.File = filename
+			pointerMap[key] = loc
 		}
 	}
+	var mapOut []byte
+	if pretty {
+		mapOut, err = json.MarshalIndent(pointerMap, "", "  ")
+	} else {
+		mapOut, err = json.Marshal(pointerMap)
+	}
+	if err != nil {
+		fail("failed to marshal source map: %v\n", err)
+	}
+	writeOutput(name, mapOut)
 }
@@ -0,0 +1,97 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/coreos/vcontext/report"
+	"github.com/spf13/pflag"
+
+	"github.com/coreos/butane/internal/convert"
+)
+
+// minVersionMain implements "butane min-version", which finds the lowest
+// spec version of a config's variant that accepts the config.
+func minVersionMain(args []string) {
+	var (
+		write    bool
+		helpFlag bool
+		cf       commonFlags
+	)
+	flags := pflag.NewFlagSet("min-version", pflag.ExitOnError)
+	flags.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
+	flags.BoolVarP(&write, "write", "w", false, "rewrite the version field of the input file to the minimum")
+	cf.register(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s min-version [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Report the lowest spec version that accepts a config, and the fields\n")
+		fmt.Fprintf(flags.Output(), "that each lower version rejects.\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if helpFlag {
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		os.Exit(0)
+	}
+	var input string
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		input = flags.Arg(0)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if write && input == "" {
+		fail("--write requires an input file\n")
+	}
+
+	cf.finish()
+	dataIn, filename := readInput(input)
+	result, r, err := convert.MinVersion(dataIn, cf.options)
+	cf.printReport(r, err, filename, dataIn)
+
+	for _, rejection := range result.Rejected {
+		for _, e := range rejection.Entries {
+			fmt.Printf("%s %s: %s: %s: %s\n", result.Variant, rejection.Version, entryLocation(e, filename), e.Kind, e.Message)
+		}
+	}
+	fmt.Printf("Minimum version: %s %s\n", result.Variant, result.Version)
+
+	if write {
+		dataOut, err := convert.SetVersion(dataIn, result.Version.String())
+		if err != nil {
+			fail("failed to update version: %v\n", err)
+		}
+		if err := os.WriteFile(input, dataOut, 0644); err != nil {
+			fail("failed to write %s: %v\n", input, err)
+		}
+	}
+}
+
+// entryLocation returns the location of a report entry as FILE:LINE:COLUMN,
+// or FILE:PATH if its line is unknown.
+func entryLocation(e report.Entry, filename string) string {
+	if e.Marker.StartP != nil {
+		return fmt.Sprintf("%s:%d:%d", filename, e.Marker.StartP.Line, e.Marker.StartP.Column)
+	}
+	return fmt.Sprintf("%s:%s", filename, e.Context)
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/spf13/pflag"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/internal/render"
+)
+
+// renderMain implements "butane render", which writes the files, links,
+// and units in the effective config to a directory or tar archive.
+func renderMain(args []string) {
+	var (
+		root     string
+		tarFile  string
+		helpFlag bool
+		cf       commonFlags
+	)
+	flags := pflag.NewFlagSet("render", pflag.ExitOnError)
+	flags.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
+	flags.StringVar(&root, "root", "", "write files to empty or nonexistent directory `DIR`")
+	flags.StringVar(&tarFile, "tar", "", "write files to tar archive `FILE`, or stdout if \"-\"")
+	cf.register(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s render --root DIR|--tar FILE [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Write the files, directories, links, and systemd units in a config to a\n")
+		fmt.Fprintf(flags.Output(), "directory or tar archive.  Disks, users, and other parts of the config\n")
+		fmt.Fprintf(flags.Output(), "that aren't files are listed as skipped.\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if helpFlag {
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		os.Exit(0)
+	}
+	var input string
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		input = flags.Arg(0)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if (root == "") == (tarFile == "") {
+		fail("exactly one of --root or --tar must be specified\n")
+	}
+
+	cf.finish()
+	dataIn, filename := readInput(input)
+	dataOut, sourceMap, r, err := config.Flatten(dataIn, cf.options)
+	cf.printReport(r, err, filename, dataIn)
+
+	// the latest spec is a superset of older ones for everything we
+	// render
+	var cfg types.Config
+	if err := json.Unmarshal(dataOut, &cfg); err != nil {
+		fail("failed to parse translated config: %v\n", err)
+	}
+	tree, err := render.Render(cfg)
+	if err != nil {
+		fail("Error rendering config: %v\n", err)
+	}
+	printSkipped(tree.Skipped, sourceMap, filename)
+
+	if root != "" {
+		if err := tree.WriteDir(root); err != nil {
+			fail("failed to write %s: %v\n", root, err)
+		}
+		return
+	}
+	outfile := os.Stdout
+	if tarFile != "-" {
+		outfile, err = os.OpenFile(tarFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			fail("failed to open %s: %v\n", tarFile, err)
+		}
+		defer outfile.Close()
+	}
+	if err := tree.WriteTar(outfile); err != nil {
+		fail("failed to write %s: %v\n", outfile.Name(), err)
+	}
+}
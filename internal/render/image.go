@@ -0,0 +1,245 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+)
+
+const (
+	sysusersPath = "/usr/lib/sysusers.d/35-butane.conf"
+	kargsPath    = "/usr/lib/bootc/kargs.d/35-butane.toml"
+)
+
+// Image is the build context for a derived container image that applies
+// a config at build time.
+type Image struct {
+	// Files to copy into the image
+	Tree          *Tree
+	Containerfile []byte
+	// Parts of the config that can't be applied at build time
+	Warnings []Skip
+}
+
+// BuildImage renders cfg into the build context for an image derived
+// from the base image from.  Files, directories, links, and units are
+// copied into the image; users and groups become a sysusers.d config;
+// and kernel arguments become a bootc kargs.d config.  Storage devices,
+// remote resources, and the parts of users that depend on a home
+// directory or password database are reported as warnings.
+func BuildImage(cfg types.Config, from string) (*Image, error) {
+	t, err := Render(cfg)
+	if err != nil {
+		return nil, err
+	}
+	img := &Image{Tree: t}
+	for _, s := range t.Skipped {
+		// handled below
+		if strings.HasPrefix(s.Source, "$.passwd.") || s.Source == "$.kernelArguments" {
+			continue
+		}
+		img.warn(s.Source, "%s can't be applied at build time", s.Description)
+	}
+	t.Skipped = nil
+
+	if err := img.addSysusers(cfg.Passwd); err != nil {
+		return nil, err
+	}
+	if err := img.addKargs(cfg.KernelArguments); err != nil {
+		return nil, err
+	}
+
+	// bootc only copies /var from the image on first deployment
+	var chowns []string
+	err = t.Walk(func(n *Node) error {
+		if !n.Implicit && (n.Path == "/var" || strings.HasPrefix(n.Path, "/var/")) {
+			img.warn(n.Source, "%s is only created when the image is first deployed", n.Path)
+		}
+		if owner := n.owner(); owner != "" {
+			chowns = append(chowns, fmt.Sprintf("chown -h %s %s", owner, shellQuote(n.Path)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var units []string
+	for _, u := range cfg.Systemd.Units {
+		if u.Enabled != nil {
+			units = append(units, shellQuote(u.Name))
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Generated by Butane\n")
+	fmt.Fprintf(&buf, "FROM %s\n", from)
+	if len(t.root.children) > 0 {
+		fmt.Fprintf(&buf, "COPY rootfs/ /\n")
+	}
+	if _, err := t.lookup(sysusersPath, 0); err == nil {
+		// chown by name requires the users to exist
+		fmt.Fprintf(&buf, "RUN systemd-sysusers %s\n", sysusersPath)
+	}
+	if len(chowns) > 0 {
+		fmt.Fprintf(&buf, "RUN %s\n", strings.Join(chowns, " && \\\n    "))
+	}
+	if len(units) > 0 {
+		// apply the Ignition preset file, including to units not
+		// defined by the config
+		fmt.Fprintf(&buf, "RUN systemctl preset %s\n", strings.Join(units, " "))
+	}
+	img.Containerfile = buf.Bytes()
+	return img, nil
+}
+
+// addSysusers writes a sysusers.d config for the users and groups in
+// passwd.
+func (img *Image) addSysusers(passwd types.Passwd) error {
+	var buf bytes.Buffer
+	for i, g := range passwd.Groups {
+		source := fmt.Sprintf("$.passwd.groups.%d", i)
+		if g.ShouldExist != nil && !*g.ShouldExist {
+			img.warn(source+".shouldExist", "group %s can't be removed at build time", g.Name)
+			continue
+		}
+		if g.PasswordHash != nil {
+			img.warn(source+".passwordHash", "password hash for group %s can't be set at build time", g.Name)
+		}
+		fmt.Fprintf(&buf, "g %s %s\n", g.Name, intField(g.Gid))
+	}
+	for i, u := range passwd.Users {
+		source := fmt.Sprintf("$.passwd.users.%d", i)
+		if u.ShouldExist != nil && !*u.ShouldExist {
+			img.warn(source+".shouldExist", "user %s can't be removed at build time", u.Name)
+			continue
+		}
+		if u.PasswordHash != nil {
+			img.warn(source+".passwordHash", "password hash for user %s can't be set at build time", u.Name)
+		}
+		if len(u.SSHAuthorizedKeys) > 0 {
+			img.warn(source+".sshAuthorizedKeys", "SSH keys for user %s can't be installed at build time", u.Name)
+		}
+		id := intField(u.UID)
+		if u.PrimaryGroup != nil {
+			if u.UID == nil {
+				img.warn(source+".primaryGroup", "primary group for user %s requires a UID at build time", u.Name)
+			} else {
+				id += ":" + *u.PrimaryGroup
+			}
+		}
+		fmt.Fprintf(&buf, "u %s %s %s %s %s\n", u.Name, id, quotedField(u.Gecos), stringField(u.HomeDir), stringField(u.Shell))
+		for _, g := range u.Groups {
+			fmt.Fprintf(&buf, "m %s %s\n", u.Name, g)
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	return img.add(sysusersPath, buf.Bytes())
+}
+
+// addKargs writes a bootc kargs.d config for the kernel arguments that
+// should exist.
+func (img *Image) addKargs(kargs types.KernelArguments) error {
+	for i, arg := range kargs.ShouldNotExist {
+		img.warn(fmt.Sprintf("$.kernelArguments.shouldNotExist.%d", i), "kernel argument %s can't be removed at build time", arg)
+	}
+	if len(kargs.ShouldExist) == 0 {
+		return nil
+	}
+	var args []string
+	for _, arg := range kargs.ShouldExist {
+		args = append(args, fmt.Sprintf("%q", string(arg)))
+	}
+	return img.add(kargsPath, []byte(fmt.Sprintf("kargs = [%s]\n", strings.Join(args, ", "))))
+}
+
+func (img *Image) add(p string, contents []byte) error {
+	if err := img.Tree.add(p, &Node{Type: TypeFile, Mode: 0644, Contents: contents}); err != nil {
+		return fmt.Errorf("%s: %w", p, err)
+	}
+	return nil
+}
+
+func (img *Image) warn(source string, format string, args ...interface{}) {
+	img.Warnings = append(img.Warnings, Skip{
+		Source:      source,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+// Write writes the Containerfile and the rootfs directory to dir, which
+// must be empty or not exist.
+func (img *Image) Write(dir string) error {
+	if err := img.Tree.WriteDir(filepath.Join(dir, "rootfs")); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "Containerfile"), img.Containerfile, 0644)
+}
+
+// owner returns the chown argument for the node, or an empty string if
+// it's owned by root.
+func (n *Node) owner() string {
+	user := n.User
+	if n.UID != nil {
+		user = fmt.Sprint(*n.UID)
+	}
+	group := n.Group
+	if n.GID != nil {
+		group = fmt.Sprint(*n.GID)
+	}
+	if user == "" && group == "" {
+		return ""
+	}
+	return shellQuote(user + ":" + group)
+}
+
+func intField(v *int) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprint(*v)
+}
+
+func stringField(v *string) string {
+	if v == nil || *v == "" {
+		return "-"
+	}
+	return *v
+}
+
+func quotedField(v *string) string {
+	if v == nil || *v == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%q", *v)
+}
+
+// shellQuote quotes s for a shell, if needed.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-", r)
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+
+	"github.com/clarketm/json"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildImage(t *testing.T) {
+	var cfg types.Config
+	err := json.Unmarshal([]byte(`{
+  "kernelArguments": {"shouldExist": ["quiet", "a=b c"], "shouldNotExist": ["rhgb"]},
+  "passwd": {
+    "groups": [{"name": "app", "gid": 1500}],
+    "users": [
+      {"name": "core", "passwordHash": "x", "sshAuthorizedKeys": ["ssh-ed25519 AAAA"]},
+      {"name": "app", "uid": 1500, "primaryGroup": "app", "gecos": "App User", "homeDir": "/var/home/app", "groups": ["wheel"]}
+    ]
+  },
+  "storage": {
+    "disks": [{"device": "/dev/vda"}],
+    "files": [
+      {"path": "/etc/app.conf", "contents": {"source": "data:,x"}, "user": {"name": "app"}, "group": {"id": 1500}},
+      {"path": "/var/lib/app/state", "contents": {"source": "data:,"}}
+    ]
+  },
+  "systemd": {
+    "units": [
+      {"name": "app.service", "enabled": true, "contents": "[Service]\nExecStart=/bin/true\n"},
+      {"name": "zincati.service", "enabled": false}
+    ]
+  }
+}`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := BuildImage(cfg, "quay.io/fedora/fedora-coreos:stable")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `# Generated by Butane
+FROM quay.io/fedora/fedora-coreos:stable
+COPY rootfs/ /
+RUN systemd-sysusers /usr/lib/sysusers.d/35-butane.conf
+RUN chown -h app:1500 /etc/app.conf
+RUN systemctl preset app.service zincati.service
+`, string(img.Containerfile), "bad Containerfile")
+	assert.Equal(t, []Skip{
+		{"$.storage.disks.0", "disk /dev/vda can't be applied at build time"},
+		{"$.passwd.users.0.passwordHash", "password hash for user core can't be set at build time"},
+		{"$.passwd.users.0.sshAuthorizedKeys", "SSH keys for user core can't be installed at build time"},
+		{"$.kernelArguments.shouldNotExist.0", "kernel argument rhgb can't be removed at build time"},
+		{"$.storage.files.1", "/var/lib/app/state is only created when the image is first deployed"},
+	}, img.Warnings, "bad warnings")
+
+	dir := t.TempDir()
+	assert.NoError(t, img.Write(dir))
+	for p, expected := range map[string]string{
+		"rootfs/usr/lib/sysusers.d/35-butane.conf":            "g app 1500\nu core - - - -\nu app 1500:app \"App User\" /var/home/app -\nm app wheel\n",
+		"rootfs/usr/lib/bootc/kargs.d/35-butane.toml":         "kargs = [\"quiet\", \"a=b c\"]\n",
+		"rootfs/etc/systemd/system-preset/20-ignition.preset": "enable app.service\ndisable zincati.service\n",
+		"rootfs/etc/app.conf":                                 "x",
+	} {
+		contents, err := os.ReadFile(filepath.Join(dir, p))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, string(contents), "bad contents of %s", p)
+	}
+}
+
+// TestBuildImageSourceMap checks that warnings are reported at output
+// paths that the source map of a translated config can locate.
+func TestBuildImageSourceMap(t *testing.T) {
+	in := `variant: fcos
+version: 1.8.0-experimental
+passwd:
+  groups:
+    - name: old
+      should_exist: false
+    - name: app
+      password_hash: x
+  users:
+    - name: core
+      password_hash: x
+      ssh_authorized_keys:
+        - ssh-ed25519 AAAA
+    - name: app
+      primary_group: app
+    - name: gone
+      should_exist: false
+kernel_arguments:
+  should_not_exist:
+    - rhgb
+`
+	sourceMap := common.SourceMap{}
+	out, _, err := config.TranslateBytes([]byte(in), common.TranslateBytesOptions{
+		TranslateOptions: common.TranslateOptions{
+			SourceMap: sourceMap,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg types.Config
+	if err := json.Unmarshal(out, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	img, err := BuildImage(cfg, "quay.io/fedora/fedora-coreos:stable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int64{
+		"$.passwd.groups.0.shouldExist":      6,
+		"$.passwd.groups.1.passwordHash":     8,
+		"$.passwd.users.0.passwordHash":      11,
+		"$.passwd.users.0.sshAuthorizedKeys": 13,
+		"$.passwd.users.1.primaryGroup":      15,
+		"$.passwd.users.2.shouldExist":       17,
+		"$.kernelArguments.shouldNotExist.0": 20,
+	}
+	var sources []string
+	for _, w := range img.Warnings {
+		sources = append(sources, w.Source)
+		if line, ok := expected[w.Source]; ok {
+			assert.Equal(t, line, sourceMap[w.Source].Line, "bad line for %s", w.Source)
+		}
+	}
+	for source := range expected {
+		assert.Contains(t, sources, source, "missing warning")
+	}
+}
@@ -0,0 +1,618 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+// Package render builds the filesystem tree that Ignition would write for
+// a config, so it can be inspected or packaged without booting a machine.
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	slashpath "path"
+	"sort"
+	"strings"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/vincent-petithory/dataurl"
+)
+
+const (
+	// maxLinkDepth bounds symlink resolution, mirroring the kernel's limit.
+	maxLinkDepth = 40
+
+	unitDir    = "/etc/systemd/system"
+	presetPath = "/etc/systemd/system-preset/20-ignition.preset"
+)
+
+var (
+	ErrNodeExists   = errors.New("path is specified more than once")
+	ErrNotDirectory = errors.New("parent is not a directory")
+	ErrLinkLoop     = errors.New("too many levels of symbolic links")
+	ErrHardLinkType = errors.New("hard link target must be a file")
+	ErrNoLinkTarget = errors.New("link target is required")
+	ErrCompression  = errors.New("unsupported compression type")
+)
+
+type Type int
+
+const (
+	TypeFile Type = iota
+	TypeDirectory
+	TypeSymlink
+	TypeHardLink
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeFile:
+		return "file"
+	case TypeDirectory:
+		return "directory"
+	case TypeSymlink:
+		return "symlink"
+	case TypeHardLink:
+		return "hard link"
+	default:
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+}
+
+// Node is a filesystem node written by Ignition.
+type Node struct {
+	Path     string // absolute, with symlinks in parent directories resolved
+	Type     Type
+	Mode     int    // Unix permission bits, including setuid, setgid, and sticky
+	UID      *int   // nil if unspecified or specified by name
+	GID      *int   // nil if unspecified or specified by name
+	User     string // user name, if specified by name
+	Group    string // group name, if specified by name
+	Contents []byte // for files
+	Target   string // symlink target, or path of hard link target
+	Implicit bool   // directory created only as a parent of another node
+	// Path of the config entry that declared the node, such as
+	// "$.storage.files.0", or empty for generated nodes
+	Source   string
+	children map[string]*Node
+}
+
+// Skip is a part of the config that wasn't rendered.
+type Skip struct {
+	// Path of the config entry, such as "$.storage.disks.0"
+	Source      string
+	Description string
+}
+
+// Tree is the set of filesystem nodes that Ignition would write for a
+// config.
+type Tree struct {
+	root *Node
+	// Skipped describes parts of the config that can't be rendered as
+	// files, such as disks and users, or whose contents are remote.
+	Skipped []Skip
+	// install symlinks that a disabled unit must not have
+	absent []*Node
+}
+
+// Render builds the filesystem tree for cfg.  It applies storage.files,
+// including appends, storage.directories, storage.links, and
+// systemd.units.  Enabled units are added to the Ignition preset file
+// and, if the unit contents specify an [Install] section, also get the
+// .wants and .requires symlinks that systemd will create from the preset
+// on first boot.
+//
+// Child configs must already be merged into cfg.
+func Render(cfg types.Config) (*Tree, error) {
+	t := &Tree{
+		root: newDir("/", 0755),
+	}
+	t.skip(cfg)
+
+	// Ignition creates nodes in order of path depth, so parents are
+	// created before their children
+	type entry struct {
+		path string
+		add  func() error
+	}
+	var entries, hardLinks []entry
+	for i, d := range cfg.Storage.Directories {
+		d, source := d, fmt.Sprintf("$.storage.directories.%d", i)
+		entries = append(entries, entry{d.Path, func() error { return t.addDirectory(d, source) }})
+	}
+	for i, f := range cfg.Storage.Files {
+		f, source := f, fmt.Sprintf("$.storage.files.%d", i)
+		entries = append(entries, entry{f.Path, func() error { return t.addFile(f, source) }})
+	}
+	for i, l := range cfg.Storage.Links {
+		l, source := l, fmt.Sprintf("$.storage.links.%d", i)
+		e := entry{l.Path, func() error { return t.addLink(l, source) }}
+		if l.Hard != nil && *l.Hard {
+			hardLinks = append(hardLinks, e)
+		} else {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return depth(entries[i].path) < depth(entries[j].path)
+	})
+	for _, e := range append(entries, hardLinks...) {
+		if err := e.add(); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.path, err)
+		}
+	}
+
+	if err := t.addUnits(cfg.Systemd.Units); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// skip records the parts of cfg that aren't rendered.
+func (t *Tree) skip(cfg types.Config) {
+	for i, ref := range cfg.Ignition.Config.Merge {
+		if ref.Source != nil {
+			t.addSkip(fmt.Sprintf("$.ignition.config.merge.%d", i), "merged config %s", *ref.Source)
+		}
+	}
+	if cfg.Ignition.Config.Replace.Source != nil {
+		t.addSkip("$.ignition.config.replace", "replacement config %s", *cfg.Ignition.Config.Replace.Source)
+	}
+	for i, d := range cfg.Storage.Disks {
+		t.addSkip(fmt.Sprintf("$.storage.disks.%d", i), "disk %s", d.Device)
+	}
+	for i, r := range cfg.Storage.Raid {
+		t.addSkip(fmt.Sprintf("$.storage.raid.%d", i), "RAID %s", r.Name)
+	}
+	for i, l := range cfg.Storage.Luks {
+		t.addSkip(fmt.Sprintf("$.storage.luks.%d", i), "LUKS volume %s", l.Name)
+	}
+	for i, fs := range cfg.Storage.Filesystems {
+		t.addSkip(fmt.Sprintf("$.storage.filesystems.%d", i), "filesystem %s", fs.Device)
+	}
+	for i, g := range cfg.Passwd.Groups {
+		t.addSkip(fmt.Sprintf("$.passwd.groups.%d", i), "group %s", g.Name)
+	}
+	for i, u := range cfg.Passwd.Users {
+		t.addSkip(fmt.Sprintf("$.passwd.users.%d", i), "user %s", u.Name)
+	}
+	if len(cfg.KernelArguments.ShouldExist) > 0 || len(cfg.KernelArguments.ShouldNotExist) > 0 {
+		t.addSkip("$.kernelArguments", "kernel arguments")
+	}
+}
+
+func (t *Tree) addSkip(source string, format string, args ...interface{}) {
+	t.Skipped = append(t.Skipped, Skip{
+		Source:      source,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+func (t *Tree) addDirectory(d types.Directory, source string) error {
+	n := &Node{
+		Type:     TypeDirectory,
+		Mode:     intOr(d.Mode, 0755),
+		Source:   source,
+		children: make(map[string]*Node),
+	}
+	setOwner(n, d.Node)
+	return t.add(d.Path, n)
+}
+
+func (t *Tree) addFile(f types.File, source string) error {
+	n := &Node{
+		Type:   TypeFile,
+		Mode:   intOr(f.Mode, 0644),
+		Source: source,
+	}
+	setOwner(n, f.Node)
+	contents, remote, err := decodeResource(f.Contents)
+	if err != nil {
+		return err
+	}
+	if remote != "" {
+		t.addSkip(source+".contents", "file %s with contents from %s", f.Path, remote)
+		return nil
+	}
+	n.Contents = contents
+	for i, a := range f.Append {
+		contents, remote, err := decodeResource(a)
+		if err != nil {
+			return err
+		}
+		if remote != "" {
+			t.addSkip(fmt.Sprintf("%s.append.%d", source, i), "append to file %s from %s", f.Path, remote)
+			continue
+		}
+		n.Contents = append(n.Contents, contents...)
+	}
+	return t.add(f.Path, n)
+}
+
+func (t *Tree) addLink(l types.Link, source string) error {
+	if l.Target == nil {
+		return ErrNoLinkTarget
+	}
+	n := &Node{
+		Type:   TypeSymlink,
+		Mode:   0777,
+		Target: *l.Target,
+		Source: source,
+	}
+	setOwner(n, l.Node)
+	if l.Hard != nil && *l.Hard {
+		target, err := t.lookup(*l.Target, 0)
+		if err != nil {
+			return err
+		}
+		if target.Type != TypeFile {
+			return ErrHardLinkType
+		}
+		n.Type = TypeHardLink
+		n.Mode = target.Mode
+		n.Target = target.Path
+		n.Contents = target.Contents
+	}
+	return t.add(l.Path, n)
+}
+
+// addUnits writes units, dropins, masks, and presets the way Ignition
+// does.
+func (t *Tree) addUnits(units []types.Unit) error {
+	contents := make(map[string]string)
+	for _, u := range units {
+		if u.Contents != nil {
+			contents[u.Name] = *u.Contents
+		}
+	}
+
+	var presets []string
+	instances := make(map[string][]string)
+	for i, u := range units {
+		source := fmt.Sprintf("$.systemd.units.%d", i)
+		unitPath := slashpath.Join(unitDir, u.Name)
+		if u.Mask != nil && *u.Mask {
+			if err := t.add(unitPath, &Node{Type: TypeSymlink, Mode: 0777, Target: "/dev/null", Source: source + ".mask"}); err != nil {
+				return fmt.Errorf("%s: %w", unitPath, err)
+			}
+		} else if u.Contents != nil {
+			if err := t.add(unitPath, &Node{Type: TypeFile, Mode: 0644, Contents: []byte(*u.Contents), Source: source}); err != nil {
+				return fmt.Errorf("%s: %w", unitPath, err)
+			}
+		}
+		for j, d := range u.Dropins {
+			if d.Contents == nil {
+				continue
+			}
+			dropinPath := slashpath.Join(unitDir, u.Name+".d", d.Name)
+			dropin := &Node{
+				Type:     TypeFile,
+				Mode:     0644,
+				Contents: []byte(*d.Contents),
+				Source:   fmt.Sprintf("%s.dropins.%d", source, j),
+			}
+			if err := t.add(dropinPath, dropin); err != nil {
+				return fmt.Errorf("%s: %w", dropinPath, err)
+			}
+		}
+
+		if u.Enabled == nil {
+			continue
+		}
+		action := "disable"
+		if *u.Enabled {
+			action = "enable"
+		}
+		// instances of a template share one preset line
+		template, instance := splitInstance(u.Name)
+		key := action + " " + u.Name
+		if instance != "" {
+			key = action + " " + template
+		}
+		if _, ok := instances[key]; !ok {
+			presets = append(presets, key)
+			instances[key] = nil
+		}
+		if instance != "" {
+			instances[key] = append(instances[key], instance)
+		}
+		links := installLinks(u.Name, template, contents, source+".enabled")
+		if !*u.Enabled {
+			t.absent = append(t.absent, links...)
+			continue
+		}
+		for _, link := range links {
+			// another unit may have the same install link
+			if err := t.add(link.Path, link); err != nil && !errors.Is(err, ErrNodeExists) {
+				return fmt.Errorf("%s: %w", link.Path, err)
+			}
+		}
+	}
+
+	if len(presets) > 0 {
+		var buf bytes.Buffer
+		for _, key := range presets {
+			buf.WriteString(strings.Join(append([]string{key}, instances[key]...), " "))
+			buf.WriteString("\n")
+		}
+		if err := t.add(presetPath, &Node{Type: TypeFile, Mode: 0644, Contents: buf.Bytes()}); err != nil {
+			return fmt.Errorf("%s: %w", presetPath, err)
+		}
+	}
+	return nil
+}
+
+// installLinks returns the symlinks that enabling the named unit would
+// create, according to the [Install] section of its contents or those of
+// its template.
+func installLinks(name, template string, contents map[string]string, source string) []*Node {
+	unitContents, ok := contents[name]
+	linkTarget := slashpath.Join(unitDir, name)
+	if !ok && template != "" {
+		unitContents, ok = contents[template]
+		linkTarget = slashpath.Join(unitDir, template)
+	}
+	if !ok {
+		return nil
+	}
+	var links []*Node
+	for _, dep := range installDependencies(unitContents) {
+		links = append(links, &Node{
+			Path:   slashpath.Join(unitDir, dep, name),
+			Type:   TypeSymlink,
+			Mode:   0777,
+			Target: linkTarget,
+			Source: source,
+		})
+	}
+	return links
+}
+
+// installDependencies returns the .wants and .requires directories named
+// by the WantedBy and RequiredBy settings in a unit's [Install] section.
+func installDependencies(contents string) []string {
+	var deps []string
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if section != "[Install]" || !ok {
+			continue
+		}
+		var suffix string
+		switch strings.TrimSpace(key) {
+		case "WantedBy":
+			suffix = ".wants"
+		case "RequiredBy":
+			suffix = ".requires"
+		default:
+			continue
+		}
+		for _, target := range strings.Fields(value) {
+			deps = append(deps, target+suffix)
+		}
+	}
+	return deps
+}
+
+// splitInstance splits an instantiated unit name like foo@bar.service
+// into its template foo@.service and instance bar.  It returns an empty
+// instance for other units.
+func splitInstance(name string) (string, string) {
+	at := strings.Index(name, "@")
+	dot := strings.LastIndex(name, ".")
+	if at < 0 || dot < at+2 {
+		return "", ""
+	}
+	return name[:at+1] + name[dot:], name[at+1 : dot]
+}
+
+// add inserts n at p, creating parent directories as needed.  An
+// explicit directory may replace an implicit one.
+func (t *Tree) add(p string, n *Node) error {
+	dir, err := t.mkdirAll(slashpath.Dir(slashpath.Clean("/"+p)), 0)
+	if err != nil {
+		return err
+	}
+	base := slashpath.Base(p)
+	n.Path = slashpath.Join(dir.Path, base)
+	if existing, ok := dir.children[base]; ok {
+		if !existing.Implicit || n.Type != TypeDirectory {
+			return ErrNodeExists
+		}
+		n.children = existing.children
+	}
+	dir.children[base] = n
+	return nil
+}
+
+// mkdirAll returns the directory at p, creating it and its parents as
+// needed.  Symlinks are resolved within the tree.
+func (t *Tree) mkdirAll(p string, linkDepth int) (*Node, error) {
+	dir := t.root
+	for _, elem := range splitPath(p) {
+		child, ok := dir.children[elem]
+		if !ok {
+			child = newDir(slashpath.Join(dir.Path, elem), 0755)
+			child.Implicit = true
+			dir.children[elem] = child
+		}
+		if child.Type == TypeSymlink {
+			if linkDepth >= maxLinkDepth {
+				return nil, ErrLinkLoop
+			}
+			target := child.Target
+			if !slashpath.IsAbs(target) {
+				target = slashpath.Join(dir.Path, target)
+			}
+			var err error
+			if child, err = t.mkdirAll(target, linkDepth+1); err != nil {
+				return nil, err
+			}
+		}
+		if child.Type != TypeDirectory {
+			return nil, ErrNotDirectory
+		}
+		dir = child
+	}
+	return dir, nil
+}
+
+// lookup returns the node at p, following symlinks.
+func (t *Tree) lookup(p string, linkDepth int) (*Node, error) {
+	n := t.root
+	for _, elem := range splitPath(p) {
+		if n.Type != TypeDirectory {
+			return nil, ErrNotDirectory
+		}
+		child, ok := n.children[elem]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		if child.Type == TypeSymlink {
+			if linkDepth >= maxLinkDepth {
+				return nil, ErrLinkLoop
+			}
+			target := child.Target
+			if !slashpath.IsAbs(target) {
+				target = slashpath.Join(n.Path, target)
+			}
+			var err error
+			if child, err = t.lookup(target, linkDepth+1); err != nil {
+				return nil, err
+			}
+		}
+		n = child
+	}
+	return n, nil
+}
+
+// Walk calls fn for each node in the tree other than the root, visiting
+// parents before their children and hard links after everything else.
+func (t *Tree) Walk(fn func(n *Node) error) error {
+	var hardLinks []*Node
+	var walk func(dir *Node) error
+	walk = func(dir *Node) error {
+		names := make([]string, 0, len(dir.children))
+		for name := range dir.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := dir.children[name]
+			if child.Type == TypeHardLink {
+				hardLinks = append(hardLinks, child)
+				continue
+			}
+			if err := fn(child); err != nil {
+				return err
+			}
+			if child.Type == TypeDirectory {
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(t.root); err != nil {
+		return err
+	}
+	for _, n := range hardLinks {
+		if err := fn(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeResource returns the contents of a resource with a data URL, or
+// the URL of a remote resource.
+func decodeResource(r types.Resource) ([]byte, string, error) {
+	if r.Source == nil {
+		return nil, "", nil
+	}
+	if !strings.HasPrefix(*r.Source, "data:") {
+		return nil, *r.Source, nil
+	}
+	decoded, err := dataurl.DecodeString(*r.Source)
+	if err != nil {
+		return nil, "", err
+	}
+	compression := ""
+	if r.Compression != nil {
+		compression = *r.Compression
+	}
+	switch compression {
+	case "":
+		return decoded.Data, "", nil
+	case "gzip":
+		reader, err := gzip.NewReader(bytes.NewReader(decoded.Data))
+		if err != nil {
+			return nil, "", err
+		}
+		defer reader.Close()
+		contents, err := io.ReadAll(reader)
+		return contents, "", err
+	default:
+		return nil, "", ErrCompression
+	}
+}
+
+func setOwner(n *Node, node types.Node) {
+	n.UID = node.User.ID
+	if node.User.Name != nil {
+		n.User = *node.User.Name
+	}
+	n.GID = node.Group.ID
+	if node.Group.Name != nil {
+		n.Group = *node.Group.Name
+	}
+}
+
+func newDir(p string, mode int) *Node {
+	return &Node{
+		Path:     p,
+		Type:     TypeDirectory,
+		Mode:     mode,
+		children: make(map[string]*Node),
+	}
+}
+
+func intOr(v *int, def int) int {
+	if v == nil {
+		return def
+	}
+	return *v
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(slashpath.Clean("/"+p), "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+func depth(p string) int {
+	return len(splitPath(p))
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package render
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/clarketm/json"
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `{
+  "ignition": {"version": "3.7.0-experimental"},
+  "kernelArguments": {"shouldExist": ["quiet"]},
+  "storage": {
+    "disks": [{"device": "/dev/vda"}],
+    "directories": [{"path": "/etc/secret", "mode": 448, "user": {"id": 10}}],
+    "files": [
+      {"path": "/etc/secret/key", "mode": 384, "contents": {"source": "data:,hunter2"}},
+      {"path": "/lnk/b/f", "contents": {"source": "data:;base64,H4sIAAAAAAAAA0vLz+cCAKhlMn4EAAAA", "compression": "gzip"}},
+      {"path": "/var/log", "contents": {"source": "data:,a"}, "append": [{"source": "data:,b"}, {"source": "https://example.com/c"}]},
+      {"path": "/remote", "contents": {"source": "https://example.com/remote"}}
+    ],
+    "links": [
+      {"path": "/lnk", "target": "srv"},
+      {"path": "/hard", "target": "/etc/secret/key", "hard": true}
+    ]
+  },
+  "systemd": {
+    "units": [
+      {"name": "a.service", "enabled": true, "contents": "[Install]\nWantedBy=multi-user.target\n", "dropins": [{"name": "10-x.conf", "contents": "x"}]},
+      {"name": "b@.service", "contents": "[Install]\nRequiredBy=default.target\n"},
+      {"name": "b@1.service", "enabled": true},
+      {"name": "b@2.service", "enabled": true},
+      {"name": "c.service", "enabled": false},
+      {"name": "d.service", "mask": true}
+    ]
+  },
+  "passwd": {"users": [{"name": "core"}]}
+}`
+
+func TestRender(t *testing.T) {
+	var cfg types.Config
+	if err := json.Unmarshal([]byte(testConfig), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Render(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes []string
+	err = tree.Walk(func(n *Node) error {
+		desc := fmt.Sprintf("%s %d %o", n.Path, n.Type, n.Mode)
+		switch n.Type {
+		case TypeFile, TypeHardLink:
+			desc += fmt.Sprintf(" %q", n.Contents)
+		case TypeSymlink:
+			desc += " -> " + n.Target
+		}
+		nodes = append(nodes, desc)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`/etc 1 755`,
+		`/etc/secret 1 700`,
+		`/etc/secret/key 0 600 "hunter2"`,
+		`/etc/systemd 1 755`,
+		`/etc/systemd/system 1 755`,
+		`/etc/systemd/system/a.service 0 644 "[Install]\nWantedBy=multi-user.target\n"`,
+		`/etc/systemd/system/a.service.d 1 755`,
+		`/etc/systemd/system/a.service.d/10-x.conf 0 644 "x"`,
+		`/etc/systemd/system/b@.service 0 644 "[Install]\nRequiredBy=default.target\n"`,
+		`/etc/systemd/system/d.service 2 777 -> /dev/null`,
+		`/etc/systemd/system/default.target.requires 1 755`,
+		`/etc/systemd/system/default.target.requires/b@1.service 2 777 -> /etc/systemd/system/b@.service`,
+		`/etc/systemd/system/default.target.requires/b@2.service 2 777 -> /etc/systemd/system/b@.service`,
+		`/etc/systemd/system/multi-user.target.wants 1 755`,
+		`/etc/systemd/system/multi-user.target.wants/a.service 2 777 -> /etc/systemd/system/a.service`,
+		`/etc/systemd/system-preset 1 755`,
+		`/etc/systemd/system-preset/20-ignition.preset 0 644 "enable a.service\nenable b@.service 1 2\ndisable c.service\n"`,
+		`/lnk 2 777 -> srv`,
+		`/srv 1 755`,
+		`/srv/b 1 755`,
+		`/srv/b/f 0 644 "foo\n"`,
+		`/var 1 755`,
+		`/var/log 0 644 "ab"`,
+		`/hard 3 600 "hunter2"`,
+	}, nodes, "bad nodes")
+	assert.Equal(t, []Skip{
+		{"$.storage.disks.0", "disk /dev/vda"},
+		{"$.passwd.users.0", "user core"},
+		{"$.kernelArguments", "kernel arguments"},
+		{"$.storage.files.3.contents", "file /remote with contents from https://example.com/remote"},
+		{"$.storage.files.2.append.1", "append to file /var/log from https://example.com/c"},
+	}, tree.Skipped, "bad skipped list")
+
+	var buf bytes.Buffer
+	assert.NoError(t, tree.WriteTar(&buf))
+	tr := tar.NewReader(&buf)
+	var headers []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, fmt.Sprintf("%s %c %o %d %s", hdr.Name, hdr.Typeflag, hdr.Mode, hdr.Uid, hdr.Linkname))
+	}
+	assert.Equal(t, "etc/secret/ 5 700 10 ", headers[1], "bad directory header")
+	assert.Equal(t, "lnk 2 777 0 srv", headers[17], "bad symlink header")
+	assert.Equal(t, "hard 1 600 0 etc/secret/key", headers[len(headers)-1], "bad hard link header")
+
+	root := filepath.Join(t.TempDir(), "root")
+	assert.NoError(t, tree.WriteDir(root))
+	contents, err := os.ReadFile(filepath.Join(root, "lnk/b/f"))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo\n", string(contents), "bad contents through symlink")
+	info, err := os.Stat(filepath.Join(root, "etc/secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, FileMode(0700)|os.ModeDir, info.Mode(), "bad directory mode")
+	assert.Equal(t, ErrRootNotEmpty, tree.WriteDir(root), "wrote to non-empty root")
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err error
+	}{
+		{
+			`{"storage": {"files": [{"path": "/a"}], "directories": [{"path": "/a"}]}}`,
+			ErrNodeExists,
+		},
+		{
+			`{"storage": {"files": [{"path": "/a"}, {"path": "/a/b"}]}}`,
+			ErrNotDirectory,
+		},
+		{
+			`{"storage": {"links": [{"path": "/a", "target": "/b"}, {"path": "/b", "target": "/a"}, {"path": "/a/c", "target": "d"}]}}`,
+			ErrLinkLoop,
+		},
+		{
+			`{"storage": {"directories": [{"path": "/a"}], "links": [{"path": "/b", "target": "/a", "hard": true}]}}`,
+			ErrHardLinkType,
+		},
+		{
+			`{"storage": {"files": [{"path": "/a", "contents": {"source": "data:,a", "compression": "xz"}}]}}`,
+			ErrCompression,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("render %d", i), func(t *testing.T) {
+			var cfg types.Config
+			if err := json.Unmarshal([]byte(test.in), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Render(cfg)
+			assert.ErrorIs(t, err, test.err, "bad error")
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var cfg types.Config
+	err := json.Unmarshal([]byte(`{
+  "storage": {
+    "files": [
+      {"path": "/lnk/a", "mode": 384, "contents": {"source": "data:,a"}},
+      {"path": "/b", "contents": {"source": "data:,b"}}
+    ],
+    "links": [{"path": "/lnk", "target": "srv"}]
+  },
+  "systemd": {
+    "units": [
+      {"name": "a.service", "enabled": true, "contents": "[Install]\nWantedBy=multi-user.target\n"},
+      {"name": "b.service", "enabled": false, "contents": "[Install]\nWantedBy=multi-user.target\n"},
+      {"name": "c.service", "mask": true}
+    ]
+  }
+}`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := Render(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	assert.NoError(t, tree.WriteDir(root))
+	mismatches, err := tree.Verify(root)
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches, "freshly written tree doesn't match")
+
+	for _, step := range []func() error{
+		func() error { return os.WriteFile(filepath.Join(root, "srv/a"), []byte("x"), 0600) },
+		func() error { return os.Chmod(filepath.Join(root, "b"), 0755) },
+		func() error { return os.Remove(filepath.Join(root, "etc/systemd/system/c.service")) },
+		func() error {
+			return os.Symlink("/etc/systemd/system/b.service", filepath.Join(root, "etc/systemd/system/multi-user.target.wants/b.service"))
+		},
+	} {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mismatches, err = tree.Verify(root)
+	assert.NoError(t, err)
+	assert.Equal(t, []Mismatch{
+		{
+			Path:    "/b",
+			Source:  "$.storage.files.1.mode",
+			Message: "mode is -rwxr-xr-x, expected -rw-r--r--",
+		},
+		{
+			Path:    "/etc/systemd/system/c.service",
+			Source:  "$.systemd.units.2.mask",
+			Message: "missing symlink",
+		},
+		{
+			Path:    "/srv/a",
+			Source:  "$.storage.files.0.contents",
+			Message: "contents differ",
+		},
+		{
+			Path:    "/etc/systemd/system/multi-user.target.wants/b.service",
+			Source:  "$.systemd.units.1.enabled",
+			Message: "unit is disabled but has an install symlink",
+		},
+	}, mismatches, "bad mismatches")
+}
@@ -0,0 +1,216 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	slashpath "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Mismatch is a difference between the tree and an existing filesystem.
+type Mismatch struct {
+	Path string
+	// Path of the config field responsible for the node, such as
+	// "$.storage.files.0.mode", or empty for generated nodes
+	Source  string
+	Message string
+}
+
+// Verify compares the tree to the filesystem at root and returns the
+// differences.  Symlinks under root are resolved relative to root.
+// Implicit parent directories aren't checked, and ownership is only
+// checked if it's specified in the config.  Owners specified by name are
+// looked up in the passwd and group files under root.
+func (t *Tree) Verify(root string) ([]Mismatch, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s: %w", root, ErrNotDirectory)
+	}
+
+	v := verifier{root: root}
+	err = t.Walk(func(n *Node) error {
+		if !n.Implicit {
+			v.verify(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range t.absent {
+		if _, err := os.Lstat(v.resolve(n.Path)); err == nil {
+			v.mismatch(n, "", "unit is disabled but has an install symlink")
+		}
+	}
+	return v.mismatches, nil
+}
+
+type verifier struct {
+	root       string
+	mismatches []Mismatch
+}
+
+func (v *verifier) mismatch(n *Node, field string, format string, args ...interface{}) {
+	source := n.Source
+	// only storage entries have fields corresponding to node attributes
+	if field != "" && strings.HasPrefix(source, "$.storage.") {
+		source += "." + field
+	}
+	v.mismatches = append(v.mismatches, Mismatch{
+		Path:    n.Path,
+		Source:  source,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *verifier) verify(n *Node) {
+	p := v.resolve(n.Path)
+	info, err := os.Lstat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		v.mismatch(n, "", "missing %s", n.Type)
+		return
+	} else if err != nil {
+		v.mismatch(n, "", "%v", err)
+		return
+	}
+
+	var ok bool
+	switch n.Type {
+	case TypeFile, TypeHardLink:
+		ok = info.Mode().IsRegular()
+	case TypeDirectory:
+		ok = info.IsDir()
+	case TypeSymlink:
+		ok = info.Mode()&fs.ModeSymlink != 0
+	}
+	if !ok {
+		v.mismatch(n, "", "not a %s", n.Type)
+		return
+	}
+
+	switch n.Type {
+	case TypeFile:
+		contents, err := os.ReadFile(p)
+		if err != nil {
+			v.mismatch(n, "", "%v", err)
+		} else if !bytes.Equal(contents, n.Contents) {
+			v.mismatch(n, "contents", "contents differ")
+		}
+	case TypeSymlink:
+		target, err := os.Readlink(p)
+		if err != nil {
+			v.mismatch(n, "", "%v", err)
+		} else if target != n.Target {
+			v.mismatch(n, "target", "symlink target is %q, expected %q", target, n.Target)
+		}
+	case TypeHardLink:
+		targetInfo, err := os.Lstat(v.resolve(n.Target))
+		if err != nil || !os.SameFile(info, targetInfo) {
+			v.mismatch(n, "target", "not a hard link to %s", n.Target)
+		}
+	}
+
+	// symlink permissions are meaningless, and a hard link's are its
+	// target's
+	if n.Type == TypeFile || n.Type == TypeDirectory {
+		const modeMask = fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+		if mode := info.Mode() & modeMask; mode != FileMode(n.Mode) {
+			v.mismatch(n, "mode", "mode is %v, expected %v", mode, FileMode(n.Mode))
+		}
+	}
+	v.verifyOwner(n, info)
+}
+
+func (v *verifier) verifyOwner(n *Node, info fs.FileInfo) {
+	if n.UID == nil && n.User == "" && n.GID == nil && n.Group == "" {
+		return
+	}
+	uid, gid, ok := fileOwner(info)
+	if !ok {
+		return
+	}
+	if expected, ok := v.id(n, "user", n.UID, n.User, "etc/passwd"); ok && uid != expected {
+		v.mismatch(n, "user", "owner is UID %d, expected %d", uid, expected)
+	}
+	if expected, ok := v.id(n, "group", n.GID, n.Group, "etc/group"); ok && gid != expected {
+		v.mismatch(n, "group", "group is GID %d, expected %d", gid, expected)
+	}
+}
+
+// id returns the expected user or group ID, looking up names in the
+// database file under the root.  It returns false if no owner was
+// specified or the name couldn't be found.
+func (v *verifier) id(n *Node, field string, id *int, name, database string) (int, bool) {
+	if id != nil {
+		return *id, true
+	}
+	if name == "" {
+		return 0, false
+	}
+	f, err := os.Open(filepath.Join(v.root, filepath.FromSlash(database)))
+	if err != nil {
+		v.mismatch(n, field, "can't look up %s %q: %v", field, name, err)
+		return 0, false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 3 || fields[0] != name {
+			continue
+		}
+		if ret, err := strconv.Atoi(fields[2]); err == nil {
+			return ret, true
+		}
+	}
+	v.mismatch(n, field, "%s %q not found in /%s", field, name, database)
+	return 0, false
+}
+
+// resolve returns the host path of p, resolving symlinks in its parent
+// directories relative to the root.
+func (v *verifier) resolve(p string) string {
+	dir := v.resolveDir(slashpath.Dir(p), 0)
+	return filepath.Join(v.root, filepath.FromSlash(slashpath.Join(dir, slashpath.Base(p))))
+}
+
+func (v *verifier) resolveDir(p string, linkDepth int) string {
+	dir := "/"
+	for _, elem := range splitPath(p) {
+		next := slashpath.Join(dir, elem)
+		target, err := os.Readlink(filepath.Join(v.root, filepath.FromSlash(next)))
+		if err != nil || linkDepth >= maxLinkDepth {
+			// not a symlink, or nonexistent
+			dir = next
+			continue
+		}
+		if !slashpath.IsAbs(target) {
+			target = slashpath.Join(dir, target)
+		}
+		dir = v.resolveDir(target, linkDepth+1)
+	}
+	return dir
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package render
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRootNotEmpty = errors.New("root directory must be empty")
+)
+
+// FileMode converts Unix mode bits to an fs.FileMode.
+func FileMode(mode int) fs.FileMode {
+	ret := fs.FileMode(mode & 0777)
+	if mode&04000 != 0 {
+		ret |= fs.ModeSetuid
+	}
+	if mode&02000 != 0 {
+		ret |= fs.ModeSetgid
+	}
+	if mode&01000 != 0 {
+		ret |= fs.ModeSticky
+	}
+	return ret
+}
+
+// WriteDir writes the tree into root, which must be empty or not exist.
+// Ownership is only applied when running as root, and only for owners
+// specified by ID, since names can't be resolved outside the node.
+func (t *Tree) WriteDir(root string) error {
+	entries, err := os.ReadDir(root)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(entries) > 0 {
+		return ErrRootNotEmpty
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+
+	// apply directory modes last, so restrictive ones don't prevent
+	// creating children
+	var dirs []*Node
+	err = t.Walk(func(n *Node) error {
+		p := filepath.Join(root, filepath.FromSlash(n.Path))
+		switch n.Type {
+		case TypeDirectory:
+			if err := os.Mkdir(p, 0700); err != nil {
+				return err
+			}
+			dirs = append(dirs, n)
+			return nil
+		case TypeFile:
+			if err := os.WriteFile(p, n.Contents, 0600); err != nil {
+				return err
+			}
+			if err := os.Chmod(p, FileMode(n.Mode)); err != nil {
+				return err
+			}
+		case TypeSymlink:
+			if err := os.Symlink(n.Target, p); err != nil {
+				return err
+			}
+		case TypeHardLink:
+			if err := os.Link(filepath.Join(root, filepath.FromSlash(n.Target)), p); err != nil {
+				return err
+			}
+		}
+		return chown(p, n)
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		p := filepath.Join(root, filepath.FromSlash(dirs[i].Path))
+		if err := os.Chmod(p, FileMode(dirs[i].Mode)); err != nil {
+			return err
+		}
+		if err := chown(p, dirs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func chown(p string, n *Node) error {
+	if os.Geteuid() != 0 || (n.UID == nil && n.GID == nil) {
+		return nil
+	}
+	return os.Lchown(p, intOr(n.UID, -1), intOr(n.GID, -1))
+}
+
+// WriteTar writes the tree to w as a tar archive, with ownership and
+// modes in the entry headers.
+func (t *Tree) WriteTar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := t.Walk(func(n *Node) error {
+		hdr := &tar.Header{
+			Name:    strings.TrimPrefix(n.Path, "/"),
+			Mode:    int64(n.Mode),
+			Uid:     intOr(n.UID, 0),
+			Gid:     intOr(n.GID, 0),
+			Uname:   n.User,
+			Gname:   n.Group,
+			ModTime: time.Unix(0, 0),
+			Format:  tar.FormatPAX,
+		}
+		switch n.Type {
+		case TypeDirectory:
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+		case TypeFile:
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = int64(len(n.Contents))
+		case TypeSymlink:
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = n.Target
+		case TypeHardLink:
+			hdr.Typeflag = tar.TypeLink
+			hdr.Linkname = strings.TrimPrefix(n.Target, "/")
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if n.Type == TypeFile {
+			_, err := tw.Write(n.Contents)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
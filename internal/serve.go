@@ -0,0 +1,76 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/coreos/butane/internal/serve"
+)
+
+// serveMain implements "butane serve", which translates configs
+// submitted over HTTP.
+func serveMain(args []string) {
+	var (
+		listen   string
+		options  serve.Options
+		helpFlag bool
+	)
+	flags := pflag.NewFlagSet("serve", pflag.ExitOnError)
+	flags.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
+	flags.StringVar(&listen, "listen", ":8080", "listen on `ADDRESS`")
+	flags.Int64Var(&options.MaxRequestSize, "max-request-size", 10<<20, "maximum request size in `BYTES`, including any files archive")
+	flags.Int64Var(&options.MaxFilesSize, "max-files-size", 100<<20, "maximum decompressed size in `BYTES` of a files archive")
+	flags.DurationVar(&options.Timeout, "timeout", 30*time.Second, "maximum time to handle a request")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s serve [options]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Translate configs submitted over HTTP.\n")
+		fmt.Fprintf(flags.Output(), "Endpoints:\n")
+		fmt.Fprintf(flags.Output(), "  POST /v1/translate   translate the config in the request body, or the\n")
+		fmt.Fprintf(flags.Output(), "                       \"config\" and \"files\" parts of a multipart form\n")
+		fmt.Fprintf(flags.Output(), "  GET  /v1/versions    list supported variants and versions\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if helpFlag {
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		os.Exit(0)
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if options.MaxRequestSize <= 0 || options.MaxFilesSize <= 0 || options.Timeout <= 0 {
+		fail("--max-request-size, --max-files-size, and --timeout must be positive\n")
+	}
+
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           serve.Handler(options),
+		ReadHeaderTimeout: options.Timeout,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		fail("failed to serve: %v\n", err)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+// Package serve implements an HTTP service for translating Butane configs.
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+	"github.com/coreos/butane/internal/archivefs"
+
+	"github.com/coreos/vcontext/report"
+)
+
+var (
+	ErrNoConfig = errors.New("request must include a config")
+	ErrWarnings = errors.New("config produced warnings and strict was specified")
+)
+
+// Options configure the service.
+type Options struct {
+	MaxRequestSize int64         // maximum request body size in bytes
+	MaxFilesSize   int64         // maximum decompressed size of a files archive
+	Timeout        time.Duration // maximum time to handle a request
+}
+
+// Entry is a report entry in a response.
+type Entry struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path,omitempty"`
+	Line    int64  `json:"line,omitempty"`
+	Column  int64  `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// TranslateResponse is the response to a translation request.  Output is
+// set if translation succeeded and Error if it didn't.
+type TranslateResponse struct {
+	Output string  `json:"output,omitempty"`
+	Report []Entry `json:"report"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// VersionsResponse lists the supported spec versions of each variant.
+type VersionsResponse struct {
+	Variants map[string][]string `json:"variants"`
+}
+
+// Handler returns an http.Handler serving these endpoints:
+//
+//	POST /v1/translate
+//	    Translate the Butane config in the request body, or in the
+//	    "config" part of a multipart/form-data body.  Local files are
+//	    read from an optional tar or zip archive in the "files" part;
+//	    nothing else on the server's filesystem or network is accessible.
+//	    The "pretty", "raw", and "strict" query parameters correspond to
+//	    the command-line options.
+//	GET /v1/versions
+//	    List the supported variants and spec versions.
+func Handler(options Options) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /v1/translate", http.TimeoutHandler(translateHandler{options}, options.Timeout, `{"error": "request timed out"}`))
+	mux.HandleFunc("GET /v1/versions", handleVersions)
+	return mux
+}
+
+type translateHandler struct {
+	options Options
+}
+
+func (h translateHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	req.Body = http.MaxBytesReader(w, req.Body, h.options.MaxRequestSize)
+	input, archive, err := readRequest(req)
+	if err != nil {
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSON(w, status, TranslateResponse{Report: []Entry{}, Error: err.Error()})
+		return
+	}
+
+	var opts common.TranslateBytesOptions
+	query := req.URL.Query()
+	opts.Pretty, _ = strconv.ParseBool(query.Get("pretty"))
+	opts.Raw, _ = strconv.ParseBool(query.Get("raw"))
+	strict, _ := strconv.ParseBool(query.Get("strict"))
+	if archive != nil {
+		fsys, err := archivefs.FromBytes(archive, h.options.MaxFilesSize)
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, archivefs.ErrTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeJSON(w, status, TranslateResponse{Report: []Entry{}, Error: fmt.Sprintf("reading files archive: %v", err)})
+			return
+		}
+		opts.FilesFS = fsys
+	}
+
+	// stop translating if the client goes away or the request times out
+	opts.Context = req.Context()
+	output, r, err := config.TranslateBytes(input, opts)
+	resp := TranslateResponse{Report: entries(r)}
+	if err == nil && strict && hasWarnings(r) {
+		err = ErrWarnings
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+	resp.Output = string(output)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// readRequest returns the config and optional files archive from req.
+func readRequest(req *http.Request) ([]byte, []byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		input, err := io.ReadAll(req.Body)
+		if err == nil && len(input) == 0 {
+			err = ErrNoConfig
+		}
+		return input, nil, err
+	}
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, nil, err
+	}
+	var input, archive []byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		switch part.FormName() {
+		case "config":
+			input, err = io.ReadAll(part)
+		case "files":
+			archive, err = io.ReadAll(part)
+		default:
+			err = fmt.Errorf("unknown form field %q", part.FormName())
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(input) == 0 {
+		return nil, nil, ErrNoConfig
+	}
+	return input, archive, nil
+}
+
+func handleVersions(w http.ResponseWriter, req *http.Request) {
+	resp := VersionsResponse{
+		Variants: make(map[string][]string),
+	}
+	for _, variant := range config.Variants() {
+		for _, version := range config.Versions(variant) {
+			resp.Variants[variant] = append(resp.Variants[variant], version.String())
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// entries converts report entries for a response.
+func entries(r report.Report) []Entry {
+	ret := []Entry{}
+	for _, e := range r.Entries {
+		entry := Entry{
+			Kind:    e.Kind.String(),
+			Message: e.Message,
+		}
+		if e.Context.Len() > 0 {
+			entry.Path = e.Context.String()
+		}
+		if e.Marker.StartP != nil {
+			entry.Line = e.Marker.StartP.Line
+			entry.Column = e.Marker.StartP.Column
+		}
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+// hasWarnings returns true if the report contains any entries more severe
+// than informational messages.
+func hasWarnings(r report.Report) bool {
+	for _, e := range r.Entries {
+		if e.Kind != report.Info {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,203 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package serve
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreos/butane/config/common"
+	"github.com/coreos/butane/internal/archivefs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	if err := tw.WriteHeader(&tar.Header{Name: "x.txt", Mode: 0644, Size: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var bigArchive bytes.Buffer
+	tw = tar.NewWriter(&bigArchive)
+	if err := tw.WriteHeader(&tar.Header{Name: "x.txt", Mode: 0644, Size: 2000}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(bytes.Repeat([]byte("x"), 2000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	localConfig := `variant: fcos
+version: 1.6.0
+storage:
+  files:
+    - path: /a
+      contents:
+        local: `
+
+	tests := []struct {
+		query   string
+		config  string
+		archive []byte
+		status  int
+		resp    TranslateResponse
+	}{
+		// plain body
+		{
+			config: "variant: fcos\nversion: 1.6.0\n",
+			status: http.StatusOK,
+			resp: TranslateResponse{
+				Output: `{"ignition":{"version":"3.5.0"}}`,
+				Report: []Entry{},
+			},
+		},
+		// files archive
+		{
+			config:  localConfig + "x.txt\n",
+			archive: archive.Bytes(),
+			status:  http.StatusOK,
+			resp: TranslateResponse{
+				Output: `{"ignition":{"version":"3.5.0"},"storage":{"files":[{"path":"/a","contents":{"compression":"","source":"data:,hello"}}]}}`,
+				Report: []Entry{},
+			},
+		},
+		// no access outside the archive
+		{
+			config:  localConfig + "../../etc/passwd\n",
+			archive: archive.Bytes(),
+			status:  http.StatusUnprocessableEntity,
+			resp: TranslateResponse{
+				Report: []Entry{{Kind: "error", Path: "$.storage.files.0.contents.local", Line: 7, Column: 16, Message: common.ErrFilesDirEscape.Error()}},
+				Error:  common.ErrInvalidSourceConfig.Error(),
+			},
+		},
+		{
+			config: localConfig + "x.txt\n",
+			status: http.StatusUnprocessableEntity,
+			resp: TranslateResponse{
+				Report: []Entry{{Kind: "error", Path: "$.storage.files.0.contents.local", Line: 7, Column: 16, Message: common.ErrNoFilesDir.Error()}},
+				Error:  common.ErrInvalidSourceConfig.Error(),
+			},
+		},
+		// strict
+		{
+			query:  "?strict=true",
+			config: "variant: fcos\nversion: 1.6.0\nfoo: bar\n",
+			status: http.StatusUnprocessableEntity,
+			resp: TranslateResponse{
+				Report: []Entry{{Kind: "warning", Path: "$.foo", Line: 3, Column: 1, Message: "unused key foo"}},
+				Error:  ErrWarnings.Error(),
+			},
+		},
+		// bad requests
+		{
+			status: http.StatusBadRequest,
+			resp:   TranslateResponse{Report: []Entry{}, Error: ErrNoConfig.Error()},
+		},
+		{
+			config: strings.Repeat("#", 40000),
+			status: http.StatusRequestEntityTooLarge,
+			resp:   TranslateResponse{Report: []Entry{}, Error: "http: request body too large"},
+		},
+		{
+			config:  localConfig + "x.txt\n",
+			archive: bigArchive.Bytes(),
+			status:  http.StatusRequestEntityTooLarge,
+			resp:    TranslateResponse{Report: []Entry{}, Error: "reading files archive: " + archivefs.ErrTooLarge.Error()},
+		},
+	}
+
+	handler := Handler(Options{
+		MaxRequestSize: 32 << 10,
+		MaxFilesSize:   1000,
+		Timeout:        time.Minute,
+	})
+	for _, test := range tests {
+		var body bytes.Buffer
+		contentType := "application/yaml"
+		if test.archive != nil {
+			mw := multipart.NewWriter(&body)
+			for name, data := range map[string][]byte{"config": []byte(test.config), "files": test.archive} {
+				part, err := mw.CreateFormFile(name, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := part.Write(data); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := mw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			contentType = mw.FormDataContentType()
+		} else {
+			body.WriteString(test.config)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/v1/translate"+test.query, &body)
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, test.status, rec.Code, "bad status")
+		var resp TranslateResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, test.resp, resp, "bad response")
+	}
+}
+
+func TestTranslateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/v1/translate", strings.NewReader("variant: fcos\nversion: 1.6.0\n"))
+	rec := httptest.NewRecorder()
+	translateHandler{Options{MaxRequestSize: 1000, MaxFilesSize: 1000, Timeout: time.Minute}}.ServeHTTP(rec, req)
+	var resp TranslateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, context.Canceled.Error(), resp.Error, "translation not canceled")
+}
+
+func TestVersions(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(Options{MaxRequestSize: 1000, Timeout: time.Minute}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/versions", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "bad status")
+	var resp VersionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"1.0.0", "1.1.0", "1.2.0-experimental"}, resp.Variants["flatcar"], "bad flatcar versions")
+	assert.NotContains(t, resp.Variants, "rhcos", "removed variant listed")
+}
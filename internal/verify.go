@@ -0,0 +1,95 @@
+// Copyright 2026 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.)
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/coreos/ignition/v2/config/v3_7_experimental/types"
+	"github.com/spf13/pflag"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/internal/render"
+)
+
+// verifyMain implements "butane verify", which compares the files,
+// links, and units in the effective config to an existing root
+// filesystem.
+func verifyMain(args []string) {
+	var (
+		root     string
+		helpFlag bool
+		cf       commonFlags
+	)
+	flags := pflag.NewFlagSet("verify", pflag.ExitOnError)
+	flags.BoolVarP(&helpFlag, "help", "h", false, "show usage and exit")
+	flags.StringVar(&root, "root", "", "compare against root filesystem mounted at `DIR`")
+	cf.register(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s verify --root DIR [options] [input-file]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Report differences between the files, directories, links, and systemd\n")
+		fmt.Fprintf(flags.Output(), "units in a config and an existing root filesystem.\n")
+		fmt.Fprintf(flags.Output(), "Options:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if helpFlag {
+		flags.SetOutput(os.Stdout)
+		flags.Usage()
+		os.Exit(0)
+	}
+	var input string
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		input = flags.Arg(0)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if root == "" {
+		fail("--root must be specified\n")
+	}
+
+	cf.finish()
+	dataIn, filename := readInput(input)
+	dataOut, sourceMap, r, err := config.Flatten(dataIn, cf.options)
+	cf.printReport(r, err, filename, dataIn)
+
+	var cfg types.Config
+	if err := json.Unmarshal(dataOut, &cfg); err != nil {
+		fail("failed to parse translated config: %v\n", err)
+	}
+	tree, err := render.Render(cfg)
+	if err != nil {
+		fail("Error rendering config: %v\n", err)
+	}
+	printSkipped(tree.Skipped, sourceMap, filename)
+	mismatches, err := tree.Verify(root)
+	if err != nil {
+		fail("failed to verify %s: %v\n", root, err)
+	}
+
+	for _, m := range mismatches {
+		fmt.Printf("%s: %s: %s\n", formatLocation(sourceMap, m.Source, filename), m.Path, m.Message)
+	}
+	if len(mismatches) > 0 {
+		os.Exit(1)
+	}
+}
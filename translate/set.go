@@ -15,9 +15,12 @@
 package translate
 
 import (
+	"cmp"
 	"fmt"
 	"reflect"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/coreos/vcontext/path"
@@ -39,10 +42,29 @@ func (t Translation) String() string {
 // of the destination path to the translation struct. The map is purely an optimization to allow fast lookups. Ideally the
 // map would just be from the destination path.ContextPath to the source path.ContextPath, but ContextPath contains a slice
 // which are not comparable and thus cannot be used as keys in maps.
+//
+// The map is also indexed by a trie of destination path elements, so
+// descending into or deleting a subtree doesn't scan the whole set.  If
+// Set is modified directly, the index is rebuilt on next use.
 type TranslationSet struct {
 	FromTag string
 	ToTag   string
 	Set     map[string]Translation
+	// nil unless the set was created by NewTranslationSet
+	index *index
+}
+
+type index struct {
+	root node
+	size int
+}
+
+// node is a vertex in the trie, corresponding to a destination path.
+type node struct {
+	set bool
+	// key of the translation in Set, if set
+	key      string
+	children map[interface{}]*node
 }
 
 func NewTranslationSet(fromTag, toTag string) TranslationSet {
@@ -50,9 +72,238 @@ func NewTranslationSet(fromTag, toTag string) TranslationSet {
 		FromTag: fromTag,
 		ToTag:   toTag,
 		Set:     map[string]Translation{},
+		index:   &index{},
+	}
+}
+
+// key normalizes a path element for use as a trie index.  Paths are
+// compared by their string representation, so integers of any type index
+// the same child, as do reflect.Values and the values they hold.
+func key(e interface{}) interface{} {
+	switch v := e.(type) {
+	case string, int:
+		return e
+	case reflect.Value:
+		if v.IsValid() && v.CanInterface() {
+			return key(v.Interface())
+		}
+	}
+	v := reflect.ValueOf(e)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int(v.Uint())
+	case reflect.String:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", e)
+}
+
+// compareKeys orders integer keys numerically before string keys.
+func compareKeys(a, b interface{}) int {
+	ai, aInt := a.(int)
+	bi, bInt := b.(int)
+	switch {
+	case aInt && bInt:
+		return cmp.Compare(ai, bi)
+	case aInt:
+		return -1
+	case bInt:
+		return 1
+	}
+	return cmp.Compare(a.(string), b.(string))
+}
+
+func (n *node) child(e interface{}) *node {
+	return n.children[key(e)]
+}
+
+func (n *node) ensureChild(e interface{}) *node {
+	k := key(e)
+	if c, ok := n.children[k]; ok {
+		return c
+	}
+	if n.children == nil {
+		n.children = make(map[interface{}]*node)
+	}
+	c := &node{}
+	n.children[k] = c
+	return c
+}
+
+func (n *node) empty() bool {
+	return !n.set && len(n.children) == 0
+}
+
+// keys appends the Set keys of the translations in the subtree rooted at
+// n to ret, in path order.
+func (n *node) keys(ret []string) []string {
+	if n.set {
+		ret = append(ret, n.key)
+	}
+	children := make([]interface{}, 0, len(n.children))
+	for k := range n.children {
+		children = append(children, k)
+	}
+	slices.SortFunc(children, compareKeys)
+	for _, k := range children {
+		ret = n.children[k].keys(ret)
+	}
+	return ret
+}
+
+// count returns the number of translations in the subtree rooted at n.
+func (n *node) count() int {
+	ret := 0
+	if n.set {
+		ret++
+	}
+	for _, c := range n.children {
+		ret += c.count()
+	}
+	return ret
+}
+
+// lookup returns the node at to, or nil.
+func (x *index) lookup(to []interface{}) *node {
+	n := &x.root
+	for _, e := range to {
+		if n = n.child(e); n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// add records that the translation to to is stored under k.
+func (x *index) add(to []interface{}, k string) {
+	n := &x.root
+	for _, e := range to {
+		n = n.ensureChild(e)
+	}
+	if !n.set {
+		n.set = true
+		x.size++
+	}
+	n.key = k
+}
+
+// remove drops the entry for to, and, if subtree is set, the entries for
+// the paths below it.
+func (x *index) remove(to []interface{}, subtree bool) {
+	// record the route so empty nodes can be pruned afterward
+	route := []*node{&x.root}
+	for _, e := range to {
+		c := route[len(route)-1].child(e)
+		if c == nil {
+			return
+		}
+		route = append(route, c)
+	}
+	n := route[len(route)-1]
+	if subtree {
+		x.size -= n.count()
+		n.children = nil
+	} else if n.set {
+		x.size--
+	}
+	n.set = false
+	n.key = ""
+	for i := len(to) - 1; i >= 0 && route[i+1].empty(); i-- {
+		parent := route[i]
+		delete(parent.children, key(to[i]))
+		if len(parent.children) == 0 {
+			parent.children = nil
+		}
+	}
+}
+
+func (x *index) rebuild(set map[string]Translation) {
+	x.root = node{}
+	x.size = 0
+	for k, t := range set {
+		x.add(t.To.Path, k)
 	}
 }
 
+// indexed returns an up-to-date index of ts.Set.
+func (ts TranslationSet) indexed() *index {
+	if ts.index == nil {
+		x := &index{}
+		x.rebuild(ts.Set)
+		return x
+	}
+	if ts.index.size != len(ts.Set) {
+		ts.index.rebuild(ts.Set)
+	}
+	return ts.index
+}
+
+// subtreeKeys returns the Set keys of the translations to to and the
+// paths below it, ordered by To path.
+func (ts TranslationSet) subtreeKeys(to []interface{}) []string {
+	x := ts.indexed()
+	for {
+		n := x.lookup(to)
+		if n == nil {
+			return nil
+		}
+		keys := n.keys(nil)
+		stale := false
+		for _, k := range keys {
+			if _, ok := ts.Set[k]; !ok {
+				stale = true
+				break
+			}
+		}
+		if !stale {
+			return keys
+		}
+		// entries were replaced in Set directly
+		x.rebuild(ts.Set)
+	}
+}
+
+// pathString is equivalent to p.String(), but avoids fmt for the common
+// element types.
+func pathString(p path.ContextPath) string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, e := range p.Path {
+		b.WriteByte('.')
+		switch v := e.(type) {
+		case string:
+			b.WriteString(v)
+		case int:
+			b.WriteString(strconv.Itoa(v))
+		default:
+			fmt.Fprintf(&b, "%v", e)
+		}
+	}
+	return b.String()
+}
+
+// store adds t to the set.  The paths in t must not be modified
+// afterward.
+func (ts TranslationSet) store(t Translation) {
+	k := pathString(t.To)
+	ts.Set[k] = t
+	if ts.index != nil {
+		ts.index.add(t.To.Path, k)
+	}
+}
+
+// prefixed returns p with prefix prepended, sharing no state with either.
+func prefixed(prefix, p path.ContextPath) path.ContextPath {
+	ret := path.ContextPath{
+		Path: make([]interface{}, 0, len(prefix.Path)+len(p.Path)),
+		Tag:  prefix.Tag,
+	}
+	ret.Path = append(append(ret.Path, prefix.Path...), p.Path...)
+	return ret
+}
+
 func (ts TranslationSet) String() string {
 	type entry struct {
 		sortKey   string
@@ -80,48 +331,95 @@ func (ts TranslationSet) String() string {
 	return str
 }
 
+// Len returns the number of translations in the set.
+func (ts TranslationSet) Len() int {
+	return len(ts.Set)
+}
+
+// Get returns the translation to the specified To path, if there is one.
+// The tag of to is ignored.
+func (ts TranslationSet) Get(to path.ContextPath) (Translation, bool) {
+	t, ok := ts.Set[pathString(to)]
+	return t, ok
+}
+
+// Translations returns all of the translations in the set, ordered by To
+// path.  List indexes sort numerically.
+func (ts TranslationSet) Translations() []Translation {
+	keys := ts.subtreeKeys(nil)
+	ret := make([]Translation, 0, len(keys))
+	for _, k := range keys {
+		ret = append(ret, ts.Set[k])
+	}
+	return ret
+}
+
 // AddTranslation adds a translation to the set
 func (ts TranslationSet) AddTranslation(from, to path.ContextPath) {
 	// create copies of the paths so if someone else changes from.Path the added translation does not change.
-	from = from.Copy()
-	to = to.Copy()
-	translation := Translation{
-		From: from,
-		To:   to,
+	ts.store(Translation{
+		From: from.Copy(),
+		To:   to.Copy(),
+	})
+}
+
+// Delete removes the translation to the specified To path, if any.
+// Translations to paths below it are kept.
+func (ts TranslationSet) Delete(to path.ContextPath) {
+	delete(ts.Set, pathString(to))
+	if ts.index != nil {
+		ts.index.remove(to.Path, false)
+	}
+}
+
+// DeleteSubtree removes the translation to the specified To path, and all
+// translations to paths below it.
+func (ts TranslationSet) DeleteSubtree(to path.ContextPath) {
+	for _, k := range ts.subtreeKeys(to.Path) {
+		delete(ts.Set, k)
+	}
+	if ts.index != nil {
+		ts.index.remove(to.Path, true)
 	}
-	toString := translation.To.String()
-	ts.Set[toString] = translation
 }
 
 // AddFromCommonSource adds translations for all of the paths in to from a single common path. This is useful
 // if one part of a config generates a large struct and all of the large struct should map to one path in the
 // config being translated.
 func (ts TranslationSet) AddFromCommonSource(common path.ContextPath, toPrefix path.ContextPath, to interface{}) {
-	v := reflect.ValueOf(to)
-	vPaths := prefixPaths(getAllPaths(v, ts.ToTag, true), toPrefix.Path...)
-	for _, toPath := range vPaths {
-		ts.AddTranslation(common, toPath)
-	}
-	ts.AddTranslation(common, toPrefix)
+	// all the translations share one copy of the source path
+	from := common.Copy()
+	prefix := toPrefix.Path[:len(toPrefix.Path):len(toPrefix.Path)]
+	forEachPath(reflect.ValueOf(to), ts.ToTag, true, prefix, func(p []interface{}) {
+		ts.store(Translation{
+			From: from,
+			To:   path.New(ts.ToTag, p...).Copy(),
+		})
+	})
+	ts.store(Translation{
+		From: from,
+		To:   toPrefix.Copy(),
+	})
 }
 
 // AddFromCommonObject adds translations for all of the paths in to. The paths being translated
 // are prefixed by fromPrefix and the translated paths are prefixed by toPrefix.
 // This is useful when we want to copy all the fields of an object to another with the same field names.
 func (ts TranslationSet) AddFromCommonObject(fromPrefix path.ContextPath, toPrefix path.ContextPath, to interface{}) {
-	vTo := reflect.ValueOf(to)
-	vPaths := getAllPaths(vTo, ts.ToTag, true)
-
-	for _, path := range vPaths {
-		ts.AddTranslation(fromPrefix.Append(path.Path...), toPrefix.Append(path.Path...))
-	}
+	forEachPath(reflect.ValueOf(to), ts.ToTag, true, nil, func(p []interface{}) {
+		rel := path.New("", p...)
+		ts.store(Translation{
+			From: prefixed(fromPrefix, rel),
+			To:   prefixed(toPrefix, rel),
+		})
+	})
 	ts.AddTranslation(fromPrefix, toPrefix)
 }
 
 // Merge adds all the entries to the set. It mutates the Set in place.
 func (ts TranslationSet) Merge(from TranslationSet) {
 	for _, t := range from.Set {
-		ts.AddTranslation(t.From, t.To)
+		ts.store(t)
 	}
 }
 
@@ -133,8 +431,25 @@ func (ts TranslationSet) MergeP(prefix interface{}, from TranslationSet) {
 // MergeP2 is like Merge, but it adds distinct prefixes to each side of the
 // set being merged in.
 func (ts TranslationSet) MergeP2(fromPrefix interface{}, toPrefix interface{}, from TranslationSet) {
-	from = from.PrefixPaths(path.New(from.FromTag, fromPrefix), path.New(from.ToTag, toPrefix))
-	ts.Merge(from)
+	ts.mergePaths(path.New(from.FromTag, fromPrefix), path.New(from.ToTag, toPrefix), from)
+}
+
+// mergePaths is equivalent to ts.Merge(from.PrefixPaths(fromPrefix,
+// toPrefix)), without building the intermediate set.
+func (ts TranslationSet) mergePaths(fromPrefix, toPrefix path.ContextPath, from TranslationSet) {
+	if len(from.Set) == 0 {
+		return
+	}
+	if reflect.ValueOf(from.Set).Pointer() == reflect.ValueOf(ts.Set).Pointer() {
+		// don't iterate over the map while adding to it
+		from = from.PrefixPaths(path.New(fromPrefix.Tag), path.New(toPrefix.Tag))
+	}
+	for _, t := range from.Set {
+		ts.store(Translation{
+			From: prefixed(fromPrefix, t.From),
+			To:   prefixed(toPrefix, t.To),
+		})
+	}
 }
 
 // Prefix returns a TranslationSet with all translation paths prefixed by prefix.
@@ -146,29 +461,19 @@ func (ts TranslationSet) Prefix(prefix interface{}) TranslationSet {
 // fromPrefix and to translation paths prefixed by toPrefix.
 func (ts TranslationSet) PrefixPaths(fromPrefix, toPrefix path.ContextPath) TranslationSet {
 	ret := NewTranslationSet(ts.FromTag, ts.ToTag)
-	for _, tr := range ts.Set {
-		ret.AddTranslation(fromPrefix.Append(tr.From.Path...), toPrefix.Append(tr.To.Path...))
-	}
+	ret.mergePaths(fromPrefix, toPrefix, ts)
 	return ret
 }
 
 // Descend returns the subtree of translations rooted at the specified To path.
 func (ts TranslationSet) Descend(to path.ContextPath) TranslationSet {
 	ret := NewTranslationSet(ts.FromTag, ts.ToTag)
-OUTER:
-	for _, tr := range ts.Set {
-		if len(tr.To.Path) < len(to.Path) {
-			// can't be in the requested subtree; skip
-			continue
-		}
-		for i, e := range to.Path {
-			if tr.To.Path[i] != e {
-				// not in the requested subtree; skip
-				continue OUTER
-			}
-		}
-		subtreePath := path.New(tr.To.Tag, tr.To.Path[len(to.Path):]...)
-		ret.AddTranslation(tr.From, subtreePath)
+	for _, k := range ts.subtreeKeys(to.Path) {
+		tr := ts.Set[k]
+		ret.store(Translation{
+			From: tr.From,
+			To:   path.New(tr.To.Tag, tr.To.Path[len(to.Path):]...).Copy(),
+		})
 	}
 	return ret
 }
@@ -182,9 +487,9 @@ func (ts TranslationSet) Map(mappings TranslationSet) TranslationSet {
 	}
 	ret := NewTranslationSet(ts.FromTag, ts.ToTag)
 	ret.Merge(ts)
-	for _, mapping := range mappings.Set {
-		if t, ok := ret.Set[mapping.From.String()]; ok {
-			delete(ret.Set, mapping.From.String())
+	for _, mapping := range mappings.Translations() {
+		if t, ok := ret.Get(mapping.From); ok {
+			ret.Delete(mapping.From)
 			ret.AddTranslation(t.From, mapping.To)
 		}
 	}
@@ -196,11 +501,12 @@ func (ts TranslationSet) Map(mappings TranslationSet) TranslationSet {
 // error listing them.
 func (ts TranslationSet) DebugVerifyCoverage(v interface{}) error {
 	var missingPaths []string
-	for _, pathToCheck := range getAllPaths(reflect.ValueOf(v), ts.ToTag, false) {
-		if _, ok := ts.Set[pathToCheck.String()]; !ok {
-			missingPaths = append(missingPaths, pathToCheck.String())
+	forEachPath(reflect.ValueOf(v), ts.ToTag, false, nil, func(p []interface{}) {
+		pathToCheck := pathString(path.New(ts.ToTag, p...))
+		if _, ok := ts.Set[pathToCheck]; !ok {
+			missingPaths = append(missingPaths, pathToCheck)
 		}
-	}
+	})
 	if len(missingPaths) > 0 {
 		return fmt.Errorf("missing paths in TranslationSet:\n%v", strings.Join(missingPaths, "\n"))
 	}
@@ -15,6 +15,7 @@
 package translate
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/coreos/vcontext/path"
@@ -25,7 +26,7 @@ import (
 // mkTrans(from1, to1, from2, to2) -> a set wiht from1->to1, from2->to2
 // This is just a shorthand for making writing tests easier
 func mkTrans(paths ...path.ContextPath) TranslationSet {
-	ret := TranslationSet{Set: map[string]Translation{}}
+	ret := NewTranslationSet("", "")
 	if len(paths)%2 == 1 {
 		panic("Odd number of args to mkTrans")
 	}
@@ -98,3 +99,206 @@ func TestTranslationSetAddFromCommonSource(t *testing.T) {
 	actual.AddFromCommonObject(path.New("yaml", "y"), path.New("json", "z", 0), &Main{})
 	assert.Equal(t, expected, actual)
 }
+
+func TestTranslationSetAccessors(t *testing.T) {
+	ts := mkTrans(
+		fp("a"), fp("A"),
+		fp("a", 10), fp("A", 10),
+		fp("a", 2), fp("A", 2),
+		fp("a", 2, "b"), fp("A", 2, "B"),
+		fp("c"), fp("C"),
+	)
+	assert.Equal(t, 5, ts.Len())
+	tr, ok := ts.Get(path.New("other", "A", 2, "B"))
+	assert.True(t, ok)
+	assert.Equal(t, Translation{From: fp("a", 2, "b"), To: fp("A", 2, "B")}, tr)
+	tr, ok = ts.Get(fp("A", int64(10)))
+	assert.True(t, ok, "integer types differ")
+	assert.Equal(t, fp("a", 10), tr.From)
+	_, ok = ts.Get(fp("A", 3))
+	assert.False(t, ok)
+	_, ok = ts.Get(fp())
+	assert.False(t, ok)
+
+	// list indexes sort numerically
+	assert.Equal(t, []Translation{
+		{From: fp("a"), To: fp("A")},
+		{From: fp("a", 2), To: fp("A", 2)},
+		{From: fp("a", 2, "b"), To: fp("A", 2, "B")},
+		{From: fp("a", 10), To: fp("A", 10)},
+		{From: fp("c"), To: fp("C")},
+	}, ts.Translations())
+
+	ts.Delete(fp("A", 2))
+	assert.Equal(t, mkTrans(
+		fp("a"), fp("A"),
+		fp("a", 10), fp("A", 10),
+		fp("a", 2, "b"), fp("A", 2, "B"),
+		fp("c"), fp("C"),
+	), ts, "bad Delete")
+	ts.DeleteSubtree(fp("A"))
+	ts.DeleteSubtree(fp("missing"))
+	assert.Equal(t, mkTrans(
+		fp("c"), fp("C"),
+	), ts, "bad DeleteSubtree")
+	assert.Equal(t, 1, ts.Len())
+
+	// direct changes to Set are seen by the other methods
+	ts = mkTrans(
+		fp("a"), fp("A"),
+		fp("a", 0), fp("A", 0),
+	)
+	delete(ts.Set, fp("A", 0).String())
+	ts.Set[fp("A", 1).String()] = Translation{From: fp("b"), To: fp("A", 1)}
+	assert.Equal(t, []Translation{
+		{From: fp("a"), To: fp("A")},
+		{From: fp("b"), To: fp("A", 1)},
+	}, ts.Translations())
+	assert.Equal(t, mkTrans(
+		fp("a"), fp(),
+		fp("b"), fp(1),
+	), ts.Descend(fp("A")), "bad Descend")
+	delete(ts.Set, fp("A").String())
+	ts.DeleteSubtree(fp("A"))
+	assert.Equal(t, 0, ts.Len())
+
+	// the zero value is empty
+	var zero TranslationSet
+	assert.Equal(t, 0, zero.Len())
+	assert.Empty(t, zero.Translations())
+	_, ok = zero.Get(fp())
+	assert.False(t, ok)
+	zero.Delete(fp("a"))
+	merged := NewTranslationSet("", "")
+	merged.Merge(zero)
+	merged.MergeP("x", zero)
+	assert.Equal(t, NewTranslationSet("", ""), merged)
+}
+
+func TestTranslationSetPrefixDescend(t *testing.T) {
+	ts := NewTranslationSet("yaml", "json")
+	ts.AddTranslation(path.New("yaml", "a"), path.New("json", "b"))
+	ts.AddTranslation(path.New("yaml", "a", 0), path.New("json", "b", 0))
+	ts.AddTranslation(path.New("yaml", "c"), path.New("json", "d", "e"))
+
+	expected := NewTranslationSet("yaml", "json")
+	expected.AddTranslation(path.New("yaml", "x", "a"), path.New("json", "y", "z", "b"))
+	expected.AddTranslation(path.New("yaml", "x", "a", 0), path.New("json", "y", "z", "b", 0))
+	expected.AddTranslation(path.New("yaml", "x", "c"), path.New("json", "y", "z", "d", "e"))
+	assert.Equal(t, expected, ts.PrefixPaths(path.New("yaml", "x"), path.New("json", "y", "z")))
+
+	merged := NewTranslationSet("yaml", "json")
+	merged.AddTranslation(path.New("yaml", "old"), path.New("json", "y", "z", "b"))
+	merged.MergeP2("x", "y", ts.Prefix("z"))
+	expected.AddTranslation(path.New("yaml", "x", "z", "a"), path.New("json", "y", "z", "b"))
+	expected.AddTranslation(path.New("yaml", "x", "z", "a", 0), path.New("json", "y", "z", "b", 0))
+	expected.AddTranslation(path.New("yaml", "x", "z", "c"), path.New("json", "y", "z", "d", "e"))
+	assert.Equal(t, expected, merged)
+
+	descended := merged.Descend(path.New("json", "y", "z"))
+	assert.Equal(t, ts.PrefixPaths(path.New("yaml", "x", "z"), path.New("json")), descended)
+	// modifying the result doesn't affect the original
+	descended.DeleteSubtree(path.New("json", "b"))
+	_, ok := merged.Get(path.New("json", "y", "z", "b", 0))
+	assert.True(t, ok)
+}
+
+type benchNode struct {
+	Path     string  `json:"path"`
+	Mode     *int    `json:"mode"`
+	Contents string  `json:"contents"`
+	User     *string `json:"user"`
+}
+
+type benchConfig struct {
+	Nodes []benchNode `json:"nodes"`
+}
+
+func makeBenchConfig(count int) benchConfig {
+	var cfg benchConfig
+	mode := 0644
+	user := "core"
+	for i := 0; i < count; i++ {
+		cfg.Nodes = append(cfg.Nodes, benchNode{
+			Path:     fmt.Sprintf("/etc/file-%d", i),
+			Mode:     &mode,
+			Contents: "data:,x",
+			User:     &user,
+		})
+	}
+	return cfg
+}
+
+// makeBenchSet returns a set covering count generated nodes, built the way
+// desugaring code builds them.
+func makeBenchSet(count int) TranslationSet {
+	ts := NewTranslationSet("yaml", "json")
+	for i, node := range makeBenchConfig(count).Nodes {
+		ts.AddFromCommonSource(path.New("yaml", "trees", i%10), path.New("json", "nodes", i), node)
+	}
+	return ts
+}
+
+func BenchmarkTranslationSetAddFromCommonSource(b *testing.B) {
+	nodes := makeBenchConfig(10000).Nodes
+	b.ReportAllocs()
+	for b.Loop() {
+		ts := NewTranslationSet("yaml", "json")
+		for i, node := range nodes {
+			ts.AddFromCommonSource(path.New("yaml", "trees", i%10), path.New("json", "nodes", i), node)
+		}
+	}
+}
+
+func BenchmarkTranslationSetMergeP(b *testing.B) {
+	ts := makeBenchSet(10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		ret := NewTranslationSet("yaml", "json")
+		ret.MergeP("storage", ts)
+	}
+}
+
+func BenchmarkTranslationSetDescend(b *testing.B) {
+	ts := makeBenchSet(10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := 0; i < 100; i++ {
+			ts.Descend(path.New("json", "nodes", i))
+		}
+	}
+}
+
+func BenchmarkTranslationSetMap(b *testing.B) {
+	ts := makeBenchSet(10000)
+	mappings := NewTranslationSet("json", "json")
+	for i := 0; i < 10000; i += 2 {
+		mappings.AddTranslation(path.New("json", "nodes", i, "path"), path.New("json", "nodes", i, "contents"))
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		ts.Map(mappings)
+	}
+}
+
+func BenchmarkTranslationSetDebugVerifyCoverage(b *testing.B) {
+	cfg := makeBenchConfig(10000)
+	ts := NewTranslationSet("yaml", "json")
+	ts.AddFromCommonSource(path.New("yaml"), path.New("json"), cfg)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := ts.DebugVerifyCoverage(cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTranslator(b *testing.B) {
+	cfg := makeBenchConfig(10000)
+	tr := NewTranslator("yaml", "json", struct{}{})
+	b.ReportAllocs()
+	for b.Loop() {
+		var out benchConfig
+		tr.Translate(&cfg, &out)
+	}
+}
@@ -181,8 +181,8 @@ func (t translator) translate(vFrom, vTo reflect.Value, fromPath, toPath path.Co
 
 		// handle all the translations and "rebase" them to our current place
 		retSet := returns[1].Interface().(TranslationSet)
-		t.translations.Merge(retSet.PrefixPaths(fromPath, toPath))
-		if len(retSet.Set) > 0 {
+		t.translations.mergePaths(fromPath, toPath, retSet)
+		if retSet.Len() > 0 {
 			t.translations.AddTranslation(fromPath, toPath)
 		}
 
@@ -217,12 +217,8 @@ type Translator interface {
 // the TranslationSet returned by Translator.Translate()
 func NewTranslator(fromTag, toTag string, options interface{}) Translator {
 	return &translator{
-		options: options,
-		translations: TranslationSet{
-			FromTag: fromTag,
-			ToTag:   toTag,
-			Set:     map[string]Translation{},
-		},
+		options:      options,
+		translations: NewTranslationSet(fromTag, toTag),
 	}
 }
 
@@ -265,11 +261,7 @@ func (t translator) Translate(from, to interface{}) (TranslationSet, report.Repo
 	fv = fv.Elem()
 	tv = tv.Elem()
 	// Make sure to clear these every time
-	t.translations = TranslationSet{
-		FromTag: t.translations.FromTag,
-		ToTag:   t.translations.ToTag,
-		Set:     map[string]Translation{},
-	}
+	t.translations = NewTranslationSet(t.translations.FromTag, t.translations.ToTag)
 	t.report = &report.Report{}
 	t.translate(fv, tv, path.New(t.translations.FromTag), path.New(t.translations.ToTag))
 	return t.translations, *t.report
@@ -33,67 +33,55 @@ func fieldName(t reflect.Value, index int, tag string) string {
 	return strings.Split(f.Tag.Get(tag), ",")[0]
 }
 
-func prefixPath(p path.ContextPath, prefix ...interface{}) path.ContextPath {
-	return path.New(p.Tag, prefix...).Append(p.Path...)
-}
-
-func prefixPaths(ps []path.ContextPath, prefix ...interface{}) []path.ContextPath {
-	ret := []path.ContextPath{}
-	for _, p := range ps {
-		ret = append(ret, prefixPath(p, prefix...))
-	}
-	return ret
-}
-
-func getAllPaths(v reflect.Value, tag string, includeZeroFields bool) []path.ContextPath {
+// forEachPath calls fn with the path, relative to v and appended to
+// prefix, of each field and list or map entry in v.  Children are visited
+// before their parents.  The path passed to fn is only valid until fn
+// returns.
+func forEachPath(v reflect.Value, tag string, includeZeroFields bool, prefix []interface{}, fn func([]interface{})) {
 	k := v.Kind()
 	t := v.Type()
 	switch {
 	case util.IsPrimitive(k):
-		return nil
+		return
 	case k == reflect.Ptr:
 		if v.IsNil() {
-			return nil
+			return
 		}
-		return getAllPaths(v.Elem(), tag, includeZeroFields)
+		forEachPath(v.Elem(), tag, includeZeroFields, prefix, fn)
 	case k == reflect.Slice:
-		ret := []path.ContextPath{}
 		for i := 0; i < v.Len(); i++ {
+			p := append(prefix, i)
 			// for struct, pointer to struct, etc., add any children
-			ret = append(ret, prefixPaths(getAllPaths(v.Index(i), tag, includeZeroFields), i)...)
+			forEachPath(v.Index(i), tag, includeZeroFields, p, fn)
 			// add slice entry
-			ret = append(ret, path.New(tag, i))
+			fn(p)
 		}
-		return ret
 	case k == reflect.Struct:
-		ret := []path.ContextPath{}
 		for i := 0; i < t.NumField(); i++ {
-			name := fieldName(v, i, tag)
 			field := v.Field(i)
 			if !includeZeroFields && field.IsZero() {
 				continue
 			}
 			if t.Field(i).Anonymous {
-				ret = append(ret, getAllPaths(field, tag, includeZeroFields)...)
+				forEachPath(field, tag, includeZeroFields, prefix, fn)
 			} else {
-				ret = append(ret, prefixPaths(getAllPaths(field, tag, includeZeroFields), name)...)
-				ret = append(ret, path.New(tag, name))
+				p := append(prefix, fieldName(v, i, tag))
+				forEachPath(field, tag, includeZeroFields, p, fn)
+				fn(p)
 			}
 		}
-		return ret
 	case k == reflect.Map:
 		// we don't have these in Butane or Ignition configs, but
 		// we need to support validating translations of
 		// metadata.labels in MachineConfig output
-		ret := []path.ContextPath{}
 		iter := v.MapRange()
 		for iter.Next() {
+			p := append(prefix, iter.Key().Interface())
 			// for struct, pointer to struct, etc., add any children
-			ret = append(ret, prefixPaths(getAllPaths(iter.Value(), tag, includeZeroFields), iter.Key())...)
+			forEachPath(iter.Value(), tag, includeZeroFields, p, fn)
 			// add map entry
-			ret = append(ret, path.New(tag, iter.Key()))
+			fn(p)
 		}
-		return ret
 	default:
 		panic("Encountered unexpected type. This is a bug, please file a report")
 	}